@@ -0,0 +1,171 @@
+// Package budget shares the requests a process sends at once between the
+// runs it has going.
+package budget
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Budget is a number of slots, one per request in flight, that the runs
+// take turns on. A slot that is given back goes to the waiting request of
+// the highest priority, then to the one of the run that holds the fewest
+// slots, then to the one that waits the longest. A run alone can use all
+// the slots.
+type Budget struct {
+	mu      sync.Mutex
+	free    int
+	waiters []*waiter
+}
+
+// Share is the part of a run in the budget. Its methods do nothing on a nil
+// Share, for the runs that do not share a budget.
+type Share struct {
+	budget   *Budget
+	priority int
+	used     int
+	closed   bool
+}
+
+type waiter struct {
+	share *Share
+	ready chan struct{}
+}
+
+// New returns a budget of size slots.
+func New(size int) *Budget {
+	return &Budget{free: max(size, 1)}
+}
+
+// Share returns the share of a run of the priority, higher first.
+func (b *Budget) Share(priority int) *Share {
+	return &Share{budget: b, priority: priority}
+}
+
+// Acquire waits for a slot. It returns the error of the context when it is
+// done first.
+func (s *Share) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	b := s.budget
+	w := waiter{share: s, ready: make(chan struct{})}
+
+	b.mu.Lock()
+
+	if s.closed {
+		b.mu.Unlock()
+
+		return nil
+	}
+
+	b.waiters = append(b.waiters, &w)
+	b.grant()
+	b.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		// granted meanwhile
+		s.release()
+	default:
+		b.waiters = slices.DeleteFunc(b.waiters, func(other *waiter) bool {
+			return other == &w
+		})
+	}
+
+	return ctx.Err()
+}
+
+// Release gives a slot back.
+func (s *Share) Release() {
+	if s == nil {
+		return
+	}
+
+	s.budget.mu.Lock()
+	defer s.budget.mu.Unlock()
+
+	s.release()
+}
+
+// Close gives back the slots of a run that is done, including the ones of
+// the requests that did not get to release theirs. The share is not
+// counted against the budget afterwards.
+func (s *Share) Close() {
+	if s == nil {
+		return
+	}
+
+	b := s.budget
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s.closed = true
+
+	for _, w := range b.waiters {
+		if w.share == s {
+			close(w.ready)
+		}
+	}
+
+	b.waiters = slices.DeleteFunc(b.waiters, func(w *waiter) bool {
+		return w.share == s
+	})
+
+	b.free += s.used
+	s.used = 0
+
+	b.grant()
+}
+
+func (s *Share) release() {
+	if s.closed || s.used == 0 {
+		return
+	}
+
+	s.used--
+	s.budget.free++
+	s.budget.grant()
+}
+
+// grant gives the free slots to the waiters that come first.
+func (b *Budget) grant() {
+	for b.free > 0 && len(b.waiters) > 0 {
+		next := 0
+
+		for i := 1; i < len(b.waiters); i++ {
+			if b.waiters[i].before(b.waiters[next]) {
+				next = i
+			}
+		}
+
+		w := b.waiters[next]
+		b.waiters = slices.Delete(b.waiters, next, next+1)
+
+		b.free--
+		w.share.used++
+
+		close(w.ready)
+	}
+}
+
+// before reports whether w gets a slot before other, which waits longer.
+func (w *waiter) before(other *waiter) bool {
+	if w.share.priority != other.share.priority {
+		return w.share.priority > other.share.priority
+	}
+
+	return w.share.used < other.share.used
+}
@@ -0,0 +1,75 @@
+package budget_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/budget"
+)
+
+// queue starts a request of the share that waits for a slot, and returns
+// the channel its name is sent on once it has one.
+func queue(t *testing.T, s *budget.Share, name string, granted chan<- string) {
+	t.Helper()
+
+	go func() {
+		if s.Acquire(t.Context()) == nil {
+			granted <- name
+		}
+	}()
+
+	// the request is waiting before the next one is queued
+	time.Sleep(20 * time.Millisecond)
+}
+
+func Test_Budget(t *testing.T) {
+	b := budget.New(2)
+	granted := make(chan string, 10)
+
+	// a run alone uses all the slots
+	first := b.Share(0)
+	require.NoError(t, first.Acquire(t.Context()))
+	require.NoError(t, first.Acquire(t.Context()))
+
+	queue(t, first, "first", granted)
+	second := b.Share(0)
+	queue(t, second, "second", granted)
+
+	// the slot goes to the run with fewer slots, although it waits less
+	first.Release()
+	require.Equal(t, "second", <-granted)
+
+	// and then in turns
+	second.Release()
+	require.Equal(t, "first", <-granted)
+
+	// a higher priority goes first
+	queue(t, second, "second", granted)
+	urgent := b.Share(1)
+	queue(t, urgent, "urgent", granted)
+
+	first.Release()
+	require.Equal(t, "urgent", <-granted)
+
+	// the slots of a run that is done are given back
+	first.Close()
+	require.Equal(t, "second", <-granted)
+
+	// a request that can't wait gets no slot
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	require.ErrorIs(t, second.Acquire(ctx), context.Canceled)
+
+	urgent.Release()
+	require.NoError(t, second.Acquire(t.Context()))
+
+	var none *budget.Share
+
+	require.NoError(t, none.Acquire(t.Context()))
+	none.Release()
+	none.Close()
+}
@@ -2,7 +2,9 @@ package deduper
 
 import (
 	"context"
+	"io"
 	"sync"
+	"time"
 )
 
 type Deduper interface {
@@ -15,3 +17,61 @@ func New() Deduper {
 		mux:  &sync.RWMutex{},
 	}
 }
+
+// Option configures the persistent dedupers.
+type Option func(*options)
+
+type options struct {
+	namespace string
+	ttl       time.Duration
+}
+
+// WithNamespace scopes the seen keys, e.g. to a job or a project.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithTTL makes keys seen longer than ttl ago count as new again.
+// A zero ttl keeps keys forever.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+func newOptions(opts []Option) options {
+	ans := options{namespace: "default"}
+
+	for _, opt := range opts {
+		opt(&ans)
+	}
+
+	return ans
+}
+
+// Committer is implemented by the persistent dedupers. AddIfNotExists only
+// claims a key for the run, Commit stores it for the later runs once its
+// place was written.
+type Committer interface {
+	Commit(ctx context.Context, keys ...string) error
+}
+
+// Commit stores the keys in dedupers that persist them.
+func Commit(ctx context.Context, d Deduper, keys ...string) error {
+	if c, ok := d.(Committer); ok {
+		return c.Commit(ctx, keys...)
+	}
+
+	return nil
+}
+
+// Close releases the resources of dedupers that hold any.
+func Close(d Deduper) error {
+	if c, ok := d.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
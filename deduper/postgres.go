@@ -0,0 +1,49 @@
+package deduper
+
+import (
+	"database/sql"
+
+	// postgres driver
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// NewPostgres returns a deduper that keeps the seen keys in the
+// gmaps_seen_keys table of the database at dsn.
+func NewPostgres(dsn string, opts ...Option) (Deduper, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(5)
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS gmaps_seen_keys (
+			namespace TEXT NOT NULL,
+			key TEXT NOT NULL,
+			seen_at BIGINT NOT NULL,
+			PRIMARY KEY (namespace, key)
+		)
+	`)
+	if err != nil {
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	const (
+		query  = `SELECT seen_at FROM gmaps_seen_keys WHERE namespace = $1 AND key = $2`
+		insert = `INSERT INTO gmaps_seen_keys (namespace, key, seen_at) VALUES ($1, $2, $3)
+		ON CONFLICT (namespace, key) DO UPDATE SET seen_at = excluded.seen_at`
+	)
+
+	ans := newSQLDeduper(db, opts, query, insert)
+
+	return ans, nil
+}
@@ -0,0 +1,122 @@
+package deduper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/common/logger"
+)
+
+var (
+	_ Deduper   = (*sqlDeduper)(nil)
+	_ Committer = (*sqlDeduper)(nil)
+)
+
+// sqlDeduper stores the seen keys in a SQL table shared by the SQLite and
+// Postgres backends. Only the placeholders of the queries differ.
+//
+// AddIfNotExists claims a key for the run only, in memory. The key is
+// stored once Commit is called for it, after its place was written, so a
+// place whose scrape failed is tried again by the next run.
+type sqlDeduper struct {
+	db     *sql.DB
+	opts   options
+	query  string
+	insert string
+
+	mu      sync.Mutex
+	claimed map[string]struct{}
+}
+
+func newSQLDeduper(db *sql.DB, opts []Option, query, insert string) *sqlDeduper {
+	return &sqlDeduper{
+		db:      db,
+		opts:    newOptions(opts),
+		query:   query,
+		insert:  insert,
+		claimed: make(map[string]struct{}),
+	}
+}
+
+func (d *sqlDeduper) AddIfNotExists(ctx context.Context, key string) bool {
+	d.mu.Lock()
+	_, ok := d.claimed[key]
+	d.mu.Unlock()
+
+	if ok {
+		return false
+	}
+
+	seen, err := d.seen(ctx, key)
+	if err != nil {
+		// scraping a place twice is better than losing it
+		logger.Warn("dedup lookup failed", "key", key, "error", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.claimed[key]; ok {
+		return false
+	}
+
+	d.claimed[key] = struct{}{}
+
+	return !seen
+}
+
+// Commit stores the keys, their places were written.
+func (d *sqlDeduper) Commit(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC().Unix()
+
+	for _, key := range keys {
+		if _, err := tx.ExecContext(ctx, d.insert, d.opts.namespace, key, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// seen reports whether the key was stored by a run and has not expired.
+func (d *sqlDeduper) seen(ctx context.Context, key string) (bool, error) {
+	var seenAt int64
+
+	err := d.db.QueryRowContext(ctx, d.query, d.opts.namespace, key).Scan(&seenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if d.opts.ttl <= 0 {
+		return true, nil
+	}
+
+	// keys seen before the cutoff are expired and get claimed again
+	cutoff := time.Now().UTC().Unix() - int64(d.opts.ttl.Seconds())
+
+	return seenAt >= cutoff, nil
+}
+
+func (d *sqlDeduper) Close() error {
+	return d.db.Close()
+}
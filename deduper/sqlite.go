@@ -0,0 +1,59 @@
+package deduper
+
+import (
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite" // sqlite driver
+)
+
+// NewSQLite returns a deduper that keeps the seen keys in a SQLite
+// database at path, so they survive restarts.
+func NewSQLite(path string, opts ...Option) (Deduper, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	pragmas := []string{
+		"PRAGMA busy_timeout = 5000",
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA synchronous=NORMAL",
+	}
+
+	for _, p := range pragmas {
+		if _, err := db.Exec(p); err != nil {
+			_ = db.Close()
+
+			return nil, err
+		}
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS seen_keys (
+			namespace TEXT NOT NULL,
+			key TEXT NOT NULL,
+			seen_at INT NOT NULL,
+			PRIMARY KEY (namespace, key)
+		)
+	`)
+	if err != nil {
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	const (
+		query  = `SELECT seen_at FROM seen_keys WHERE namespace = ? AND key = ?`
+		insert = `INSERT INTO seen_keys (namespace, key, seen_at) VALUES (?, ?, ?)
+		ON CONFLICT (namespace, key) DO UPDATE SET seen_at = excluded.seen_at`
+	)
+
+	ans := newSQLDeduper(db, opts, query, insert)
+
+	return ans, nil
+}
@@ -0,0 +1,90 @@
+package deduper_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/deduper"
+)
+
+func Test_SQLiteCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.db")
+
+	d, err := deduper.NewSQLite(path)
+	require.NoError(t, err)
+
+	require.True(t, d.AddIfNotExists(t.Context(), "written"))
+	require.True(t, d.AddIfNotExists(t.Context(), "failed"))
+
+	// claimed for the run
+	require.False(t, d.AddIfNotExists(t.Context(), "written"))
+	require.False(t, d.AddIfNotExists(t.Context(), "failed"))
+
+	require.NoError(t, deduper.Commit(t.Context(), d, "written"))
+	require.NoError(t, deduper.Close(d))
+
+	// the next run skips the written place only
+	d, err = deduper.NewSQLite(path)
+	require.NoError(t, err)
+
+	defer deduper.Close(d)
+
+	require.False(t, d.AddIfNotExists(t.Context(), "written"))
+	require.True(t, d.AddIfNotExists(t.Context(), "failed"))
+}
+
+func Test_SQLiteNamespace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.db")
+
+	a, err := deduper.NewSQLite(path, deduper.WithNamespace("a"))
+	require.NoError(t, err)
+
+	require.True(t, a.AddIfNotExists(t.Context(), "place"))
+	require.NoError(t, deduper.Commit(t.Context(), a, "place"))
+	require.NoError(t, deduper.Close(a))
+
+	b, err := deduper.NewSQLite(path, deduper.WithNamespace("b"))
+	require.NoError(t, err)
+
+	require.True(t, b.AddIfNotExists(t.Context(), "place"))
+	require.NoError(t, deduper.Close(b))
+
+	a, err = deduper.NewSQLite(path, deduper.WithNamespace("a"))
+	require.NoError(t, err)
+
+	defer deduper.Close(a)
+
+	require.False(t, a.AddIfNotExists(t.Context(), "place"))
+}
+
+func Test_SQLiteTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.db")
+
+	d, err := deduper.NewSQLite(path)
+	require.NoError(t, err)
+
+	require.True(t, d.AddIfNotExists(t.Context(), "place"))
+	require.NoError(t, deduper.Commit(t.Context(), d, "place"))
+	require.NoError(t, deduper.Close(d))
+
+	// seen_at has a precision of one second
+	time.Sleep(1100 * time.Millisecond)
+
+	kept, err := deduper.NewSQLite(path, deduper.WithTTL(time.Hour))
+	require.NoError(t, err)
+
+	defer deduper.Close(kept)
+
+	require.False(t, kept.AddIfNotExists(t.Context(), "place"))
+
+	expired, err := deduper.NewSQLite(path, deduper.WithTTL(time.Nanosecond))
+	require.NoError(t, err)
+
+	defer deduper.Close(expired)
+
+	require.True(t, expired.AddIfNotExists(t.Context(), "place"))
+	require.False(t, expired.AddIfNotExists(t.Context(), "place"))
+}
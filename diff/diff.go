@@ -0,0 +1,219 @@
+// Package diff compares two crawls of the same queries and reports the
+// places that were added, removed or changed between them.
+package diff
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Fields are the columns of the results that are compared, named like in
+// the CSV output.
+var Fields = []string{
+	"title",
+	"category",
+	"address",
+	"phone",
+	"website",
+	"status",
+	"review_count",
+	"review_rating",
+	"price_range",
+	"open_hours",
+}
+
+// Place is a place of a crawl, with the compared fields in the same text
+// form as in the CSV output.
+type Place struct {
+	Cid    string
+	DataID string
+	Link   string
+	Fields map[string]string
+}
+
+// NewPlace returns the place of a result entry.
+func NewPlace(e *gmaps.Entry) Place {
+	headers, row := e.CsvHeaders(), e.CsvRow()
+
+	return newPlace(func(name string) string {
+		if i := slices.Index(headers, name); i >= 0 && i < len(row) {
+			return row[i]
+		}
+
+		return ""
+	})
+}
+
+func newPlace(column func(string) string) Place {
+	p := Place{
+		Cid:    column("cid"),
+		DataID: column("data_id"),
+		Link:   column("link"),
+		Fields: make(map[string]string, len(Fields)),
+	}
+
+	for _, name := range Fields {
+		p.Fields[name] = normalize(name, column(name))
+	}
+
+	return p
+}
+
+// normalize makes equal values compare equal whatever the source, so
+// "4.500000" is 4.5 and a missing value is the same as null.
+func normalize(name, v string) string {
+	v = strings.TrimSpace(v)
+
+	switch {
+	case v == "null", v == "{}", v == "[]":
+		return ""
+	case name == "review_count" || name == "review_rating":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+
+	return v
+}
+
+// FieldChange is the change of one field of a place.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// PlaceChange is a place that was added, removed or changed.
+type PlaceChange struct {
+	Change string        `json:"change"`
+	Cid    string        `json:"cid"`
+	DataID string        `json:"data_id"`
+	Title  string        `json:"title"`
+	Link   string        `json:"link"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// Report is the result of a comparison.
+type Report struct {
+	Added   int           `json:"added"`
+	Removed int           `json:"removed"`
+	Changed int           `json:"changed"`
+	Places  []PlaceChange `json:"places"`
+}
+
+// Compare matches the places of the two crawls by cid, or by data id when
+// the cid is missing, and reports the differences. Places that appear more
+// than once in a crawl are compared by their last occurrence.
+func Compare(oldPlaces, newPlaces []Place) Report {
+	oldPlaces, newPlaces = unique(oldPlaces), unique(newPlaces)
+
+	byCid := map[string]int{}
+	byDataID := map[string]int{}
+
+	for i := range oldPlaces {
+		if oldPlaces[i].Cid != "" {
+			byCid[oldPlaces[i].Cid] = i
+		}
+
+		if oldPlaces[i].DataID != "" {
+			byDataID[oldPlaces[i].DataID] = i
+		}
+	}
+
+	report := Report{Places: []PlaceChange{}}
+	matched := make([]bool, len(oldPlaces))
+
+	for i := range newPlaces {
+		p := &newPlaces[i]
+
+		idx, ok := byCid[p.Cid]
+		if !ok || p.Cid == "" {
+			idx, ok = byDataID[p.DataID]
+			ok = ok && p.DataID != ""
+		}
+
+		if !ok {
+			report.Places = append(report.Places, newChange(Added, p, nil))
+			report.Added++
+
+			continue
+		}
+
+		matched[idx] = true
+
+		if fields := compareFields(&oldPlaces[idx], p); len(fields) > 0 {
+			report.Places = append(report.Places, newChange(Changed, p, fields))
+			report.Changed++
+		}
+	}
+
+	for i := range oldPlaces {
+		if !matched[i] {
+			report.Places = append(report.Places, newChange(Removed, &oldPlaces[i], nil))
+			report.Removed++
+		}
+	}
+
+	return report
+}
+
+// unique drops the places without a cid and a data id and keeps the last
+// occurrence of the others, in the order they first appeared.
+func unique(places []Place) []Place {
+	positions := map[string]int{}
+	ans := make([]Place, 0, len(places))
+
+	for i := range places {
+		key := places[i].Cid
+		if key == "" {
+			key = places[i].DataID
+		}
+
+		if key == "" {
+			continue
+		}
+
+		if pos, ok := positions[key]; ok {
+			ans[pos] = places[i]
+
+			continue
+		}
+
+		positions[key] = len(ans)
+		ans = append(ans, places[i])
+	}
+
+	return ans
+}
+
+func compareFields(oldPlace, newPlace *Place) []FieldChange {
+	var ans []FieldChange
+
+	for _, name := range Fields {
+		if o, n := oldPlace.Fields[name], newPlace.Fields[name]; o != n {
+			ans = append(ans, FieldChange{Field: name, Old: o, New: n})
+		}
+	}
+
+	return ans
+}
+
+func newChange(change string, p *Place, fields []FieldChange) PlaceChange {
+	return PlaceChange{
+		Change: change,
+		Cid:    p.Cid,
+		DataID: p.DataID,
+		Title:  p.Fields["title"],
+		Link:   p.Link,
+		Fields: fields,
+	}
+}
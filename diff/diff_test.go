@@ -0,0 +1,51 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/diff"
+)
+
+func Test_Compare(t *testing.T) {
+	oldCSV := `title,cid,data_id,phone,review_count,review_rating,status
+Cafe,1,0x1,555-1,10,4.5,
+Bakery,2,0x2,555-2,3,4,
+Bar,,0x3,555-3,7,3.9,
+`
+	newJSON := `{"title":"Cafe","cid":"1","data_id":"0x1","phone":"555-1","review_count":12,"review_rating":4.4}
+[{"title":"Bar","data_id":"0x3","phone":"555-3","review_count":7,"review_rating":3.9},{"title":"Deli","cid":"4","data_id":"0x4"}]
+`
+
+	oldPlaces, err := diff.ReadPlaces(strings.NewReader(oldCSV))
+	require.NoError(t, err)
+	require.Len(t, oldPlaces, 3)
+
+	newPlaces, err := diff.ReadPlaces(strings.NewReader(newJSON))
+	require.NoError(t, err)
+	require.Len(t, newPlaces, 3)
+
+	report := diff.Compare(oldPlaces, newPlaces)
+	require.Equal(t, 1, report.Added)
+	require.Equal(t, 1, report.Removed)
+	require.Equal(t, 1, report.Changed)
+
+	byTitle := map[string]diff.PlaceChange{}
+	for _, p := range report.Places {
+		byTitle[p.Title] = p
+	}
+
+	require.Equal(t, diff.Added, byTitle["Deli"].Change)
+	require.Equal(t, diff.Removed, byTitle["Bakery"].Change)
+	require.Equal(t, []diff.FieldChange{
+		{Field: "review_count", Old: "10", New: "12"},
+		{Field: "review_rating", Old: "4.5", New: "4.4"},
+	}, byTitle["Cafe"].Fields)
+
+	var buf strings.Builder
+
+	require.NoError(t, diff.WriteCSV(&buf, &report))
+	require.Equal(t, 5, strings.Count(buf.String(), "\n"))
+}
@@ -0,0 +1,160 @@
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// ReadPlaces reads the places of a results file, in the CSV or in the JSON
+// format of the scraper. The format is detected from the content.
+func ReadPlaces(r io.Reader) ([]Place, error) {
+	br := bufio.NewReader(r)
+
+	for {
+		b, err := br.Peek(1)
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = br.ReadByte()
+
+			continue
+		case '{', '[':
+			return readJSON(br)
+		default:
+			return readCSV(br)
+		}
+	}
+}
+
+// readJSON reads a stream of entries or of lists of entries, as written by
+// the place and the fast mode jobs.
+func readJSON(r io.Reader) ([]Place, error) {
+	dec := json.NewDecoder(r)
+
+	var ans []Place
+
+	for {
+		var raw json.RawMessage
+
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return ans, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read json: %w", err)
+		}
+
+		var entries []*gmaps.Entry
+
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+			err = json.Unmarshal(raw, &entries)
+		} else {
+			entries = []*gmaps.Entry{{}}
+			err = json.Unmarshal(raw, entries[0])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read json: %w", err)
+		}
+
+		for _, e := range entries {
+			ans = append(ans, NewPlace(e))
+		}
+	}
+}
+
+func readCSV(r io.Reader) ([]Place, error) {
+	reader := csv.NewReader(r)
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+
+	columns := make(map[string]int, len(headers))
+	for i, h := range headers {
+		columns[strings.TrimPrefix(h, "\ufeff")] = i
+	}
+
+	if _, ok := columns["cid"]; !ok {
+		return nil, errors.New("csv file has no cid column")
+	}
+
+	var ans []Place
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return ans, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		ans = append(ans, newPlace(func(name string) string {
+			if idx, ok := columns[name]; ok && idx < len(record) {
+				return record[idx]
+			}
+
+			return ""
+		}))
+	}
+}
+
+// WriteJSON writes the report as one JSON document.
+func WriteJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(report)
+}
+
+// WriteCSV writes the report with one row per changed field. Added and
+// removed places have a single row without a field.
+func WriteCSV(w io.Writer, report *Report) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"change", "cid", "data_id", "title", "link", "field", "old", "new"}); err != nil {
+		return err
+	}
+
+	for i := range report.Places {
+		p := &report.Places[i]
+		place := []string{p.Change, p.Cid, p.DataID, p.Title, p.Link}
+
+		if len(p.Fields) == 0 {
+			if err := writer.Write(append(place, "", "", "")); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		for _, f := range p.Fields {
+			if err := writer.Write(append(place[:5:5], f.Field, f.Old, f.New)); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
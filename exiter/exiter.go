@@ -12,23 +12,67 @@ type Exiter interface {
 	IncrSeedCompleted(int)
 	IncrPlacesFound(int)
 	IncrPlacesCompleted(int)
+	// IncrErrors counts failed attempts of jobs. They do not change when
+	// the run is done, as the jobs are retried.
+	IncrErrors(int)
+	// IncrBlocks counts the attempts of jobs that Google blocked.
+	IncrBlocks(int)
 	Run(context.Context)
 }
 
+// Progress is a snapshot of the counters of an exiter.
+type Progress struct {
+	SeedCount       int `json:"seed_count"`
+	SeedCompleted   int `json:"seed_completed"`
+	PlacesFound     int `json:"places_found"`
+	PlacesCompleted int `json:"places_completed"`
+	Errors          int `json:"errors"`
+	Blocks          int `json:"blocks"`
+	// Elapsed is the time since Run was called, in seconds.
+	Elapsed int `json:"elapsed"`
+	// ETA estimates the remaining time in seconds from the rate the seeds
+	// and places were completed so far. It is 0 while there is no estimate.
+	// As places keep being found, it is a lower bound.
+	ETA int `json:"eta"`
+}
+
+type Option func(*exiter)
+
+// WithSnapshots makes the exiter call fn with a snapshot of the progress
+// every interval while it runs, and once more when it stops.
+func WithSnapshots(interval time.Duration, fn func(Progress)) Option {
+	return func(e *exiter) {
+		e.snapshotInterval = interval
+		e.snapshotFn = fn
+	}
+}
+
 type exiter struct {
 	seedCount       int
 	seedCompleted   int
 	placesFound     int
 	placesCompleted int
+	errors          int
+	blocks          int
+	startedAt       time.Time
 
 	mu         *sync.Mutex
 	cancelFunc context.CancelFunc
+
+	snapshotInterval time.Duration
+	snapshotFn       func(Progress)
 }
 
-func New() Exiter {
-	return &exiter{
+func New(opts ...Option) Exiter {
+	ans := exiter{
 		mu: &sync.Mutex{},
 	}
+
+	for _, opt := range opts {
+		opt(&ans)
+	}
+
+	return &ans
 }
 
 func (e *exiter) SetSeedCount(val int) {
@@ -66,14 +110,48 @@ func (e *exiter) IncrPlacesCompleted(val int) {
 	e.placesCompleted += val
 }
 
+func (e *exiter) IncrErrors(val int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.errors += val
+}
+
+func (e *exiter) IncrBlocks(val int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.blocks += val
+}
+
 func (e *exiter) Run(ctx context.Context) {
 	ticker := time.NewTicker(time.Second * 5)
 	defer ticker.Stop()
 
+	e.mu.Lock()
+	e.startedAt = time.Now()
+	e.mu.Unlock()
+
+	// a nil channel never fires when there are no snapshots
+	var snapshots <-chan time.Time
+
+	if e.snapshotFn != nil {
+		snapshotTicker := time.NewTicker(e.snapshotInterval)
+		defer snapshotTicker.Stop()
+
+		snapshots = snapshotTicker.C
+
+		defer func() {
+			e.snapshotFn(e.snapshot())
+		}()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-snapshots:
+			e.snapshotFn(e.snapshot())
 		case <-ticker.C:
 			if e.isDone() {
 				e.cancelFunc()
@@ -84,6 +162,32 @@ func (e *exiter) Run(ctx context.Context) {
 	}
 }
 
+func (e *exiter) snapshot() Progress {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ans := Progress{
+		SeedCount:       e.seedCount,
+		SeedCompleted:   e.seedCompleted,
+		PlacesFound:     e.placesFound,
+		PlacesCompleted: e.placesCompleted,
+		Errors:          e.errors,
+		Blocks:          e.blocks,
+	}
+
+	elapsed := time.Since(e.startedAt)
+	ans.Elapsed = int(elapsed.Seconds())
+
+	done := e.seedCompleted + e.placesCompleted
+	total := e.seedCount + e.placesFound
+
+	if done > 0 && total > done {
+		ans.ETA = int(elapsed.Seconds() * float64(total-done) / float64(done))
+	}
+
+	return ans
+}
+
 func (e *exiter) isDone() bool {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -0,0 +1,70 @@
+package exiter_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/exiter"
+)
+
+func Test_ExiterSnapshots(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		snapshots []exiter.Progress
+	)
+
+	e := exiter.New(exiter.WithSnapshots(10*time.Millisecond, func(p exiter.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		snapshots = append(snapshots, p)
+	}))
+
+	e.SetSeedCount(2)
+	e.IncrSeedCompleted(1)
+	e.IncrPlacesFound(4)
+	e.IncrPlacesCompleted(1)
+	e.IncrErrors(3)
+	e.IncrBlocks(1)
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		e.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(snapshots) > 0
+	}, time.Second, 5*time.Millisecond)
+
+	e.IncrPlacesCompleted(2)
+
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	first := snapshots[0]
+	require.Equal(t, 2, first.SeedCount)
+	require.Equal(t, 1, first.SeedCompleted)
+	require.Equal(t, 4, first.PlacesFound)
+	require.Equal(t, 1, first.PlacesCompleted)
+	require.Equal(t, 3, first.Errors)
+	require.Equal(t, 1, first.Blocks)
+
+	// one more snapshot when it stops
+	last := snapshots[len(snapshots)-1]
+	require.Equal(t, 3, last.PlacesCompleted)
+}
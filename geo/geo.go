@@ -0,0 +1,160 @@
+// Package geo writes places in the geographic formats of GIS tools,
+// GeoJSON for QGIS and most web maps and KML for Google Earth.
+package geo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gosom/scrapemate"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+const (
+	FormatGeoJSON = "geojson"
+	FormatKML     = "kml"
+)
+
+// numericFields are written as numbers rather than text.
+var numericFields = map[string]bool{
+	"review_count":  true,
+	"review_rating": true,
+	"latitude":      true,
+	"longitude":     true,
+}
+
+// Encoder writes places as one document. The places are rows with the
+// columns of the CSV results, so that the results files of earlier runs can
+// be converted as well as entries.
+type Encoder interface {
+	// Encode writes a place. Places without coordinates are written
+	// without a geometry.
+	Encode(row []string) error
+	// Close ends the document. It does not close the underlying writer.
+	Close() error
+}
+
+// NewEncoder returns an encoder of the format that writes the places to w.
+// header names the columns of the rows, and fields are the columns that go
+// in the properties of the places, all of them when there are none.
+func NewEncoder(format string, w io.Writer, header, fields []string) (Encoder, error) {
+	cols := newColumns(header, fields)
+
+	switch format {
+	case FormatGeoJSON:
+		return newGeoJSONEncoder(w, cols), nil
+	case FormatKML:
+		return newKMLEncoder(w, cols), nil
+	default:
+		return nil, fmt.Errorf("unknown geo format %q", format)
+	}
+}
+
+// IsFormat reports whether the format is one of the geographic formats.
+func IsFormat(format string) bool {
+	return format == FormatGeoJSON || format == FormatKML
+}
+
+type columns struct {
+	header     []string
+	properties []int
+	lat, lon   int
+	title      int
+}
+
+func newColumns(header, fields []string) columns {
+	ans := columns{header: header, lat: -1, lon: -1, title: -1}
+
+	selected := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		selected[strings.TrimSpace(f)] = true
+	}
+
+	for i, h := range header {
+		switch h {
+		case "latitude":
+			ans.lat = i
+		case "longitude":
+			ans.lon = i
+		case "title":
+			ans.title = i
+		}
+
+		if len(selected) == 0 || selected[h] {
+			ans.properties = append(ans.properties, i)
+		}
+	}
+
+	// fields that match no column select all of them, like for the CSV
+	if len(ans.properties) == 0 {
+		for i := range header {
+			ans.properties = append(ans.properties, i)
+		}
+	}
+
+	return ans
+}
+
+func (c *columns) value(row []string, idx int) string {
+	if idx >= 0 && idx < len(row) {
+		return row[idx]
+	}
+
+	return ""
+}
+
+// coordinates returns the coordinates of the place, and false when it has
+// none.
+func (c *columns) coordinates(row []string) (lat, lon float64, ok bool) {
+	lat, err1 := strconv.ParseFloat(c.value(row, c.lat), 64)
+	lon, err2 := strconv.ParseFloat(c.value(row, c.lon), 64)
+
+	if err1 != nil || err2 != nil || (lat == 0 && lon == 0) {
+		return 0, 0, false
+	}
+
+	return lat, lon, true
+}
+
+// writer is a scrapemate.ResultWriter that encodes the places it receives.
+type writer struct {
+	enc Encoder
+}
+
+// NewWriter returns a scrapemate.ResultWriter that writes the places to w
+// as one document of the format.
+func NewWriter(format string, w io.Writer, fields []string) (scrapemate.ResultWriter, error) {
+	enc, err := NewEncoder(format, w, (&gmaps.Entry{}).CsvHeaders(), fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &writer{enc: enc}, nil
+}
+
+func (w *writer) Run(_ context.Context, in <-chan scrapemate.Result) error {
+	for result := range in {
+		var entries []*gmaps.Entry
+
+		switch v := result.Data.(type) {
+		case *gmaps.Entry:
+			entries = []*gmaps.Entry{v}
+		case []*gmaps.Entry:
+			entries = v
+		default:
+			return fmt.Errorf("invalid data type for geo writer: %T", result.Data)
+		}
+
+		for _, entry := range entries {
+			if err := w.enc.Encode(entry.CsvRow()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.enc.Close()
+}
@@ -0,0 +1,91 @@
+package geo_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/geo"
+)
+
+var (
+	header = []string{"title", "category", "review_count", "latitude", "longitude", "open_hours"}
+	rows   = [][]string{
+		{"Cafe", "Coffee shop", "12", "52.52", "13.405", `{"Monday":["9-17"]}`},
+		{"Nowhere", "Bar", "", "0", "0", "null"},
+	}
+)
+
+func Test_GeoJSON(t *testing.T) {
+	var buf strings.Builder
+
+	enc, err := geo.NewEncoder(geo.FormatGeoJSON, &buf, header, []string{"title", "review_count", "open_hours"})
+	require.NoError(t, err)
+
+	for _, row := range rows {
+		require.NoError(t, enc.Encode(row))
+	}
+
+	require.NoError(t, enc.Close())
+
+	var doc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry *struct {
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &doc))
+	require.Equal(t, "FeatureCollection", doc.Type)
+	require.Len(t, doc.Features, 2)
+
+	require.Equal(t, []float64{13.405, 52.52}, doc.Features[0].Geometry.Coordinates)
+	require.Equal(t, map[string]any{
+		"title":        "Cafe",
+		"review_count": float64(12),
+		"open_hours":   map[string]any{"Monday": []any{"9-17"}},
+	}, doc.Features[0].Properties)
+
+	require.Nil(t, doc.Features[1].Geometry)
+	require.Equal(t, "", doc.Features[1].Properties["review_count"])
+}
+
+func Test_KML(t *testing.T) {
+	var buf strings.Builder
+
+	enc, err := geo.NewEncoder(geo.FormatKML, &buf, header, []string{"category"})
+	require.NoError(t, err)
+
+	for _, row := range rows {
+		require.NoError(t, enc.Encode(row))
+	}
+
+	require.NoError(t, enc.Close())
+
+	var doc struct {
+		Placemarks []struct {
+			Name string `xml:"name"`
+			Data []struct {
+				Name  string `xml:"name,attr"`
+				Value string `xml:"value"`
+			} `xml:"ExtendedData>Data"`
+			Coordinates string `xml:"Point>coordinates"`
+		} `xml:"Document>Placemark"`
+	}
+
+	require.NoError(t, xml.Unmarshal([]byte(buf.String()), &doc))
+	require.Len(t, doc.Placemarks, 2)
+
+	require.Equal(t, "Cafe", doc.Placemarks[0].Name)
+	require.Equal(t, "13.405,52.52", doc.Placemarks[0].Coordinates)
+	require.Len(t, doc.Placemarks[0].Data, 1)
+	require.Equal(t, "Coffee shop", doc.Placemarks[0].Data[0].Value)
+
+	require.Empty(t, doc.Placemarks[1].Coordinates)
+}
@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// geoJSONEncoder writes a FeatureCollection with a Point feature for each
+// place. The features are written as they come, the collection is not kept
+// in memory.
+type geoJSONEncoder struct {
+	w     *bufio.Writer
+	cols  columns
+	count int
+}
+
+func newGeoJSONEncoder(w io.Writer, cols columns) *geoJSONEncoder {
+	return &geoJSONEncoder{w: bufio.NewWriter(w), cols: cols}
+}
+
+func (e *geoJSONEncoder) Encode(row []string) error {
+	if e.count == 0 {
+		_, _ = e.w.WriteString(`{"type":"FeatureCollection","features":[` + "\n")
+	} else {
+		_, _ = e.w.WriteString(",\n")
+	}
+
+	e.count++
+
+	_, _ = e.w.WriteString(`{"type":"Feature","geometry":`)
+
+	if lat, lon, ok := e.cols.coordinates(row); ok {
+		// GeoJSON has the longitude first
+		_, _ = e.w.WriteString(`{"type":"Point","coordinates":[`)
+		_, _ = e.w.WriteString(strconv.FormatFloat(lon, 'f', -1, 64))
+		_ = e.w.WriteByte(',')
+		_, _ = e.w.WriteString(strconv.FormatFloat(lat, 'f', -1, 64))
+		_, _ = e.w.WriteString(`]}`)
+	} else {
+		_, _ = e.w.WriteString(`null`)
+	}
+
+	// the properties are in the order of the columns
+	_, _ = e.w.WriteString(`,"properties":{`)
+
+	for i, idx := range e.cols.properties {
+		if i > 0 {
+			_ = e.w.WriteByte(',')
+		}
+
+		name, _ := json.Marshal(e.cols.header[idx])
+
+		_, _ = e.w.Write(name)
+		_ = e.w.WriteByte(':')
+		_, _ = e.w.Write(propertyValue(e.cols.header[idx], e.cols.value(row, idx)))
+	}
+
+	_, err := e.w.WriteString(`}}`)
+
+	return err
+}
+
+func (e *geoJSONEncoder) Close() error {
+	if e.count == 0 {
+		_, _ = e.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	}
+
+	_, _ = e.w.WriteString("\n]}\n")
+
+	return e.w.Flush()
+}
+
+// propertyValue returns the JSON of a column. Numbers are written as
+// numbers, and the nested fields, which the CSV has as JSON, as objects and
+// arrays.
+func propertyValue(name, v string) []byte {
+	if numericFields[name] {
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return []byte(v)
+		}
+	}
+
+	if len(v) > 0 && (v[0] == '{' || v[0] == '[') && json.Valid([]byte(v)) {
+		return []byte(v)
+	}
+
+	if v == "null" {
+		return []byte(v)
+	}
+
+	b, _ := json.Marshal(v)
+
+	return b
+}
@@ -0,0 +1,83 @@
+package geo
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+type kmlPlacemark struct {
+	XMLName xml.Name  `xml:"Placemark"`
+	Name    string    `xml:"name"`
+	Data    []kmlData `xml:"ExtendedData>Data,omitempty"`
+	Point   *kmlPoint `xml:"Point,omitempty"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// kmlEncoder writes a KML document with a Placemark for each place, named
+// after its title, and the properties as its ExtendedData.
+type kmlEncoder struct {
+	w       *bufio.Writer
+	enc     *xml.Encoder
+	cols    columns
+	started bool
+}
+
+func newKMLEncoder(w io.Writer, cols columns) *kmlEncoder {
+	bw := bufio.NewWriter(w)
+
+	return &kmlEncoder{w: bw, enc: xml.NewEncoder(bw), cols: cols}
+}
+
+func (e *kmlEncoder) start() {
+	if e.started {
+		return
+	}
+
+	e.started = true
+
+	_, _ = e.w.WriteString(xml.Header)
+	_, _ = e.w.WriteString(`<kml xmlns="http://www.opengis.net/kml/2.2">` + "\n<Document>\n")
+}
+
+func (e *kmlEncoder) Encode(row []string) error {
+	e.start()
+
+	placemark := kmlPlacemark{Name: e.cols.value(row, e.cols.title)}
+
+	if lat, lon, ok := e.cols.coordinates(row); ok {
+		// KML has the longitude first
+		placemark.Point = &kmlPoint{
+			Coordinates: strconv.FormatFloat(lon, 'f', -1, 64) + "," + strconv.FormatFloat(lat, 'f', -1, 64),
+		}
+	}
+
+	for _, idx := range e.cols.properties {
+		placemark.Data = append(placemark.Data, kmlData{Name: e.cols.header[idx], Value: e.cols.value(row, idx)})
+	}
+
+	if err := e.enc.Encode(&placemark); err != nil {
+		return err
+	}
+
+	_, err := e.w.WriteString("\n")
+
+	return err
+}
+
+func (e *kmlEncoder) Close() error {
+	e.start()
+
+	_, _ = e.w.WriteString("</Document>\n</kml>\n")
+
+	return e.w.Flush()
+}
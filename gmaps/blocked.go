@@ -0,0 +1,194 @@
+package gmaps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosom/scrapemate"
+)
+
+// ErrBlocked is the error of the jobs Google blocked, with its "sorry" page
+// about unusual traffic or a CAPTCHA. The errors are *BlockedError, test
+// them with errors.Is.
+var ErrBlocked = errors.New("blocked by google")
+
+// BlockedError is a block of a job by Google.
+type BlockedError struct {
+	URL string
+	// Reason is what the block was detected by.
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	return ErrBlocked.Error() + ": " + e.Reason
+}
+
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// blockMarkers are found in the block pages of Google, in any language.
+var blockMarkers = []struct {
+	marker []byte
+	reason string
+}{
+	{[]byte(`id="captcha-form"`), "captcha"},
+	{[]byte("g-recaptcha"), "captcha"},
+	{[]byte("/sorry/index"), "unusual traffic"},
+	{[]byte("detected unusual traffic"), "unusual traffic"},
+}
+
+// DetectBlock returns the block of a response, or nil when it is not a
+// block page. statusCode and url may be empty.
+func DetectBlock(statusCode int, url string, body []byte) *BlockedError {
+	if strings.Contains(url, "/sorry/") {
+		return &BlockedError{URL: url, Reason: "unusual traffic"}
+	}
+
+	for _, m := range blockMarkers {
+		if bytes.Contains(body, m.marker) {
+			return &BlockedError{URL: url, Reason: m.reason}
+		}
+	}
+
+	if statusCode == http.StatusTooManyRequests {
+		return &BlockedError{URL: url, Reason: http.StatusText(statusCode)}
+	}
+
+	return nil
+}
+
+// detectPageBlock checks the page a browser job is on.
+func detectPageBlock(page scrapemate.BrowserPage, statusCode int) *BlockedError {
+	var body []byte
+
+	if content, err := page.Content(); err == nil {
+		body = []byte(content)
+	}
+
+	return DetectBlock(statusCode, page.URL(), body)
+}
+
+// Backoff pauses the jobs of a run when Google blocks one of them: they
+// wait for a cool-down before their next request. The cool-down doubles
+// with each block in a row, up to a maximum, and a success after it ends
+// resets it. Its methods do nothing on a nil Backoff.
+type Backoff struct {
+	state *backoffState
+
+	mu      sync.Mutex
+	rotated time.Time
+	onBlock func(*BlockedError)
+}
+
+// backoffState is the cool-down shared by a Backoff and its scopes.
+type backoffState struct {
+	mu       sync.Mutex
+	cooldown time.Duration
+	max      time.Duration
+	blocks   int
+	until    time.Time
+}
+
+// NewBackoff returns a Backoff with the first and the longest cool-down.
+// onBlock, when not nil, is called when a cool-down starts, to rotate the
+// proxy of the run. Blocks during a cool-down are of requests sent before
+// it and do not start another one.
+func NewBackoff(cooldown, maxCooldown time.Duration, onBlock func(*BlockedError)) *Backoff {
+	return &Backoff{
+		state: &backoffState{
+			cooldown: cooldown,
+			max:      maxCooldown,
+		},
+		onBlock: onBlock,
+	}
+}
+
+// Scoped returns a backoff sharing the cool-down of b, for the jobs of one
+// of the runs that share it. Its onBlock is called instead of the one of b
+// for the blocks of its own jobs, once per cool-down, so each run rotates
+// its own proxy.
+func (b *Backoff) Scoped(onBlock func(*BlockedError)) *Backoff {
+	if b == nil {
+		return nil
+	}
+
+	return &Backoff{state: b.state, onBlock: onBlock}
+}
+
+// Wait waits for the end of the cool-down.
+func (b *Backoff) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	b.state.mu.Lock()
+	wait := time.Until(b.state.until)
+	b.state.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Blocked starts a cool-down, unless one is running.
+func (b *Backoff) Blocked(err *BlockedError) {
+	if b == nil {
+		return
+	}
+
+	s := b.state
+	s.mu.Lock()
+
+	if now := time.Now(); !now.Before(s.until) {
+		cooldown := s.cooldown << min(s.blocks, 20)
+		if cooldown > s.max || cooldown <= 0 {
+			cooldown = s.max
+		}
+
+		s.blocks++
+		s.until = now.Add(cooldown)
+	}
+
+	until := s.until
+
+	s.mu.Unlock()
+
+	b.mu.Lock()
+	rotate := b.rotated.Before(until)
+	b.rotated = until
+	b.mu.Unlock()
+
+	if rotate && b.onBlock != nil {
+		b.onBlock(err)
+	}
+}
+
+// Succeeded records a request that was not blocked.
+func (b *Backoff) Succeeded() {
+	if b == nil {
+		return
+	}
+
+	b.state.mu.Lock()
+	defer b.state.mu.Unlock()
+
+	if !time.Now().Before(b.state.until) {
+		b.state.blocks = 0
+	}
+}
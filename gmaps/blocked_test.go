@@ -0,0 +1,180 @@
+package gmaps_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gosom/scrapemate"
+	memprovider "github.com/gosom/scrapemate/adapters/providers/memory"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+const sorryPage = `<html><body><div id="infoDiv">Our systems have detected unusual traffic from your computer network.</div>
+<form id="captcha-form" action="index" method="post"><div class="g-recaptcha"></div></form></body></html>`
+
+func Test_DetectBlock(t *testing.T) {
+	blocked := gmaps.DetectBlock(http.StatusTooManyRequests, "https://www.google.com/sorry/index?continue=x", nil)
+	require.NotNil(t, blocked)
+	require.ErrorIs(t, blocked, gmaps.ErrBlocked)
+
+	blocked = gmaps.DetectBlock(http.StatusOK, "https://maps.google.com/search", []byte(sorryPage))
+	require.NotNil(t, blocked)
+	require.Equal(t, "captcha", blocked.Reason)
+
+	require.Nil(t, gmaps.DetectBlock(http.StatusOK, "https://maps.google.com/search", []byte(`)]}'\n[[]]`)))
+}
+
+func Test_Backoff(t *testing.T) {
+	var rotations int
+
+	b := gmaps.NewBackoff(40*time.Millisecond, 60*time.Millisecond, func(*gmaps.BlockedError) {
+		rotations++
+	})
+
+	blocked := &gmaps.BlockedError{Reason: "captcha"}
+
+	start := time.Now()
+
+	b.Blocked(blocked)
+	// a block during the cool-down was sent before it
+	b.Blocked(blocked)
+	require.NoError(t, b.Wait(t.Context()))
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	require.Equal(t, 1, rotations)
+
+	// the next one is longer, up to the maximum
+	start = time.Now()
+
+	b.Blocked(blocked)
+	require.NoError(t, b.Wait(t.Context()))
+	require.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+	require.Equal(t, 2, rotations)
+
+	var nilBackoff *gmaps.Backoff
+
+	nilBackoff.Blocked(blocked)
+	require.NoError(t, nilBackoff.Wait(t.Context()))
+}
+
+func Test_BackoffScoped(t *testing.T) {
+	var rotated []string
+
+	b := gmaps.NewBackoff(40*time.Millisecond, 40*time.Millisecond, nil)
+	scope := func(key string) *gmaps.Backoff {
+		return b.Scoped(func(*gmaps.BlockedError) {
+			rotated = append(rotated, key)
+		})
+	}
+
+	first, second := scope("a"), scope("b")
+	blocked := &gmaps.BlockedError{Reason: "captcha"}
+
+	start := time.Now()
+
+	// the scopes share the cool-down, each rotates once in it
+	first.Blocked(blocked)
+	first.Blocked(blocked)
+	second.Blocked(blocked)
+	require.NoError(t, second.Wait(t.Context()))
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	require.Equal(t, []string{"a", "b"}, rotated)
+
+	var nilBackoff *gmaps.Backoff
+
+	require.Nil(t, nilBackoff.Scoped(nil))
+}
+
+func Test_SearchJobBlocked(t *testing.T) {
+	monitor := exiter.New()
+	backoff := gmaps.NewBackoff(time.Millisecond, time.Millisecond, nil)
+
+	job := gmaps.NewSearchJob(&gmaps.MapSearchParams{Query: "cafe"},
+		gmaps.WithSearchJobExitMonitor(monitor),
+		gmaps.WithSearchJobBackoff(backoff),
+	)
+
+	resp := scrapemate.Response{StatusCode: http.StatusTooManyRequests, Body: []byte(sorryPage)}
+	require.True(t, job.DoCheckResponse(&resp))
+
+	// the search is tried again until it was blocked too many times
+	var err error
+
+	for range 4 {
+		resp := scrapemate.Response{StatusCode: http.StatusTooManyRequests, Body: []byte(sorryPage)}
+
+		var next []scrapemate.IJob
+
+		_, next, err = job.Process(t.Context(), &resp)
+		if err != nil {
+			break
+		}
+
+		require.Len(t, next, 1)
+		require.NotEqual(t, job.GetID(), next[0].GetID())
+
+		job = next[0].(*gmaps.SearchJob)
+	}
+
+	require.True(t, errors.Is(err, gmaps.ErrBlocked))
+}
+
+func Test_WaitProviderBackoff(t *testing.T) {
+	const cooldown = 100 * time.Millisecond
+
+	backoff := gmaps.NewBackoff(cooldown, cooldown, nil)
+	provider := gmaps.NewWaitProvider(memprovider.New())
+
+	job := gmaps.NewSearchJob(&gmaps.MapSearchParams{Query: "cafe"}, gmaps.WithSearchJobBackoff(backoff))
+	other := gmaps.NewSearchJob(&gmaps.MapSearchParams{Query: "bar"}, gmaps.WithSearchJobBackoff(backoff))
+
+	// the blocked search is returned to be tried again without holding the
+	// worker for the cool-down
+	start := time.Now()
+	resp := scrapemate.Response{StatusCode: http.StatusTooManyRequests, Body: []byte(sorryPage)}
+
+	_, next, err := job.Process(t.Context(), &resp)
+	require.NoError(t, err)
+	require.Len(t, next, 1)
+	require.Less(t, time.Since(start), cooldown)
+
+	// but the other jobs are handed to the workers after it
+	require.NoError(t, provider.Push(t.Context(), other))
+
+	jobs, _ := provider.Jobs(t.Context())
+
+	got := <-jobs
+	require.Equal(t, other.GetID(), got.GetID())
+	require.GreaterOrEqual(t, time.Since(start), cooldown)
+
+	// a job that can't wait is not handed
+	backoff.Blocked(&gmaps.BlockedError{Reason: "captcha"})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	retry, ok := next[0].(*gmaps.SearchJob)
+	require.True(t, ok)
+	require.ErrorIs(t, retry.BeforeFetch(ctx), context.Canceled)
+}
+
+func Test_BackoffNotStored(t *testing.T) {
+	// the database provider stores the jobs with gob, the backoff belongs to
+	// the process
+	backoff := gmaps.NewBackoff(time.Second, time.Second, nil)
+
+	job := gmaps.NewGmapJob("", "en", "cafe", 1, false, "", 0, gmaps.WithBackoff(backoff))
+	place := gmaps.NewPlaceJob(job.ID, "en", "https://www.google.com/maps/place/x", false, false, gmaps.WithPlaceJobBackoff(backoff))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, gob.NewEncoder(&buf).Encode(job))
+	require.NoError(t, gob.NewEncoder(&buf).Encode(place))
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/google/uuid"
+	"github.com/gosom/google-maps-scraper/budget"
 	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/ratelimit"
 	"github.com/gosom/scrapemate"
 	"github.com/mcnijman/go-emailaddress"
 )
@@ -18,6 +20,11 @@ type EmailExtractJob struct {
 
 	Entry       *Entry
 	ExitMonitor exiter.Exiter
+	// PlaceURL is the URL of the place job that created the email job.
+	PlaceURL string
+
+	rateLimiter *ratelimit.Limiter
+	share       *budget.Share
 }
 
 func NewEmailJob(parentID string, entry *Entry, opts ...EmailExtractJobOptions) *EmailExtractJob {
@@ -52,6 +59,44 @@ func WithEmailJobExitMonitor(exitMonitor exiter.Exiter) EmailExtractJobOptions {
 	}
 }
 
+func WithEmailJobPlaceURL(u string) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.PlaceURL = u
+	}
+}
+
+// WithEmailJobRateLimiter makes the job wait for its turn to send a request
+// to the website of the place.
+func WithEmailJobRateLimiter(l *ratelimit.Limiter) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.rateLimiter = l
+	}
+}
+
+// WithEmailJobBudget makes the job take a slot of the budget of the
+// process while it sends its request.
+func WithEmailJobBudget(s *budget.Share) EmailExtractJobOptions {
+	return func(j *EmailExtractJob) {
+		j.share = s
+	}
+}
+
+// BrowserActions waits for the turn of the website and for a slot of the
+// budget before it opens it.
+func (j *EmailExtractJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPage) scrapemate.Response {
+	if err := j.rateLimiter.Wait(ctx, j.GetFullURL()); err != nil {
+		return scrapemate.Response{Error: err}
+	}
+
+	if err := j.share.Acquire(ctx); err != nil {
+		return scrapemate.Response{Error: err}
+	}
+
+	defer j.share.Release()
+
+	return j.Job.BrowserActions(ctx, page)
+}
+
 func (j *EmailExtractJob) Process(ctx context.Context, resp *scrapemate.Response) (any, []scrapemate.IJob, error) {
 	defer func() {
 		resp.Document = nil
@@ -10,6 +10,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Image struct {
@@ -49,12 +50,24 @@ type About struct {
 }
 
 type Review struct {
+	// ID is Google's review id, or a hash of the author and the text when
+	// Google's id is not available.
+	ID             string
 	Name           string
 	ProfilePicture string
 	Rating         int
 	Description    string
 	Images         []string
-	When           string
+	// When is the date of the review in the year-month-day format.
+	When string
+	// Time is when the review was posted. TimeEstimated is set when it is
+	// derived from RelativeTime, the text Google shows ("3 months ago").
+	Time          time.Time `json:",omitzero"`
+	TimeEstimated bool      `json:",omitempty"`
+	RelativeTime  string    `json:",omitempty"`
+	// OwnerResponse is the reply of the business owner.
+	OwnerResponse     string    `json:",omitempty"`
+	OwnerResponseTime time.Time `json:",omitzero"`
 }
 
 type Entry struct {
@@ -98,13 +111,18 @@ type Entry struct {
 }
 
 func (e *Entry) haversineDistance(lat, lon float64) float64 {
+	return Haversine(lat, lon, e.Latitude, e.Longtitude)
+}
+
+// Haversine returns the distance in meters between two points.
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
 	const R = 6371e3 // earth radius in meters
 
-	clat := lat * math.Pi / 180
-	clon := lon * math.Pi / 180
+	clat := lat1 * math.Pi / 180
+	clon := lon1 * math.Pi / 180
 
-	elat := e.Latitude * math.Pi / 180
-	elon := e.Longtitude * math.Pi / 180
+	elat := lat2 * math.Pi / 180
+	elon := lon2 * math.Pi / 180
 
 	dlat := elat - clat
 	dlon := elon - clon
@@ -448,6 +466,7 @@ func EntryFromJSON(raw []byte, reviewCountOnly ...bool) (entry Entry, err error)
 
 func parseReviews(reviewsI []any) []Review {
 	ans := make([]Review, 0, len(reviewsI))
+	now := time.Now()
 
 	for i := range reviewsI {
 		el := getNthElementAndCast[[]any](reviewsI, i, 0)
@@ -459,10 +478,10 @@ func parseReviews(reviewsI []any) []Review {
 			}
 		}
 
-		// Try multiple paths for the timestamp
-		time := getNthElementAndCast[[]any](el, 2, 2, 0, 1, 21, 6, 8)
-		if len(time) == 0 {
-			time = getNthElementAndCast[[]any](el, 2, 2, 0, 1, 6, 8)
+		// Try multiple paths for the date parts
+		date := getNthElementAndCast[[]any](el, 2, 2, 0, 1, 21, 6, 8)
+		if len(date) == 0 {
+			date = getNthElementAndCast[[]any](el, 2, 2, 0, 1, 6, 8)
 		}
 
 		// Try multiple paths for profile picture
@@ -501,22 +520,46 @@ func parseReviews(reviewsI []any) []Review {
 			}
 		}
 
+		if authorName == "" {
+			continue
+		}
+
 		review := Review{
+			ID:             reviewID(getNthElementAndCast[string](el, 0), authorName, description),
 			Name:           authorName,
 			ProfilePicture: profilePic,
-			When: func() string {
-				if len(time) < 3 {
-					return ""
-				}
+			Rating:         rating,
+			Description:    description,
+		}
 
-				return fmt.Sprintf("%v-%v-%v", time[0], time[1], time[2])
-			}(),
-			Rating:      rating,
-			Description: description,
+		// The creation time is in microseconds, fall back to the date parts
+		posted := microsToTime(getNthElementAndCast[float64](el, 1, 2))
+		if posted.IsZero() && len(date) >= 3 {
+			posted = time.Date(int(toFloat(date[0])), time.Month(int(toFloat(date[1]))), int(toFloat(date[2])), 0, 0, 0, 0, time.UTC)
 		}
 
-		if review.Name == "" {
-			continue
+		review.setReviewTime(posted, getNthElementAndCast[string](el, 1, 6), now)
+
+		// Keep the date parts as they are when they could not be parsed
+		if review.When == "" && len(date) >= 3 {
+			review.When = fmt.Sprintf("%v-%v-%v", date[0], date[1], date[2])
+		}
+
+		// Try multiple paths for the owner response
+		ownerResponse := getNthElementAndCast[string](el, 3, 14, 0, 0)
+		if ownerResponse == "" {
+			ownerResponse = getNthElementAndCast[string](el, 3, 14, 0)
+		}
+
+		if ownerResponse != "" {
+			review.OwnerResponse = ownerResponse
+			review.OwnerResponseTime = microsToTime(getNthElementAndCast[float64](el, 3, 1))
+
+			if review.OwnerResponseTime.IsZero() {
+				review.OwnerResponseTime, _ = EstimateReviewTime(getNthElementAndCast[string](el, 3, 3), now)
+			}
+
+			review.OwnerResponseTime = review.OwnerResponseTime.UTC()
 		}
 
 		// Try multiple paths for images
@@ -538,6 +581,20 @@ func parseReviews(reviewsI []any) []Review {
 	return ans
 }
 
+func microsToTime(v float64) time.Time {
+	if v <= 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMicro(int64(v)).UTC()
+}
+
+func toFloat(v any) float64 {
+	f, _ := v.(float64)
+
+	return f
+}
+
 type getLinkSourceParams struct {
 	arr    []any
 	source []int
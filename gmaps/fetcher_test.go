@@ -0,0 +1,212 @@
+package gmaps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+)
+
+const testMapURL = "https://www.google.com/maps/place/Cafe/data=!4m2!3m1!1s0x1:0x2"
+
+type testReview struct {
+	id     string
+	posted time.Time
+}
+
+// reviewPage returns a reviews RPC page with the reviews, pointing to the
+// next page when next is set.
+func reviewPage(next string, reviews ...testReview) []byte {
+	items := make([]any, 0, len(reviews))
+
+	for _, r := range reviews {
+		user := []any{nil, nil, float64(r.posted.UnixMicro()), nil, []any{nil, nil, nil, nil, nil, []any{"author " + r.id, ""}}}
+		items = append(items, []any{[]any{r.id, user, []any{[]any{float64(5)}}}})
+	}
+
+	var token any
+	if next != "" {
+		token = next
+	}
+
+	data, _ := json.Marshal([]any{nil, token, items})
+
+	return append([]byte(")]}'\n"), data...)
+}
+
+// pagesFetcher serves review pages by their page token, the first page
+// under the empty token.
+type pagesFetcher struct {
+	pages     map[string][]byte
+	requested []string
+}
+
+func (p *pagesFetcher) Fetch(_ context.Context, job scrapemate.IJob) scrapemate.Response {
+	u := job.GetFullURL()
+
+	pb, err := url.QueryUnescape(u[strings.Index(u, "pb=")+3:])
+	if err != nil {
+		return scrapemate.Response{Error: err}
+	}
+
+	// the page token is in the !2m2!1i<size>!2s<token> component
+	_, after, _ := strings.Cut(pb, "!2m2!1i")
+	_, after, _ = strings.Cut(after, "!2s")
+	token, _, _ := strings.Cut(after, "!")
+
+	p.requested = append(p.requested, token)
+
+	page, ok := p.pages[token]
+	if !ok {
+		return scrapemate.Response{Error: fmt.Errorf("no page %q", token)}
+	}
+
+	return scrapemate.Response{StatusCode: 200, Body: page}
+}
+
+func (p *pagesFetcher) Close() error {
+	return nil
+}
+
+func fetchTestPages(t *testing.T, pages map[string][]byte, opts ReviewOptions, checkpoint ReviewCheckpoint) ([]Review, []string) {
+	t.Helper()
+
+	client := &pagesFetcher{pages: pages}
+
+	f := fetcher{
+		httpClient: client,
+		params: fetchReviewsParams{
+			mapURL:     testMapURL,
+			options:    opts,
+			checkpoint: checkpoint,
+		},
+	}
+
+	resp, err := f.fetch(t.Context())
+	require.NoError(t, err)
+
+	var entry Entry
+
+	entry.AddExtraReviews(resp.pages)
+
+	return entry.UserReviewsExtended, client.requested
+}
+
+func Test_FetchReviewsStopsAtCheckpoint(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2025, 6, d, 12, 0, 0, 0, time.UTC)
+	}
+
+	pages := map[string][]byte{
+		"":   reviewPage("p2", testReview{"r9", day(9)}, testReview{"r8", day(8)}),
+		"p2": reviewPage("p3", testReview{"r7", day(7)}, testReview{"r6", day(6)}),
+		"p3": reviewPage("", testReview{"r5", day(5)}, testReview{"r4", day(4)}),
+	}
+
+	opts := ReviewOptions{Sort: ReviewSortNewest}
+
+	reviews, requested := fetchTestPages(t, pages, opts, ReviewCheckpoint{})
+	require.Len(t, reviews, 6)
+	require.Equal(t, []string{"", "p2", "p3"}, requested)
+
+	// a stored review on the second page ends the paging there
+	reviews, requested = fetchTestPages(t, pages, opts, ReviewCheckpoint{IDs: []string{"r6", "r5"}})
+	require.Len(t, reviews, 4)
+	require.Equal(t, []string{"", "p2"}, requested)
+
+	// so does an older review when the ids were not kept
+	_, requested = fetchTestPages(t, pages, opts, ReviewCheckpoint{Latest: day(8)})
+	require.Equal(t, []string{""}, requested)
+}
+
+func Test_FetchReviewsMax(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2025, 6, d, 12, 0, 0, 0, time.UTC)
+	}
+
+	pages := map[string][]byte{
+		"":   reviewPage("p2", testReview{"r9", day(9)}, testReview{"r8", day(8)}),
+		"p2": reviewPage("p3", testReview{"r7", day(7)}, testReview{"r6", day(6)}),
+		"p3": reviewPage("", testReview{"r5", day(5)}, testReview{"r4", day(4)}),
+	}
+
+	// any order stops once enough reviews were fetched
+	_, requested := fetchTestPages(t, pages, ReviewOptions{Sort: ReviewSortHighest, Max: 3}, ReviewCheckpoint{})
+	require.Equal(t, []string{"", "p2"}, requested)
+
+	_, requested = fetchTestPages(t, pages, ReviewOptions{Max: 2}, ReviewCheckpoint{})
+	require.Equal(t, []string{""}, requested)
+}
+
+func Test_FetchReviewsSince(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2025, 6, d, 12, 0, 0, 0, time.UTC)
+	}
+
+	pages := map[string][]byte{
+		"":   reviewPage("p2", testReview{"r9", day(9)}, testReview{"r8", day(8)}),
+		"p2": reviewPage("p3", testReview{"r7", day(7)}, testReview{"r6", day(6)}),
+		"p3": reviewPage("", testReview{"r5", day(5)}, testReview{"r4", day(4)}),
+	}
+
+	since := time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC)
+
+	// newest first, the first older review ends the paging
+	_, requested := fetchTestPages(t, pages, ReviewOptions{Sort: ReviewSortNewest, Since: since}, ReviewCheckpoint{})
+	require.Equal(t, []string{"", "p2"}, requested)
+
+	// in other orders older reviews can be followed by newer ones
+	_, requested = fetchTestPages(t, pages, ReviewOptions{Sort: ReviewSortRelevant, Since: since}, ReviewCheckpoint{})
+	require.Equal(t, []string{"", "p2", "p3"}, requested)
+
+	// older reviews do not count towards the maximum
+	_, requested = fetchTestPages(t, pages, ReviewOptions{Sort: ReviewSortLowest, Since: since, Max: 4}, ReviewCheckpoint{})
+	require.Equal(t, []string{"", "p2", "p3"}, requested)
+}
+
+func Test_ReviewOptionsApply(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	reviews := []Review{
+		{ID: "new", Time: since.AddDate(0, 0, 1)},
+		{ID: "old", Time: since.AddDate(0, 0, -1)},
+		{ID: "undated"},
+		{ID: "exact", Time: since},
+	}
+
+	ids := func(reviews []Review) []string {
+		var ans []string
+		for i := range reviews {
+			ans = append(ans, reviews[i].ID)
+		}
+
+		return ans
+	}
+
+	opts := ReviewOptions{}
+	require.Equal(t, []string{"new", "old", "undated", "exact"}, ids(opts.apply(slices.Clone(reviews))))
+
+	opts = ReviewOptions{Since: since}
+	require.Equal(t, []string{"new", "undated", "exact"}, ids(opts.apply(slices.Clone(reviews))))
+
+	opts = ReviewOptions{Since: since, Max: 2}
+	require.Equal(t, []string{"new", "undated"}, ids(opts.apply(slices.Clone(reviews))))
+
+	opts = ReviewOptions{Max: 1}
+	require.Equal(t, []string{"new"}, ids(opts.apply(slices.Clone(reviews))))
+}
+
+func Test_ReviewOptionsPageSize(t *testing.T) {
+	for _, tc := range []struct{ max, size int }{{0, 20}, {5, 5}, {20, 20}, {150, 20}} {
+		opts := ReviewOptions{Max: tc.max}
+
+		require.Equal(t, tc.size, opts.pageSize(), tc.max)
+	}
+}
@@ -0,0 +1,291 @@
+package gmaps
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	// maxGridZoom is the deepest zoom level a grid cell is split to.
+	// Beyond that Google returns the same places for every sub cell.
+	maxGridZoom = 20
+
+	// gridViewportPx is the viewport width used by fast mode requests,
+	// see buildGoogleMapsParams.
+	gridViewportPx = 600
+
+	metersPerDegreeLat = 111320.0
+)
+
+type LatLon struct {
+	Lat float64
+	Lon float64
+}
+
+type BoundingBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+func (b BoundingBox) Validate() error {
+	if b.MinLat < -90 || b.MaxLat > 90 || b.MinLon < -180 || b.MaxLon > 180 {
+		return errors.New("bounding box out of range")
+	}
+
+	if b.MinLat >= b.MaxLat || b.MinLon >= b.MaxLon {
+		return errors.New("bounding box has no area")
+	}
+
+	return nil
+}
+
+func (b BoundingBox) Center() LatLon {
+	return LatLon{
+		Lat: (b.MinLat + b.MaxLat) / 2,
+		Lon: (b.MinLon + b.MaxLon) / 2,
+	}
+}
+
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// Quadrants splits the box into four equally sized boxes.
+func (b BoundingBox) Quadrants() [4]BoundingBox {
+	c := b.Center()
+
+	return [4]BoundingBox{
+		{MinLat: b.MinLat, MinLon: b.MinLon, MaxLat: c.Lat, MaxLon: c.Lon},
+		{MinLat: b.MinLat, MinLon: c.Lon, MaxLat: c.Lat, MaxLon: b.MaxLon},
+		{MinLat: c.Lat, MinLon: b.MinLon, MaxLat: b.MaxLat, MaxLon: c.Lon},
+		{MinLat: c.Lat, MinLon: c.Lon, MaxLat: b.MaxLat, MaxLon: b.MaxLon},
+	}
+}
+
+func (b BoundingBox) corners() [4]LatLon {
+	return [4]LatLon{
+		{Lat: b.MinLat, Lon: b.MinLon},
+		{Lat: b.MinLat, Lon: b.MaxLon},
+		{Lat: b.MaxLat, Lon: b.MaxLon},
+		{Lat: b.MaxLat, Lon: b.MinLon},
+	}
+}
+
+// GridArea is the region covered by a grid sweep. It is either a bounding
+// box or a polygon. For polygons Bounds is the polygon's bounding box.
+type GridArea struct {
+	Bounds  BoundingBox
+	Polygon []LatLon
+}
+
+func NewBoundingBoxArea(b BoundingBox) (*GridArea, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &GridArea{Bounds: b}, nil
+}
+
+func NewPolygonArea(points []LatLon) (*GridArea, error) {
+	if len(points) < 3 {
+		return nil, errors.New("polygon needs at least 3 points")
+	}
+
+	b := BoundingBox{
+		MinLat: points[0].Lat,
+		MinLon: points[0].Lon,
+		MaxLat: points[0].Lat,
+		MaxLon: points[0].Lon,
+	}
+
+	for _, p := range points[1:] {
+		b.MinLat = math.Min(b.MinLat, p.Lat)
+		b.MinLon = math.Min(b.MinLon, p.Lon)
+		b.MaxLat = math.Max(b.MaxLat, p.Lat)
+		b.MaxLon = math.Max(b.MaxLon, p.Lon)
+	}
+
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &GridArea{Bounds: b, Polygon: points}, nil
+}
+
+// Contains reports whether the point lies inside the area.
+func (a *GridArea) Contains(lat, lon float64) bool {
+	if !a.Bounds.Contains(lat, lon) {
+		return false
+	}
+
+	if len(a.Polygon) == 0 {
+		return true
+	}
+
+	return pointInPolygon(lat, lon, a.Polygon)
+}
+
+// Overlaps reports whether the box shares any area with the grid area.
+func (a *GridArea) Overlaps(b BoundingBox) bool {
+	if b.MaxLat < a.Bounds.MinLat || b.MinLat > a.Bounds.MaxLat ||
+		b.MaxLon < a.Bounds.MinLon || b.MinLon > a.Bounds.MaxLon {
+		return false
+	}
+
+	if len(a.Polygon) == 0 {
+		return true
+	}
+
+	for _, c := range b.corners() {
+		if pointInPolygon(c.Lat, c.Lon, a.Polygon) {
+			return true
+		}
+	}
+
+	for _, p := range a.Polygon {
+		if b.Contains(p.Lat, p.Lon) {
+			return true
+		}
+	}
+
+	// a thin polygon may cross the box without any vertex inside either
+	corners := b.corners()
+
+	for i := range a.Polygon {
+		p1, p2 := a.Polygon[i], a.Polygon[(i+1)%len(a.Polygon)]
+
+		for j := range corners {
+			if segmentsIntersect(p1, p2, corners[j], corners[(j+1)%len(corners)]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// GridCell is a single tile of a grid sweep.
+type GridCell struct {
+	Bounds BoundingBox
+	Zoom   int
+	Area   *GridArea
+}
+
+// Location returns the search location for the cell. The radius is the
+// half diagonal of the cell so the radius filter never cuts off a corner.
+func (c *GridCell) Location() MapLocation {
+	center := c.Bounds.Center()
+
+	return MapLocation{
+		Lat:     center.Lat,
+		Lon:     center.Lon,
+		ZoomLvl: float64(c.Zoom),
+		Radius:  Haversine(center.Lat, center.Lon, c.Bounds.MaxLat, c.Bounds.MaxLon),
+	}
+}
+
+// CanSplit reports whether the cell may be split into smaller cells.
+func (c *GridCell) CanSplit() bool {
+	return c.Zoom < maxGridZoom
+}
+
+// Split divides the cell into its quadrants one zoom level deeper,
+// dropping the quadrants that fall outside of the area.
+func (c *GridCell) Split() []GridCell {
+	if !c.CanSplit() {
+		return nil
+	}
+
+	ans := make([]GridCell, 0, 4)
+
+	for _, q := range c.Bounds.Quadrants() {
+		if c.Area != nil && !c.Area.Overlaps(q) {
+			continue
+		}
+
+		ans = append(ans, GridCell{Bounds: q, Zoom: c.Zoom + 1, Area: c.Area})
+	}
+
+	return ans
+}
+
+// NewGridCells covers the area with cells sized to what a fast mode
+// search at the given zoom level displays.
+func NewGridCells(area *GridArea, zoom int) []GridCell {
+	if zoom < 1 || zoom > maxGridZoom {
+		zoom = 15
+	}
+
+	center := area.Bounds.Center()
+	side := cellSideMeters(center.Lat, zoom)
+
+	latStep := side / metersPerDegreeLat
+	lonStep := side / (metersPerDegreeLat * math.Max(math.Cos(center.Lat*math.Pi/180), 0.01))
+
+	rows := max(1, int(math.Ceil((area.Bounds.MaxLat-area.Bounds.MinLat)/latStep)))
+	cols := max(1, int(math.Ceil((area.Bounds.MaxLon-area.Bounds.MinLon)/lonStep)))
+
+	cells := make([]GridCell, 0, rows*cols)
+
+	for r := range rows {
+		for c := range cols {
+			b := BoundingBox{
+				MinLat: area.Bounds.MinLat + float64(r)*latStep,
+				MinLon: area.Bounds.MinLon + float64(c)*lonStep,
+			}
+
+			b.MaxLat = math.Min(b.MinLat+latStep, area.Bounds.MaxLat)
+			b.MaxLon = math.Min(b.MinLon+lonStep, area.Bounds.MaxLon)
+
+			if !area.Overlaps(b) {
+				continue
+			}
+
+			cells = append(cells, GridCell{Bounds: b, Zoom: zoom, Area: area})
+		}
+	}
+
+	return cells
+}
+
+// cellSideMeters is the width in meters of the fast mode viewport at the
+// given latitude and zoom level.
+func cellSideMeters(lat float64, zoom int) float64 {
+	const earthCircumferenceMetersPerPx = 156543.03392
+
+	metersPerPx := earthCircumferenceMetersPerPx * math.Cos(lat*math.Pi/180) / math.Pow(2, float64(zoom))
+
+	return metersPerPx * gridViewportPx
+}
+
+// pointInPolygon uses ray casting, treating coordinates as planar.
+func pointInPolygon(lat, lon float64, polygon []LatLon) bool {
+	inside := false
+
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		pi, pj := polygon[i], polygon[j]
+
+		if (pi.Lat > lat) != (pj.Lat > lat) &&
+			lon < (pj.Lon-pi.Lon)*(lat-pi.Lat)/(pj.Lat-pi.Lat)+pi.Lon {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+func segmentsIntersect(p1, p2, q1, q2 LatLon) bool {
+	orient := func(a, b, c LatLon) float64 {
+		return (b.Lon-a.Lon)*(c.Lat-a.Lat) - (b.Lat-a.Lat)*(c.Lon-a.Lon)
+	}
+
+	d1 := orient(q1, q2, p1)
+	d2 := orient(q1, q2, p2)
+	d3 := orient(p1, p2, q1)
+	d4 := orient(p1, p2, q2)
+
+	return ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) &&
+		((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0))
+}
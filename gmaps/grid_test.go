@@ -0,0 +1,60 @@
+package gmaps_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func Test_NewGridCells(t *testing.T) {
+	area, err := gmaps.NewBoundingBoxArea(gmaps.BoundingBox{
+		MinLat: 40.70,
+		MinLon: -74.02,
+		MaxLat: 40.80,
+		MaxLon: -73.93,
+	})
+	require.NoError(t, err)
+
+	cells := gmaps.NewGridCells(area, 15)
+	require.Greater(t, len(cells), 1)
+
+	for i := range cells {
+		require.Equal(t, 15, cells[i].Zoom)
+		require.True(t, area.Overlaps(cells[i].Bounds))
+
+		loc := cells[i].Location()
+		require.True(t, cells[i].Bounds.Contains(loc.Lat, loc.Lon))
+		require.Greater(t, loc.Radius, 0.0)
+	}
+
+	coarse := gmaps.NewGridCells(area, 13)
+	require.Less(t, len(coarse), len(cells))
+}
+
+func Test_GridCellSplit(t *testing.T) {
+	area, err := gmaps.NewPolygonArea([]gmaps.LatLon{
+		{Lat: 0, Lon: 0},
+		{Lat: 0, Lon: 1},
+		{Lat: 1, Lon: 0},
+	})
+	require.NoError(t, err)
+
+	cell := gmaps.GridCell{Bounds: area.Bounds, Zoom: 10, Area: area}
+
+	children := cell.Split()
+	// the quadrant opposite to the right angle is outside the triangle
+	require.Len(t, children, 3)
+
+	for i := range children {
+		require.Equal(t, 11, children[i].Zoom)
+	}
+
+	require.True(t, area.Contains(0.2, 0.2))
+	require.False(t, area.Contains(0.8, 0.8))
+
+	deepest := gmaps.GridCell{Bounds: area.Bounds, Zoom: 20, Area: area}
+	require.False(t, deepest.CanSplit())
+	require.Empty(t, deepest.Split())
+}
@@ -2,8 +2,8 @@ package gmaps
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"strings"
@@ -13,8 +13,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/gosom/scrapemate"
 
+	"github.com/gosom/google-maps-scraper/budget"
 	"github.com/gosom/google-maps-scraper/deduper"
 	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/ratelimit"
 )
 
 type GmapJobOptions func(*GmapJob)
@@ -29,7 +31,13 @@ type GmapJob struct {
 	Deduper             deduper.Deduper
 	ExitMonitor         exiter.Exiter
 	ExtractExtraReviews bool
+	ReviewOptions       ReviewOptions
 	SearchDelay         int
+
+	reviewStore ReviewStore
+	backoff     *Backoff
+	rateLimiter *ratelimit.Limiter
+	share       *budget.Share
 }
 
 func NewGmapJob(
@@ -99,12 +107,80 @@ func WithExtraReviews() GmapJobOptions {
 	}
 }
 
+// WithReviewOptions sets the sort order and the limits of the extra reviews
+// of the places.
+func WithReviewOptions(opts ReviewOptions) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.ReviewOptions = opts
+	}
+}
+
+// WithReviewStore makes the place jobs fetch only the reviews posted since
+// the reviews in the store.
+func WithReviewStore(store ReviewStore) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.reviewStore = store
+	}
+}
+
 func WithSearchDelay(d int) GmapJobOptions {
 	return func(j *GmapJob) {
 		j.SearchDelay = d
 	}
 }
 
+// WithBackoff makes the job and its place jobs wait for the cool-down of
+// the run after a block.
+func WithBackoff(b *Backoff) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.backoff = b
+	}
+}
+
+// WithRateLimiter makes the job and the jobs it creates wait for their turn
+// to send a request to their host.
+func WithRateLimiter(l *ratelimit.Limiter) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.rateLimiter = l
+	}
+}
+
+// WithBudget makes the job and the jobs it creates take a slot of the
+// budget of the process while they send their request.
+func WithBudget(s *budget.Share) GmapJobOptions {
+	return func(j *GmapJob) {
+		j.share = s
+	}
+}
+
+func (j *GmapJob) placeJobOptions() []PlaceJobOptions {
+	jopts := []PlaceJobOptions{
+		WithPlaceJobReviewOptions(j.ReviewOptions),
+	}
+
+	if j.ExitMonitor != nil {
+		jopts = append(jopts, WithPlaceJobExitMonitor(j.ExitMonitor))
+	}
+
+	if j.reviewStore != nil {
+		jopts = append(jopts, WithPlaceJobReviewStore(j.reviewStore))
+	}
+
+	if j.backoff != nil {
+		jopts = append(jopts, WithPlaceJobBackoff(j.backoff))
+	}
+
+	if j.rateLimiter != nil {
+		jopts = append(jopts, WithPlaceJobRateLimiter(j.rateLimiter))
+	}
+
+	if j.share != nil {
+		jopts = append(jopts, WithPlaceJobBudget(j.share))
+	}
+
+	return jopts
+}
+
 func (j *GmapJob) UseInResults() bool {
 	return false
 }
@@ -125,10 +201,7 @@ func (j *GmapJob) Process(ctx context.Context, resp *scrapemate.Response) (any,
 	var next []scrapemate.IJob
 
 	if strings.Contains(resp.URL, "/maps/place/") {
-		jopts := []PlaceJobOptions{}
-		if j.ExitMonitor != nil {
-			jopts = append(jopts, WithPlaceJobExitMonitor(j.ExitMonitor))
-		}
+		jopts := j.placeJobOptions()
 
 		placeJob := NewPlaceJob(j.ID, j.LangCode, resp.URL, j.ExtractEmail, j.ExtractExtraReviews, jopts...)
 
@@ -136,10 +209,7 @@ func (j *GmapJob) Process(ctx context.Context, resp *scrapemate.Response) (any,
 	} else {
 		doc.Find(`div[role=feed] div[jsaction]>a`).Each(func(_ int, s *goquery.Selection) {
 			if href := s.AttrOr("href", ""); href != "" {
-				jopts := []PlaceJobOptions{}
-				if j.ExitMonitor != nil {
-					jopts = append(jopts, WithPlaceJobExitMonitor(j.ExitMonitor))
-				}
+				jopts := j.placeJobOptions()
 
 				nextJob := NewPlaceJob(j.ID, j.LangCode, href, j.ExtractEmail, j.ExtractExtraReviews, jopts...)
 
@@ -161,14 +231,29 @@ func (j *GmapJob) Process(ctx context.Context, resp *scrapemate.Response) (any,
 }
 
 func (j *GmapJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPage) scrapemate.Response {
-	if j.SearchDelay > 0 {
-		// add some randomness +- 30%
-		randFactor := 0.7 + (0.6 * rand.Float64())
-		sleepTime := time.Duration(float64(j.SearchDelay)*randFactor) * time.Second
-		time.Sleep(sleepTime)
+	var resp scrapemate.Response
+
+	if err := j.backoff.Wait(ctx); err != nil {
+		resp.Error = err
+
+		return resp
 	}
 
-	var resp scrapemate.Response
+	if err := waitSearch(ctx, j.rateLimiter, j.GetFullURL(), j.SearchDelay); err != nil {
+		resp.Error = err
+
+		return resp
+	}
+
+	if err := j.share.Acquire(ctx); err != nil {
+		resp.Error = err
+
+		return resp
+	}
+
+	defer j.share.Release()
+
+	defer recordAttempt(j.ExitMonitor, j.backoff, &resp)
 
 	pageResponse, err := page.Goto(j.GetFullURL(), scrapemate.WaitUntilDOMContentLoaded)
 	if err != nil {
@@ -193,6 +278,12 @@ func (j *GmapJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPag
 	resp.StatusCode = pageResponse.StatusCode
 	resp.Headers = pageResponse.Headers
 
+	if blocked := DetectBlock(resp.StatusCode, page.URL(), nil); blocked != nil {
+		setBlocked(&resp, blocked)
+
+		return resp
+	}
+
 	// When Google Maps finds only 1 place, it slowly redirects to that place's URL
 	// check element scroll
 	sel := `div[role='feed']`
@@ -208,6 +299,15 @@ func (j *GmapJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPag
 		singlePlace = waitUntilURLContains(waitCtx, page, "/maps/place/")
 
 		waitCancel()
+
+		// a block page has no results either
+		if !singlePlace {
+			if blocked := detectPageBlock(page, resp.StatusCode); blocked != nil {
+				setBlocked(&resp, blocked)
+
+				return resp
+			}
+		}
 	}
 
 	if singlePlace {
@@ -246,6 +346,34 @@ func (j *GmapJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPag
 	return resp
 }
 
+// recordAttempt counts a failed or blocked attempt of a job on its exit
+// monitor, and tells the backoff how it went.
+func recordAttempt(e exiter.Exiter, b *Backoff, resp *scrapemate.Response) {
+	var blocked *BlockedError
+
+	switch {
+	case errors.As(resp.Error, &blocked):
+		b.Blocked(blocked)
+
+		if e != nil {
+			e.IncrBlocks(1)
+		}
+	case resp.Error != nil:
+		if e != nil {
+			e.IncrErrors(1)
+		}
+	default:
+		b.Succeeded()
+	}
+}
+
+// setBlocked makes the response of a blocked attempt. Its status code makes
+// scrapemate try the job again, after the cool-down.
+func setBlocked(resp *scrapemate.Response, blocked *BlockedError) {
+	resp.Error = blocked
+	resp.StatusCode = http.StatusTooManyRequests
+}
+
 func waitUntilURLContains(ctx context.Context, page scrapemate.BrowserPage, s string) bool {
 	ticker := time.NewTicker(time.Millisecond * 150)
 	defer ticker.Stop()
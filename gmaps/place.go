@@ -3,13 +3,16 @@ package gmaps
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gosom/scrapemate"
 
+	"github.com/gosom/google-maps-scraper/budget"
 	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/ratelimit"
 )
 
 type PlaceJobOptions func(*PlaceJob)
@@ -21,6 +24,12 @@ type PlaceJob struct {
 	ExtractEmail        bool
 	ExitMonitor         exiter.Exiter
 	ExtractExtraReviews bool
+	ReviewOptions       ReviewOptions
+
+	reviewStore ReviewStore
+	backoff     *Backoff
+	rateLimiter *ratelimit.Limiter
+	share       *budget.Share
 }
 
 func NewPlaceJob(parentID, langCode, u string, extractEmail, extraExtraReviews bool, opts ...PlaceJobOptions) *PlaceJob {
@@ -58,7 +67,47 @@ func WithPlaceJobExitMonitor(exitMonitor exiter.Exiter) PlaceJobOptions {
 	}
 }
 
-func (j *PlaceJob) Process(_ context.Context, resp *scrapemate.Response) (any, []scrapemate.IJob, error) {
+// WithPlaceJobReviewOptions sets the sort order and the limits of the extra
+// reviews.
+func WithPlaceJobReviewOptions(opts ReviewOptions) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.ReviewOptions = opts
+	}
+}
+
+// WithPlaceJobBackoff makes the job wait for the cool-down of the run after
+// a block.
+func WithPlaceJobBackoff(b *Backoff) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.backoff = b
+	}
+}
+
+// WithPlaceJobRateLimiter makes the job and its email job wait for their
+// turn to send a request to their host.
+func WithPlaceJobRateLimiter(l *ratelimit.Limiter) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.rateLimiter = l
+	}
+}
+
+// WithPlaceJobBudget makes the job and its email job take a slot of the
+// budget of the process while they send their request.
+func WithPlaceJobBudget(s *budget.Share) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.share = s
+	}
+}
+
+// WithPlaceJobReviewStore makes the job fetch only the reviews posted since
+// the reviews in the store.
+func WithPlaceJobReviewStore(store ReviewStore) PlaceJobOptions {
+	return func(j *PlaceJob) {
+		j.reviewStore = store
+	}
+}
+
+func (j *PlaceJob) Process(ctx context.Context, resp *scrapemate.Response) (any, []scrapemate.IJob, error) {
 	defer func() {
 		resp.Document = nil
 		resp.Body = nil
@@ -94,12 +143,38 @@ func (j *PlaceJob) Process(_ context.Context, resp *scrapemate.Response) (any, [
 		entry.UserReviewsExtended = append(entry.UserReviewsExtended, convertedReviews...)
 	}
 
+	if checkpoint, ok := resp.Meta["reviews_checkpoint"].(ReviewCheckpoint); ok {
+		// the last page usually overlaps with the reviews stored before
+		entry.UserReviewsExtended = slices.DeleteFunc(entry.UserReviewsExtended, func(r Review) bool {
+			return checkpoint.Known(&r)
+		})
+	}
+
+	entry.UserReviewsExtended = j.ReviewOptions.apply(entry.UserReviewsExtended)
+
+	if j.reviewStore != nil && (len(allReviewsRaw.pages) > 0 || len(domReviews) > 0) {
+		if err := j.reviewStore.Save(ctx, entry.DataID, entry.UserReviewsExtended); err != nil {
+			fmt.Printf("Warning: saving the review checkpoint failed: %v\n", err)
+		}
+	}
+
 	if j.ExtractEmail && entry.IsWebsiteValidForEmail() {
-		opts := []EmailExtractJobOptions{}
+		opts := []EmailExtractJobOptions{
+			WithEmailJobPlaceURL(j.GetURL()),
+		}
+
 		if j.ExitMonitor != nil {
 			opts = append(opts, WithEmailJobExitMonitor(j.ExitMonitor))
 		}
 
+		if j.rateLimiter != nil {
+			opts = append(opts, WithEmailJobRateLimiter(j.rateLimiter))
+		}
+
+		if j.share != nil {
+			opts = append(opts, WithEmailJobBudget(j.share))
+		}
+
 		emailJob := NewEmailJob(j.ID, &entry, opts...)
 
 		j.UsageInResultststs = false
@@ -115,6 +190,28 @@ func (j *PlaceJob) Process(_ context.Context, resp *scrapemate.Response) (any, [
 func (j *PlaceJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPage) scrapemate.Response {
 	var resp scrapemate.Response
 
+	if err := j.backoff.Wait(ctx); err != nil {
+		resp.Error = err
+
+		return resp
+	}
+
+	if err := j.rateLimiter.Wait(ctx, j.GetURL()); err != nil {
+		resp.Error = err
+
+		return resp
+	}
+
+	if err := j.share.Acquire(ctx); err != nil {
+		resp.Error = err
+
+		return resp
+	}
+
+	defer j.share.Release()
+
+	defer recordAttempt(j.ExitMonitor, j.backoff, &resp)
+
 	pageResponse, err := page.Goto(j.GetURL(), scrapemate.WaitUntilDOMContentLoaded)
 	if err != nil {
 		resp.Error = err
@@ -138,10 +235,21 @@ func (j *PlaceJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPa
 	resp.StatusCode = pageResponse.StatusCode
 	resp.Headers = pageResponse.Headers
 
+	if blocked := DetectBlock(resp.StatusCode, page.URL(), nil); blocked != nil {
+		setBlocked(&resp, blocked)
+
+		return resp
+	}
+
 	raw, err := j.extractJSON(page)
 	if err != nil {
 		resp.Error = err
 
+		// a block page has no place data either
+		if blocked := detectPageBlock(page, resp.StatusCode); blocked != nil {
+			setBlocked(&resp, blocked)
+		}
+
 		return resp
 	}
 
@@ -152,12 +260,26 @@ func (j *PlaceJob) BrowserActions(ctx context.Context, page scrapemate.BrowserPa
 	resp.Meta["json"] = raw
 
 	if j.ExtractExtraReviews {
-		reviewCount := j.getReviewCount(raw)
-		if reviewCount > 8 { // we have more reviews
+		place, err := EntryFromJSON(raw, true)
+
+		// the place page has up to 8 reviews in the default order
+		if err == nil && (place.ReviewCount > 8 || (place.ReviewCount > 0 && j.ReviewOptions.Sort > ReviewSortRelevant)) {
 			params := fetchReviewsParams{
 				page:        page,
 				mapURL:      page.URL(),
-				reviewCount: reviewCount,
+				reviewCount: place.ReviewCount,
+				options:     j.ReviewOptions,
+			}
+
+			if j.reviewStore != nil {
+				checkpoint, err := j.reviewStore.Checkpoint(ctx, place.DataID)
+				if err != nil {
+					fmt.Printf("Warning: loading the review checkpoint failed: %v\n", err)
+				} else {
+					params.options.Sort = ReviewSortNewest
+					params.checkpoint = checkpoint
+					resp.Meta["reviews_checkpoint"] = checkpoint
+				}
 			}
 
 			// Use the new fallback mechanism that tries RPC first, then DOM
@@ -255,15 +377,6 @@ func (j *PlaceJob) extractJSON(page scrapemate.BrowserPage) ([]byte, error) {
 	return nil, fmt.Errorf("APP_INITIALIZATION_STATE data not found after retries")
 }
 
-func (j *PlaceJob) getReviewCount(data []byte) int {
-	tmpEntry, err := EntryFromJSON(data, true)
-	if err != nil {
-		return 0
-	}
-
-	return tmpEntry.ReviewCount
-}
-
 func (j *PlaceJob) UseInResults() bool {
 	return j.UsageInResultststs
 }
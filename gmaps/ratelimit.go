@@ -0,0 +1,33 @@
+package gmaps
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/ratelimit"
+)
+
+// searchJitter keeps the search delays of the jobs without a rate limiter
+// around +-30% as before.
+const searchJitter = 0.3
+
+// defaultLimiter spaces the searches of the jobs without a rate limiter.
+var defaultLimiter = ratelimit.New(ratelimit.WithJitter(searchJitter))
+
+// waitSearch waits for the turn of a search to rawURL. With a search delay,
+// in seconds, it is also spaced by the delay from the other searches with
+// the same one, whichever worker runs them.
+func waitSearch(ctx context.Context, l *ratelimit.Limiter, rawURL string, delay int) error {
+	if delay > 0 {
+		if l == nil {
+			l = defaultLimiter
+		}
+
+		if err := l.WaitEvery(ctx, "search/"+strconv.Itoa(delay), time.Duration(delay)*time.Second); err != nil {
+			return err
+		}
+	}
+
+	return l.Wait(ctx, rawURL)
+}
@@ -0,0 +1,113 @@
+package gmaps
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ReviewSort is the order Google returns the reviews of a place in.
+type ReviewSort int
+
+const (
+	ReviewSortRelevant ReviewSort = iota + 1
+	ReviewSortNewest
+	ReviewSortHighest
+	ReviewSortLowest
+)
+
+var reviewSortNames = map[ReviewSort]string{
+	ReviewSortRelevant: "relevant",
+	ReviewSortNewest:   "newest",
+	ReviewSortHighest:  "highest",
+	ReviewSortLowest:   "lowest",
+}
+
+// ParseReviewSort parses one of relevant, newest, highest or lowest. The
+// empty string is Google's default order.
+func ParseReviewSort(s string) (ReviewSort, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	for sort, name := range reviewSortNames {
+		if s == name {
+			return sort, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid review sort %q, must be one of relevant, newest, highest or lowest", s)
+}
+
+func (s ReviewSort) String() string {
+	return reviewSortNames[s]
+}
+
+// pbValue is the value of the sort in the reviews RPC, where the zero value
+// is Google's default order.
+func (s ReviewSort) pbValue() int {
+	return int(cmp.Or(s, ReviewSortRelevant))
+}
+
+// ParseReviewsSince parses a review cutoff date in the 2006-01-02 or the
+// RFC 3339 format. The empty string is no cutoff.
+func ParseReviewsSince(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid reviews since %q, use the 2006-01-02 format", s)
+	}
+
+	return t.UTC(), nil
+}
+
+// ReviewOptions selects the extra reviews fetched for a place. The zero
+// value fetches all of them in Google's default order.
+type ReviewOptions struct {
+	Sort ReviewSort
+	// Max is the maximum number of extra reviews per place, zero for all.
+	Max int
+	// Since drops the reviews posted before it.
+	Since time.Time
+}
+
+// pageSize is the number of reviews to request per page.
+func (o *ReviewOptions) pageSize() int {
+	const defaultPageSize = 20
+
+	if o.Max > 0 && o.Max < defaultPageSize {
+		return o.Max
+	}
+
+	return defaultPageSize
+}
+
+// before reports whether the review was posted before the cutoff. Reviews
+// without a time are kept.
+func (o *ReviewOptions) before(r *Review) bool {
+	return !o.Since.IsZero() && !r.Time.IsZero() && r.Time.Before(o.Since)
+}
+
+// apply drops the reviews before the cutoff and the ones over the maximum.
+func (o *ReviewOptions) apply(reviews []Review) []Review {
+	reviews = slices.DeleteFunc(reviews, func(r Review) bool {
+		return o.before(&r)
+	})
+
+	if o.Max > 0 && len(reviews) > o.Max {
+		reviews = reviews[:o.Max]
+	}
+
+	return reviews
+}
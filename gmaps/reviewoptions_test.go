@@ -0,0 +1,44 @@
+package gmaps_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func Test_ParseReviewSort(t *testing.T) {
+	for in, want := range map[string]gmaps.ReviewSort{
+		"":         0,
+		"newest":   gmaps.ReviewSortNewest,
+		" Lowest ": gmaps.ReviewSortLowest,
+		"relevant": gmaps.ReviewSortRelevant,
+		"highest":  gmaps.ReviewSortHighest,
+	} {
+		got, err := gmaps.ParseReviewSort(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+
+	_, err := gmaps.ParseReviewSort("oldest")
+	require.Error(t, err)
+}
+
+func Test_ParseReviewsSince(t *testing.T) {
+	got, err := gmaps.ParseReviewsSince("2025-06-01")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), got)
+
+	got, err = gmaps.ParseReviewsSince("2025-06-01T10:00:00+02:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC), got)
+
+	got, err = gmaps.ParseReviewsSince("")
+	require.NoError(t, err)
+	require.True(t, got.IsZero())
+
+	_, err = gmaps.ParseReviewsSince("01/06/2025")
+	require.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package gmaps
+
+import (
+	"strconv"
+	"time"
+)
+
+// ReviewRow is a single review of a place, keyed by the place's DataID and
+// Cid. It is the unit of the reviews export.
+type ReviewRow struct {
+	DataID            string    `json:"data_id"`
+	Cid               string    `json:"cid"`
+	ReviewID          string    `json:"review_id"`
+	Author            string    `json:"author"`
+	AuthorPicture     string    `json:"author_picture"`
+	Rating            int       `json:"rating"`
+	Text              string    `json:"text"`
+	When              string    `json:"when"`
+	Time              time.Time `json:"time,omitzero"`
+	TimeEstimated     bool      `json:"time_estimated"`
+	RelativeTime      string    `json:"relative_time"`
+	OwnerResponse     string    `json:"owner_response"`
+	OwnerResponseTime time.Time `json:"owner_response_time,omitzero"`
+	Images            []string  `json:"images"`
+}
+
+// ReviewRows returns the reviews of the entry, the inline ones and the
+// extra ones, with the reviews that appear in both listed once.
+func (e *Entry) ReviewRows() []ReviewRow {
+	return NewReviewRows(e.DataID, e.Cid, e.UserReviews, e.UserReviewsExtended)
+}
+
+// NewReviewRows flattens the review lists of one place, skipping reviews
+// with an id that was already seen.
+func NewReviewRows(dataID, cid string, lists ...[]Review) []ReviewRow {
+	var (
+		ans  []ReviewRow
+		seen = map[string]bool{}
+	)
+
+	for _, reviews := range lists {
+		for i := range reviews {
+			r := &reviews[i]
+
+			id := r.ID
+			if id == "" {
+				id = reviewID("", r.Name, r.Description)
+			}
+
+			if seen[id] {
+				continue
+			}
+
+			seen[id] = true
+
+			images := r.Images
+			if images == nil {
+				images = []string{}
+			}
+
+			ans = append(ans, ReviewRow{
+				DataID:            dataID,
+				Cid:               cid,
+				ReviewID:          id,
+				Author:            r.Name,
+				AuthorPicture:     r.ProfilePicture,
+				Rating:            r.Rating,
+				Text:              r.Description,
+				When:              r.When,
+				Time:              r.Time,
+				TimeEstimated:     r.TimeEstimated,
+				RelativeTime:      r.RelativeTime,
+				OwnerResponse:     r.OwnerResponse,
+				OwnerResponseTime: r.OwnerResponseTime,
+				Images:            images,
+			})
+		}
+	}
+
+	return ans
+}
+
+func (r *ReviewRow) CsvHeaders() []string {
+	return []string{
+		"data_id",
+		"cid",
+		"review_id",
+		"author",
+		"author_picture",
+		"rating",
+		"text",
+		"when",
+		"time",
+		"time_estimated",
+		"relative_time",
+		"owner_response",
+		"owner_response_time",
+		"images",
+	}
+}
+
+func (r *ReviewRow) CsvRow() []string {
+	return []string{
+		r.DataID,
+		r.Cid,
+		r.ReviewID,
+		r.Author,
+		r.AuthorPicture,
+		strconv.Itoa(r.Rating),
+		r.Text,
+		r.When,
+		formatReviewTime(r.Time),
+		strconv.FormatBool(r.TimeEstimated),
+		r.RelativeTime,
+		r.OwnerResponse,
+		formatReviewTime(r.OwnerResponseTime),
+		stringify(r.Images),
+	}
+}
+
+func formatReviewTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,47 @@
+package gmaps_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func Test_NewReviewRows(t *testing.T) {
+	posted := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	inline := []gmaps.Review{
+		{ID: "r1", Name: "Ana", Rating: 5, Description: "Great", Time: posted, When: "2025-3-1"},
+		{Name: "Ben", Rating: 3, Description: "Slow"},
+	}
+
+	extended := []gmaps.Review{
+		// the inline reviews are also among the extra ones
+		{ID: "r1", Name: "Ana", Rating: 5, Description: "Great"},
+		{Name: "Ben", Rating: 3, Description: "Slow"},
+		{ID: "r2", Name: "Cara", Rating: 4, Description: "Nice", OwnerResponse: "Thanks", Images: []string{"https://img"}},
+	}
+
+	rows := gmaps.NewReviewRows("0x1:0x2", "42", inline, extended)
+	require.Len(t, rows, 3)
+
+	require.Equal(t, "r1", rows[0].ReviewID)
+	require.Equal(t, "0x1:0x2", rows[0].DataID)
+	require.Equal(t, "42", rows[0].Cid)
+	require.Equal(t, posted, rows[0].Time)
+	require.Equal(t, []string{}, rows[0].Images)
+
+	require.Regexp(t, `^h_[0-9a-f]{20}$`, rows[1].ReviewID)
+	require.Equal(t, "Ben", rows[1].Author)
+
+	require.Equal(t, "r2", rows[2].ReviewID)
+	require.Equal(t, "Thanks", rows[2].OwnerResponse)
+
+	record := rows[0].CsvRow()
+	require.Len(t, record, len(rows[0].CsvHeaders()))
+	require.Equal(t, []string{"0x1:0x2", "42", "r1", "Ana", "", "5", "Great", "2025-3-1", "2025-03-01T00:00:00Z", "false", "", "", "", "[]"}, record)
+
+	require.Empty(t, gmaps.NewReviewRows("0x1:0x2", "42"))
+}
@@ -22,6 +22,10 @@ type fetchReviewsParams struct {
 	page        scrapemate.BrowserPage
 	mapURL      string
 	reviewCount int
+	options     ReviewOptions
+	// checkpoint stops the paging at the first page with a review that
+	// was stored by an earlier crawl. It needs the newest first sort.
+	checkpoint ReviewCheckpoint
 }
 
 type FetchReviewsResponse struct {
@@ -31,6 +35,7 @@ type FetchReviewsResponse struct {
 type fetcher struct {
 	httpClient scrapemate.HTTPFetcher
 	params     fetchReviewsParams
+	fetched    int
 }
 
 func newReviewFetcher(params fetchReviewsParams) *fetcher {
@@ -49,7 +54,7 @@ func (f *fetcher) fetch(ctx context.Context) (FetchReviewsResponse, error) {
 		return FetchReviewsResponse{}, fmt.Errorf("failed to generate session request ID: %v", err)
 	}
 
-	reviewURL, err := f.generateURL(f.params.mapURL, "", 20, requestIDForSession)
+	reviewURL, err := f.generateURL(f.params.mapURL, "", f.params.options.pageSize(), requestIDForSession)
 	if err != nil {
 		return FetchReviewsResponse{}, fmt.Errorf("failed to generate initial URL: %v", err)
 	}
@@ -76,8 +81,8 @@ func (f *fetcher) fetch(ctx context.Context) (FetchReviewsResponse, error) {
 
 	nextPageToken := extractNextPageToken(currentPageBody)
 
-	for nextPageToken != "" {
-		reviewURL, err = f.generateURL(f.params.mapURL, nextPageToken, 20, requestIDForSession)
+	for nextPageToken != "" && !f.lastPage(currentPageBody) {
+		reviewURL, err = f.generateURL(f.params.mapURL, nextPageToken, f.params.options.pageSize(), requestIDForSession)
 		if err != nil {
 			log.Printf("Error generating URL for token %s: %v", nextPageToken, err)
 			break
@@ -145,8 +150,8 @@ func (f *fetcher) fetchWithBrowser(_ context.Context, initialURL, requestID stri
 
 	// Get additional pages
 	nextPageToken := extractNextPageToken([]byte(data))
-	for nextPageToken != "" && len(ans.pages) < 50 { // Limit to 50 pages
-		nextURL, err := f.generateURL(f.params.mapURL, nextPageToken, 20, requestID)
+	for nextPageToken != "" && len(ans.pages) < 50 && !f.lastPage([]byte(data)) { // Limit to 50 pages
+		nextURL, err := f.generateURL(f.params.mapURL, nextPageToken, f.params.options.pageSize(), requestID)
 		if err != nil {
 			break
 		}
@@ -188,6 +193,33 @@ func (f *fetcher) fetchWithBrowser(_ context.Context, initialURL, requestID stri
 	return ans, nil
 }
 
+// lastPage reports whether the pages after the page are not needed: the
+// maximum number of reviews was fetched, or, in the newest first order,
+// the page reached the cutoff date or the reviews an earlier crawl stored.
+func (f *fetcher) lastPage(page []byte) bool {
+	opts := &f.params.options
+
+	if opts.Max == 0 && f.params.checkpoint.IsZero() && (opts.Since.IsZero() || opts.Sort != ReviewSortNewest) {
+		return false
+	}
+
+	reviews := extractReviews(page)
+	done := false
+
+	for i := range reviews {
+		switch {
+		case f.params.checkpoint.Known(&reviews[i]):
+			done = true
+		case opts.before(&reviews[i]):
+			done = done || opts.Sort == ReviewSortNewest
+		default:
+			f.fetched++
+		}
+	}
+
+	return done || (opts.Max > 0 && f.fetched >= opts.Max)
+}
+
 var (
 	patternsOnce sync.Once
 	patterns     map[string]*regexp.Regexp
@@ -247,7 +279,7 @@ func (f *fetcher) generateURL(mapURL, pageToken string, pageSize int, requestID
 		fmt.Sprintf("!2m2!1i%d!2s%s", pageSize, encodedPageToken),
 		fmt.Sprintf("!5m2!1s%s!7e81", requestID),
 		"!8m9!2b1!3b1!5b1!7b1",
-		"!12m4!1b1!2b1!4m1!1e1!11m0!13m1!1e1",
+		fmt.Sprintf("!12m4!1b1!2b1!4m1!1e1!11m0!13m1!1e%d", f.params.options.Sort.pbValue()),
 	}
 
 	// Use English language for consistent parsing
@@ -324,6 +356,7 @@ func generateRandomID(length int) (string, error) {
 
 // DOMReview represents a review extracted from the DOM
 type DOMReview struct {
+	ID                      string
 	AuthorName              string
 	AuthorURL               string
 	ProfilePicture          string
@@ -331,24 +364,41 @@ type DOMReview struct {
 	RelativeTimeDescription string
 	Text                    string
 	Images                  []string
+	OwnerResponse           string
+	OwnerResponseTime       string
 }
 
-// ConvertDOMReviewsToReviews converts DOMReview slice to Review slice
+// ConvertDOMReviewsToReviews converts DOMReview slice to Review slice.
+// The DOM only shows relative dates, so the review times are estimated.
 func ConvertDOMReviewsToReviews(domReviews []DOMReview) []Review {
 	reviews := make([]Review, 0, len(domReviews))
+	now := time.Now()
 
 	for _, dr := range domReviews {
+		if dr.AuthorName == "" {
+			continue
+		}
+
 		review := Review{
+			ID:             reviewID(dr.ID, dr.AuthorName, dr.Text),
 			Name:           dr.AuthorName,
 			ProfilePicture: dr.ProfilePicture,
 			Rating:         dr.Rating,
 			Description:    dr.Text,
-			When:           dr.RelativeTimeDescription,
 			Images:         dr.Images,
 		}
-		if review.Name != "" {
-			reviews = append(reviews, review)
+
+		review.setReviewTime(time.Time{}, dr.RelativeTimeDescription, now)
+
+		if dr.OwnerResponse != "" {
+			review.OwnerResponse = dr.OwnerResponse
+
+			if t, ok := EstimateReviewTime(dr.OwnerResponseTime, now); ok {
+				review.OwnerResponseTime = t.UTC()
+			}
 		}
+
+		reviews = append(reviews, review)
 	}
 
 	return reviews
@@ -584,15 +634,30 @@ func extractReviewsFromPage(ctx context.Context, page scrapemate.BrowserPage) ([
 							}
 						}
 
+						// Owner response
+						let ownerResponse = '';
+						let ownerResponseTime = '';
+						const ownerEl = element.querySelector('.CDe7pd');
+						if (ownerEl) {
+							ownerResponse = ownerEl.querySelector('.wiI7pd')?.textContent?.trim() || '';
+							ownerResponseTime = ownerEl.querySelector('.DZSIDd')?.textContent?.trim() || '';
+						}
+
+						const reviewId = element.getAttribute('data-review-id') ||
+							element.querySelector('[data-review-id]')?.getAttribute('data-review-id') || '';
+
 						if (userName && (text || rating > 0)) {
 							reviews.push({
+								review_id: reviewId,
 								author_name: userName,
 								author_url: userUrl,
 								profile_picture: profilePic,
 								rating: rating,
 								relative_time_description: relativeTime,
 								text: text,
-								images: images
+								images: images,
+								owner_response: ownerResponse,
+								owner_response_time: ownerResponseTime
 							});
 						}
 					} catch (e) {
@@ -619,6 +684,10 @@ func extractReviewsFromPage(ctx context.Context, page scrapemate.BrowserPage) ([
 					}
 
 					review := DOMReview{}
+					if v, ok := reviewMap["review_id"].(string); ok {
+						review.ID = v
+					}
+
 					if v, ok := reviewMap["author_name"].(string); ok {
 						review.AuthorName = v
 					}
@@ -643,6 +712,14 @@ func extractReviewsFromPage(ctx context.Context, page scrapemate.BrowserPage) ([
 						review.Text = v
 					}
 
+					if v, ok := reviewMap["owner_response"].(string); ok {
+						review.OwnerResponse = v
+					}
+
+					if v, ok := reviewMap["owner_response_time"].(string); ok {
+						review.OwnerResponseTime = v
+					}
+
 					if v, ok := reviewMap["images"].([]interface{}); ok {
 						for _, img := range v {
 							if imgStr, ok := img.(string); ok {
@@ -651,10 +728,15 @@ func extractReviewsFromPage(ctx context.Context, page scrapemate.BrowserPage) ([
 						}
 					}
 
-					// Add if unique (check by author name and text prefix)
+					// Add if unique (check by review id, author name and text prefix)
 					isDuplicate := false
 
 					for _, existing := range reviews {
+						if review.ID != "" && existing.ID == review.ID {
+							isDuplicate = true
+							break
+						}
+
 						if existing.AuthorName == review.AuthorName {
 							if existing.Text == review.Text {
 								isDuplicate = true
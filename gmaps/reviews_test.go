@@ -0,0 +1,71 @@
+package gmaps_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// Test_ReviewParsersParity checks that the RPC and the DOM extraction of the
+// same reviews agree on what the checkpoints and the exports rely on.
+func Test_ReviewParsersParity(t *testing.T) {
+	raw, err := os.ReadFile("../testdata/reviews_rpc.json")
+	require.NoError(t, err)
+
+	var entry gmaps.Entry
+
+	entry.AddExtraReviews([][]byte{raw})
+
+	// the same reviews as the page shows them
+	dom := gmaps.ConvertDOMReviewsToReviews([]gmaps.DOMReview{
+		{
+			ID:                      "ChZDSUhNMG9nS0VJQ0FnSUR2c3JLQ0ZREAE",
+			AuthorName:              "Ana Silva",
+			ProfilePicture:          "https://lh3.googleusercontent.com/a/ana",
+			Rating:                  5,
+			RelativeTimeDescription: "2 months ago",
+			Text:                    "Great coffee and friendly staff.",
+			OwnerResponse:           "Thank you, Ana!",
+			OwnerResponseTime:       "a month ago",
+		},
+		{
+			AuthorName:              "Ben Okafor",
+			ProfilePicture:          "https://lh3.googleusercontent.com/a/ben",
+			Rating:                  3,
+			RelativeTimeDescription: "3 weeks ago",
+			Text:                    "Slow service on weekends.",
+		},
+	})
+
+	rpc := entry.UserReviewsExtended
+
+	require.Len(t, rpc, 2)
+	require.Len(t, dom, 2)
+
+	for i := range rpc {
+		require.Equal(t, rpc[i].ID, dom[i].ID, rpc[i].Name)
+		require.Equal(t, rpc[i].Name, dom[i].Name)
+		require.Equal(t, rpc[i].Rating, dom[i].Rating)
+		require.Equal(t, rpc[i].Description, dom[i].Description)
+
+		require.False(t, rpc[i].Time.IsZero(), rpc[i].Name)
+		require.Equal(t, rpc[i].Time, dom[i].Time, rpc[i].Name)
+		require.Equal(t, rpc[i].TimeEstimated, dom[i].TimeEstimated)
+		require.Equal(t, rpc[i].When, dom[i].When)
+		require.Equal(t, rpc[i].RelativeTime, dom[i].RelativeTime)
+
+		require.Equal(t, rpc[i].OwnerResponse, dom[i].OwnerResponse)
+		require.Equal(t, rpc[i].OwnerResponseTime, dom[i].OwnerResponseTime)
+	}
+
+	require.Equal(t, "ChZDSUhNMG9nS0VJQ0FnSUR2c3JLQ0ZREAE", rpc[0].ID)
+	require.Equal(t, "Thank you, Ana!", rpc[0].OwnerResponse)
+	require.False(t, rpc[0].OwnerResponseTime.IsZero())
+
+	// reviews without an id get the same derived one from both
+	require.Regexp(t, `^h_[0-9a-f]{20}$`, rpc[1].ID)
+	require.Empty(t, rpc[1].OwnerResponse)
+}
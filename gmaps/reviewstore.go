@@ -0,0 +1,79 @@
+package gmaps
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// maxCheckpointIDs is how many review ids of a place a checkpoint keeps.
+const maxCheckpointIDs = 50
+
+// ReviewStore keeps track of the reviews of each place between crawls, so
+// a crawl only fetches the reviews that were posted since the last one.
+type ReviewStore interface {
+	// Checkpoint returns the newest reviews stored for the place with the
+	// data id, or a zero checkpoint for places that were never crawled.
+	Checkpoint(ctx context.Context, dataID string) (ReviewCheckpoint, error)
+	// Save records the reviews that were crawled for the place.
+	Save(ctx context.Context, dataID string, reviews []Review) error
+}
+
+// ReviewCheckpoint describes the newest reviews of a place that an earlier
+// crawl stored.
+type ReviewCheckpoint struct {
+	// IDs are the ids of the newest stored reviews, newest first.
+	IDs []string `json:"ids"`
+	// Latest is when the newest stored review was posted.
+	Latest time.Time `json:"latest,omitzero"`
+}
+
+func (c *ReviewCheckpoint) IsZero() bool {
+	return len(c.IDs) == 0 && c.Latest.IsZero()
+}
+
+// Known reports whether the review was stored by an earlier crawl, because
+// its id is known or because it is not newer than the newest stored
+// review. Estimated times are too coarse to compare.
+func (c *ReviewCheckpoint) Known(r *Review) bool {
+	if slices.Contains(c.IDs, r.ID) {
+		return true
+	}
+
+	return !c.Latest.IsZero() && !r.Time.IsZero() && !r.TimeEstimated && !r.Time.After(c.Latest)
+}
+
+// Merge returns the checkpoint after the reviews were stored as well.
+func (c ReviewCheckpoint) Merge(reviews []Review) ReviewCheckpoint {
+	sorted := slices.Clone(reviews)
+
+	slices.SortStableFunc(sorted, func(a, b Review) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	ans := ReviewCheckpoint{Latest: c.Latest}
+
+	for i := range sorted {
+		r := &sorted[i]
+
+		if !r.TimeEstimated && r.Time.After(ans.Latest) {
+			ans.Latest = r.Time
+		}
+
+		if r.ID != "" && !slices.Contains(ans.IDs, r.ID) {
+			ans.IDs = append(ans.IDs, r.ID)
+		}
+	}
+
+	for _, id := range c.IDs {
+		if !slices.Contains(ans.IDs, id) {
+			ans.IDs = append(ans.IDs, id)
+		}
+	}
+
+	if len(ans.IDs) > maxCheckpointIDs {
+		ans.IDs = ans.IDs[:maxCheckpointIDs]
+	}
+
+	return ans
+}
@@ -0,0 +1,61 @@
+package gmaps_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func Test_ReviewCheckpointKnown(t *testing.T) {
+	latest := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	c := gmaps.ReviewCheckpoint{IDs: []string{"r1"}, Latest: latest}
+
+	require.True(t, c.Known(&gmaps.Review{ID: "r1", Time: latest.AddDate(0, 1, 0)}))
+	require.True(t, c.Known(&gmaps.Review{ID: "r0", Time: latest}))
+	require.False(t, c.Known(&gmaps.Review{ID: "r2", Time: latest.Add(time.Second)}))
+
+	// estimated and missing times are not compared
+	require.False(t, c.Known(&gmaps.Review{ID: "r0", Time: latest.AddDate(0, -1, 0), TimeEstimated: true}))
+	require.False(t, c.Known(&gmaps.Review{ID: "r0"}))
+
+	var zero gmaps.ReviewCheckpoint
+
+	require.True(t, zero.IsZero())
+	require.False(t, zero.Known(&gmaps.Review{ID: "r1", Time: latest}))
+}
+
+func Test_ReviewCheckpointMerge(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	c := gmaps.ReviewCheckpoint{IDs: []string{"r2", "r1"}, Latest: day(2)}
+
+	c = c.Merge([]gmaps.Review{
+		{ID: "r3", Time: day(3)},
+		{ID: "r5", Time: day(5)},
+		// an estimate can be off by a month, it does not move Latest
+		{ID: "r9", Time: day(9), TimeEstimated: true},
+		{ID: "r2", Time: day(2)},
+		{Time: day(4)},
+	})
+
+	require.Equal(t, day(5), c.Latest)
+	require.Equal(t, []string{"r9", "r5", "r3", "r2", "r1"}, c.IDs)
+
+	// only the newest ids are kept
+	var many []gmaps.Review
+	for i := range 60 {
+		many = append(many, gmaps.Review{ID: fmt.Sprintf("n%d", i), Time: day(10).Add(time.Duration(i) * time.Minute)})
+	}
+
+	c = c.Merge(many)
+	require.Len(t, c.IDs, 50)
+	require.Equal(t, "n59", c.IDs[0])
+	require.Equal(t, day(10).Add(59*time.Minute), c.Latest)
+}
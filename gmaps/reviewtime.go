@@ -0,0 +1,164 @@
+package gmaps
+
+import (
+	"cmp"
+	"crypto/sha1" //nolint:gosec // used for ids, not for security
+	"encoding/hex"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reviewDateLayout is the layout of Review.When.
+const reviewDateLayout = "2006-1-2"
+
+type relativeUnit struct {
+	years, months, days int
+	duration            time.Duration
+}
+
+var (
+	unitMinute = relativeUnit{duration: time.Minute}
+	unitHour   = relativeUnit{duration: time.Hour}
+	unitDay    = relativeUnit{days: 1}
+	unitWeek   = relativeUnit{days: 7}
+	unitMonth  = relativeUnit{months: 1}
+	unitYear   = relativeUnit{years: 1}
+)
+
+// relativeUnits maps the words Google uses in relative review dates to
+// their unit, for the languages the scraper supports (en, tr, de, fr, es).
+var relativeUnits = map[string]relativeUnit{
+	"minute": unitMinute, "minuten": unitMinute, "minutos": unitMinute, "minuto": unitMinute, "dakika": unitMinute,
+	"hour": unitHour, "stunden": unitHour, "stunde": unitHour, "heure": unitHour, "horas": unitHour, "hora": unitHour, "saat": unitHour,
+	"day": unitDay, "tagen": unitDay, "tag": unitDay, "jours": unitDay, "jour": unitDay, "días": unitDay, "día": unitDay, "gün": unitDay,
+	"week": unitWeek, "wochen": unitWeek, "woche": unitWeek, "semaines": unitWeek, "semaine": unitWeek, "semanas": unitWeek, "semana": unitWeek, "hafta": unitWeek,
+	"month": unitMonth, "monaten": unitMonth, "monat": unitMonth, "mois": unitMonth, "meses": unitMonth, "mes": unitMonth, "ay": unitMonth,
+	"year": unitYear, "jahren": unitYear, "jahr": unitYear, "ans": unitYear, "an": unitYear, "años": unitYear, "año": unitYear, "yıl": unitYear,
+}
+
+// relativeOne are the words used instead of the number 1, as in
+// "a month ago" or "vor einem Monat".
+var relativeOne = map[string]bool{
+	"a": true, "an": true, "one": true,
+	"ein": true, "einem": true, "einer": true,
+	"un": true, "une": true, "una": true,
+	"bir": true,
+}
+
+var (
+	relativeUnitWords = sortedByLength(relativeUnits)
+	relativeNumberRe  = regexp.MustCompile(`\d+`)
+	relativeWordRe    = regexp.MustCompile(`[\p{L}]+`)
+)
+
+// EstimateReviewTime estimates when a review was posted from the relative
+// date Google shows, like "3 months ago", "vor 2 Wochen" or "hace un año".
+// It reports false when the text is not understood.
+func EstimateReviewTime(relative string, now time.Time) (time.Time, bool) {
+	text := strings.ToLower(strings.TrimSpace(relative))
+	if text == "" {
+		return time.Time{}, false
+	}
+
+	switch {
+	case strings.Contains(text, "yesterday"), strings.Contains(text, "gestern"),
+		strings.Contains(text, "hier"), strings.Contains(text, "ayer"), strings.Contains(text, "dün"):
+		return truncateDay(now.AddDate(0, 0, -1)), true
+	case strings.Contains(text, "just now"), strings.Contains(text, "today"), strings.Contains(text, "heute"),
+		strings.Contains(text, "aujourd'hui"), strings.Contains(text, "hoy"), strings.Contains(text, "bugün"):
+		return truncateDay(now), true
+	}
+
+	words := relativeWordRe.FindAllString(text, -1)
+
+	unit, ok := findRelativeUnit(words)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	n := 0
+
+	if m := relativeNumberRe.FindString(text); m != "" {
+		n, _ = strconv.Atoi(m)
+	} else {
+		for _, w := range words {
+			if relativeOne[w] {
+				n = 1
+
+				break
+			}
+		}
+	}
+
+	if n == 0 {
+		return time.Time{}, false
+	}
+
+	if unit.duration > 0 {
+		return now.Add(-time.Duration(n) * unit.duration), true
+	}
+
+	return truncateDay(now.AddDate(-n*unit.years, -n*unit.months, -n*unit.days)), true
+}
+
+func findRelativeUnit(words []string) (relativeUnit, bool) {
+	for _, uw := range relativeUnitWords {
+		for _, w := range words {
+			// plural forms like "months" or "años" start with the unit
+			if w == uw || (strings.HasPrefix(w, uw) && len(w)-len(uw) <= 2) {
+				return relativeUnits[uw], true
+			}
+		}
+	}
+
+	return relativeUnit{}, false
+}
+
+// sortedByLength returns the keys longest first, so "monaten" is tried
+// before "monat" and "an" ("un an") does not shadow "an hour".
+func sortedByLength(m map[string]relativeUnit) []string {
+	return slices.SortedFunc(maps.Keys(m), func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), cmp.Compare(a, b))
+	})
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// reviewID returns the id Google assigned to the review or, when it is
+// missing, an id derived from the author and the text so the same review
+// gets the same id on every crawl.
+func reviewID(id, author, text string) string {
+	if id != "" {
+		return id
+	}
+
+	h := sha1.Sum([]byte(author + "\x00" + text)) //nolint:gosec // see import
+
+	return "h_" + hex.EncodeToString(h[:10])
+}
+
+// setReviewTime fills in the time fields of the review. An absolute time
+// wins over the estimate from the relative date.
+func (r *Review) setReviewTime(absolute time.Time, relative string, now time.Time) {
+	r.RelativeTime = relative
+
+	switch {
+	case !absolute.IsZero():
+		r.Time = absolute.UTC()
+	case relative != "":
+		if t, ok := EstimateReviewTime(relative, now); ok {
+			r.Time = t.UTC()
+			r.TimeEstimated = true
+		}
+	}
+
+	if !r.Time.IsZero() {
+		r.When = r.Time.Format(reviewDateLayout)
+	}
+}
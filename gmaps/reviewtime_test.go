@@ -0,0 +1,39 @@
+package gmaps_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func Test_EstimateReviewTime(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		relative string
+		expected time.Time
+	}{
+		{"3 months ago", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"a year ago", time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{"Edited 2 weeks ago", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"an hour ago", now.Add(-time.Hour)},
+		{"yesterday", time.Date(2025, 6, 14, 0, 0, 0, 0, time.UTC)},
+		{"vor 5 Tagen", time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)},
+		{"vor einem Monat", time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{"il y a un an", time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{"hace 2 meses", time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC)},
+		{"3 ay önce", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		got, ok := gmaps.EstimateReviewTime(tc.relative, now)
+		require.True(t, ok, tc.relative)
+		require.Equal(t, tc.expected, got, tc.relative)
+	}
+
+	_, ok := gmaps.EstimateReviewTime("not a date", now)
+	require.False(t, ok)
+}
@@ -4,19 +4,22 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"math/rand"
 	"net/http"
-	"time"
+	"slices"
 
 	"github.com/google/uuid"
+	"github.com/gosom/google-maps-scraper/budget"
 	"github.com/gosom/google-maps-scraper/deduper"
 	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/ratelimit"
 	"github.com/gosom/scrapemate"
 )
 
 const (
-	resultsPerPage   = 20
+	resultsPerPage     = 20
 	maxPaginationPages = 6 // max 6 pages = 120 results per query
+	// maxBlockRetries is how many times a blocked search is tried again.
+	maxBlockRetries = 3
 )
 
 type SearchJobOptions func(*SearchJob)
@@ -43,9 +46,17 @@ type SearchJob struct {
 	ExitMonitor exiter.Exiter
 	Deduper     deduper.Deduper
 	SearchDelay int
-	offset      int // pagination offset (0, 20, 40, ...)
-	pageNum     int // current page number (0-based)
-	maxPages    int // max pages to paginate (from depth setting)
+	offset      int       // pagination offset (0, 20, 40, ...)
+	pageNum     int       // current page number (0-based)
+	maxPages    int       // max pages to paginate (from depth setting)
+	cell        *GridCell // grid sweep cell, nil for plain searches
+	subCell     bool      // created by splitting a full cell
+	blocks      int       // times the search was blocked
+	backoff     *Backoff
+	rateLimiter *ratelimit.Limiter
+	share       *budget.Share
+	held        bool // holds a slot of the share for its request
+	attempts    int  // attempts of the request since it took the slot
 }
 
 func NewSearchJob(params *MapSearchParams, opts ...SearchJobOptions) *SearchJob {
@@ -90,6 +101,30 @@ func WithSearchJobDelay(d int) SearchJobOptions {
 	}
 }
 
+// WithSearchJobRateLimiter makes the job wait for its turn to send a
+// request to Google.
+func WithSearchJobRateLimiter(l *ratelimit.Limiter) SearchJobOptions {
+	return func(j *SearchJob) {
+		j.rateLimiter = l
+	}
+}
+
+// WithSearchJobBackoff makes the job wait for the cool-down of the run
+// after a block, before it is tried again.
+func WithSearchJobBackoff(b *Backoff) SearchJobOptions {
+	return func(j *SearchJob) {
+		j.backoff = b
+	}
+}
+
+// WithSearchJobBudget makes the job take a slot of the budget of the
+// process while it sends its request.
+func WithSearchJobBudget(s *budget.Share) SearchJobOptions {
+	return func(j *SearchJob) {
+		j.share = s
+	}
+}
+
 func WithSearchJobDeduper(d deduper.Deduper) SearchJobOptions {
 	return func(j *SearchJob) {
 		j.Deduper = d
@@ -104,26 +139,79 @@ func WithSearchJobMaxPages(n int) SearchJobOptions {
 	}
 }
 
-func (j *SearchJob) Process(ctx context.Context, resp *scrapemate.Response) (any, []scrapemate.IJob, error) {
-	if j.SearchDelay > 0 {
-		// add some randomness +- 30%
-		randFactor := 0.7 + (0.6 * rand.Float64())
-		sleepTime := time.Duration(float64(j.SearchDelay)*randFactor) * time.Second
-		time.Sleep(sleepTime)
+// WithSearchJobGridCell makes the job part of a grid sweep. When the cell
+// comes back full it is split into smaller cells instead of being truncated.
+func WithSearchJobGridCell(cell *GridCell) SearchJobOptions {
+	return func(j *SearchJob) {
+		j.cell = cell
+	}
+}
+
+// BeforeFetch waits for the end of the cool-down of the run, so a block
+// holds back the requests of the other jobs too, then for the turn of the
+// search and for a slot of the budget.
+func (j *SearchJob) BeforeFetch(ctx context.Context) error {
+	if err := j.backoff.Wait(ctx); err != nil {
+		return err
 	}
+
+	if err := waitSearch(ctx, j.rateLimiter, j.GetFullURL(), j.SearchDelay); err != nil {
+		return err
+	}
+
+	if err := j.share.Acquire(ctx); err != nil {
+		return err
+	}
+
+	j.held, j.attempts = true, 0
+
+	return nil
+}
+
+// DoCheckResponse accepts the block pages too, for Process to detect them.
+// It is called after each attempt of the request, the slot taken in
+// BeforeFetch is given back after the last one.
+func (j *SearchJob) DoCheckResponse(resp *scrapemate.Response) bool {
+	ok := j.Job.DoCheckResponse(resp) || DetectBlock(resp.StatusCode, resp.URL, resp.Body) != nil
+
+	j.attempts++
+
+	if j.held && (ok || j.attempts > j.MaxRetries) {
+		j.held = false
+		j.share.Release()
+	}
+
+	return ok
+}
+
+func (j *SearchJob) Process(ctx context.Context, resp *scrapemate.Response) (any, []scrapemate.IJob, error) {
 	defer func() {
 		resp.Document = nil
 		resp.Body = nil
 		resp.Meta = nil
 	}()
 
+	if blocked := DetectBlock(resp.StatusCode, resp.URL, resp.Body); blocked != nil {
+		return j.blocked(blocked)
+	}
+
+	j.backoff.Succeeded()
+
 	body := removeFirstLine(resp.Body)
 	if len(body) == 0 {
+		if j.ExitMonitor != nil {
+			j.ExitMonitor.IncrErrors(1)
+		}
+
 		return nil, nil, fmt.Errorf("empty response body")
 	}
 
 	entries, err := ParseSearchResults(body)
 	if err != nil {
+		if j.ExitMonitor != nil {
+			j.ExitMonitor.IncrErrors(1)
+		}
+
 		return nil, nil, fmt.Errorf("failed to parse search results: %w", err)
 	}
 
@@ -135,16 +223,17 @@ func (j *SearchJob) Process(ctx context.Context, resp *scrapemate.Response) (any
 		j.params.Location.Radius,
 	)
 
+	if j.cell != nil && j.cell.Area != nil {
+		entries = slices.DeleteFunc(entries, func(e *Entry) bool {
+			return !j.cell.Area.Contains(e.Latitude, e.Longtitude)
+		})
+	}
+
 	// Deduplicate entries by CID to avoid same place appearing in multiple district searches
 	if j.Deduper != nil {
 		unique := make([]*Entry, 0, len(entries))
 		for _, e := range entries {
-			// Use CID as unique key, fallback to title+address
-			key := e.Cid
-			if key == "" {
-				key = e.Title + "|" + e.Address
-			}
-			if key != "" && j.Deduper.AddIfNotExists(ctx, key) {
+			if key := PlaceKey(e); key != "" && j.Deduper.AddIfNotExists(ctx, key) {
 				unique = append(unique, e)
 			}
 		}
@@ -169,24 +258,33 @@ func (j *SearchJob) Process(ctx context.Context, resp *scrapemate.Response) (any
 			ExitMonitor: j.ExitMonitor,
 			Deduper:     j.Deduper,
 			SearchDelay: j.SearchDelay,
+			backoff:     j.backoff,
+			rateLimiter: j.rateLimiter,
+			share:       j.share,
 			offset:      nextOffset,
 			pageNum:     nextPage,
 			maxPages:    j.maxPages,
+			cell:        j.cell,
+			subCell:     j.subCell,
 		}
 		nextJobs = append(nextJobs, nextJob)
+	} else if rawCount >= resultsPerPage && j.cell != nil && j.cell.CanSplit() {
+		// The last page is still full so the cell holds more places than
+		// Google returns for one search. Search its quadrants instead.
+		nextJobs = append(nextJobs, j.splitCell()...)
+	}
 
-		// Track the pagination job in exit monitor
-		if j.ExitMonitor != nil {
-			j.ExitMonitor.IncrPlacesFound(1) // track the next-page job as a "place" to wait for
-		}
+	// Track the follow up jobs in exit monitor
+	if j.ExitMonitor != nil && len(nextJobs) > 0 {
+		j.ExitMonitor.IncrPlacesFound(len(nextJobs)) // track the follow up jobs as "places" to wait for
 	}
 
 	if j.ExitMonitor != nil {
-		if j.pageNum == 0 {
+		if j.pageNum == 0 && !j.subCell {
 			// Only the first page counts as seed completion
 			j.ExitMonitor.IncrSeedCompleted(1)
 		} else {
-			// Pagination pages complete their "place" tracking
+			// Pagination pages and sub cells complete their "place" tracking
 			j.ExitMonitor.IncrPlacesCompleted(1)
 		}
 		j.ExitMonitor.IncrPlacesFound(len(entries))
@@ -196,6 +294,69 @@ func (j *SearchJob) Process(ctx context.Context, resp *scrapemate.Response) (any
 	return entries, nextJobs, nil
 }
 
+// blocked starts the cool-down of the run and returns the search to be
+// tried again, up to maxBlockRetries times. The retry waits for the end of
+// the cool-down in BeforeFetch, before a worker takes it.
+func (j *SearchJob) blocked(blocked *BlockedError) (any, []scrapemate.IJob, error) {
+	j.backoff.Blocked(blocked)
+
+	if j.ExitMonitor != nil {
+		j.ExitMonitor.IncrBlocks(1)
+	}
+
+	if j.blocks >= maxBlockRetries {
+		if j.ExitMonitor != nil {
+			j.ExitMonitor.IncrErrors(1)
+		}
+
+		return nil, nil, blocked
+	}
+
+	retry := *j
+	retry.Job.ID = uuid.New().String()
+	retry.blocks++
+
+	return nil, []scrapemate.IJob{&retry}, nil
+}
+
+func (j *SearchJob) splitCell() []scrapemate.IJob {
+	cells := j.cell.Split()
+	ans := make([]scrapemate.IJob, 0, len(cells))
+
+	for i := range cells {
+		params := *j.params
+		params.Location = cells[i].Location()
+
+		job := NewSearchJob(&params,
+			WithSearchJobExitMonitor(j.ExitMonitor),
+			WithSearchJobDeduper(j.Deduper),
+			WithSearchJobDelay(j.SearchDelay),
+			WithSearchJobBackoff(j.backoff),
+			WithSearchJobRateLimiter(j.rateLimiter),
+			WithSearchJobBudget(j.share),
+			WithSearchJobMaxPages(j.maxPages),
+			WithSearchJobGridCell(&cells[i]),
+		)
+
+		job.subCell = true
+		job.Priority = j.Job.Priority + 1
+
+		ans = append(ans, job)
+	}
+
+	return ans
+}
+
+// PlaceKey is the key fast mode deduplicates places by: the CID, or the
+// title and address when the CID is missing.
+func PlaceKey(e *Entry) string {
+	if e.Cid != "" {
+		return e.Cid
+	}
+
+	return e.Title + "|" + e.Address
+}
+
 func removeFirstLine(data []byte) []byte {
 	if len(data) == 0 {
 		return data
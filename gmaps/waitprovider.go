@@ -0,0 +1,64 @@
+package gmaps
+
+import (
+	"context"
+
+	"github.com/gosom/scrapemate"
+)
+
+// beforeFetcher is implemented by the jobs that wait for their turn before
+// their request is sent.
+type beforeFetcher interface {
+	BeforeFetch(ctx context.Context) error
+}
+
+type waitProvider struct {
+	scrapemate.JobProvider
+}
+
+// NewWaitProvider wraps the job provider of fast mode so the jobs wait for
+// the cool-down of the run and for their turn before a worker takes them,
+// not after their request in Process. The stealth fetcher has no hook
+// before the request, and scrapemateapp takes the provider as an option.
+func NewWaitProvider(next scrapemate.JobProvider) scrapemate.JobProvider {
+	return &waitProvider{JobProvider: next}
+}
+
+// Jobs is called by each worker, so a job waits while its worker finishes
+// the previous one.
+func (p *waitProvider) Jobs(ctx context.Context) (<-chan scrapemate.IJob, <-chan error) {
+	jobc, errc := p.JobProvider.Jobs(ctx)
+	out := make(chan scrapemate.IJob)
+
+	go func() {
+		for {
+			var (
+				job scrapemate.IJob
+				ok  bool
+			)
+
+			select {
+			case <-ctx.Done():
+				return
+			case job, ok = <-jobc:
+				if !ok {
+					return
+				}
+			}
+
+			if j, ok := job.(beforeFetcher); ok {
+				if err := j.BeforeFetch(ctx); err != nil {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- job:
+			}
+		}
+	}()
+
+	return out, errc
+}
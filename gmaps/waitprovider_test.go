@@ -0,0 +1,72 @@
+package gmaps_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gosom/scrapemate"
+	memprovider "github.com/gosom/scrapemate/adapters/providers/memory"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/budget"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/ratelimit"
+)
+
+func Test_WaitProviderRateLimit(t *testing.T) {
+	const every = 100 * time.Millisecond
+
+	limiter := ratelimit.New(ratelimit.WithDomainRate(ratelimit.GoogleDomain, ratelimit.Every(every)))
+	provider := gmaps.NewWaitProvider(memprovider.New())
+
+	// the searches take their turn before a worker takes them
+	for _, query := range []string{"cafe", "bar", "bakery"} {
+		job := gmaps.NewSearchJob(&gmaps.MapSearchParams{Query: query}, gmaps.WithSearchJobRateLimiter(limiter))
+
+		require.NoError(t, provider.Push(t.Context(), job))
+	}
+
+	jobs, _ := provider.Jobs(t.Context())
+
+	taken := make([]time.Time, 0, 3)
+
+	for range 3 {
+		<-jobs
+
+		taken = append(taken, time.Now())
+	}
+
+	require.GreaterOrEqual(t, taken[1].Sub(taken[0]), every)
+	require.GreaterOrEqual(t, taken[2].Sub(taken[1]), every)
+}
+
+func Test_WaitProviderBudget(t *testing.T) {
+	share := budget.New(1).Share(0)
+	provider := gmaps.NewWaitProvider(memprovider.New())
+
+	for _, query := range []string{"cafe", "bar"} {
+		job := gmaps.NewSearchJob(&gmaps.MapSearchParams{Query: query}, gmaps.WithSearchJobBudget(share))
+
+		require.NoError(t, provider.Push(t.Context(), job))
+	}
+
+	jobs, _ := provider.Jobs(t.Context())
+	first := <-jobs
+
+	// the other search waits for the slot of the first one
+	select {
+	case <-jobs:
+		require.Fail(t, "the budget has one slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// which is given back once its request was checked
+	require.True(t, first.DoCheckResponse(&scrapemate.Response{StatusCode: http.StatusOK}))
+
+	select {
+	case <-jobs:
+	case <-time.After(time.Second):
+		require.Fail(t, "the slot was not given back")
+	}
+}
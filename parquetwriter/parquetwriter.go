@@ -0,0 +1,94 @@
+// Package parquetwriter writes places as Parquet files for analytics tools
+// like DuckDB, Spark and pandas. The nested fields of the places, as the
+// images, the about options and the reviews, are lists of structs rather
+// than JSON text.
+package parquetwriter
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gosom/scrapemate"
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// RowGroupSize is how many places are kept in memory before they are
+// written out as a row group.
+const RowGroupSize = 5000
+
+// Encoder writes places as one Parquet file.
+type Encoder struct {
+	w *parquet.GenericWriter[Place]
+	// rows is how many places are in the current row group.
+	rows int
+}
+
+// NewEncoder returns an encoder that writes the places to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: parquet.NewGenericWriter[Place](w,
+			parquet.Compression(&parquet.Snappy),
+			parquet.CreatedBy("google-maps-scraper", "", ""),
+		),
+	}
+}
+
+// Encode writes a place. A row group is written every RowGroupSize places.
+func (e *Encoder) Encode(entry *gmaps.Entry) error {
+	if _, err := e.w.Write([]Place{NewPlace(entry)}); err != nil {
+		return err
+	}
+
+	e.rows++
+
+	if e.rows < RowGroupSize {
+		return nil
+	}
+
+	e.rows = 0
+
+	return e.w.Flush()
+}
+
+// Close writes the last row group and the footer of the file. It does not
+// close the underlying writer.
+func (e *Encoder) Close() error {
+	return e.w.Close()
+}
+
+// writer is a scrapemate.ResultWriter that encodes the places it receives.
+type writer struct {
+	enc *Encoder
+}
+
+// New returns a scrapemate.ResultWriter that writes the places to w as one
+// Parquet file.
+func New(w io.Writer) scrapemate.ResultWriter {
+	return &writer{enc: NewEncoder(w)}
+}
+
+func (w *writer) Run(_ context.Context, in <-chan scrapemate.Result) error {
+	for result := range in {
+		var entries []*gmaps.Entry
+
+		switch v := result.Data.(type) {
+		case *gmaps.Entry:
+			entries = []*gmaps.Entry{v}
+		case []*gmaps.Entry:
+			entries = v
+		default:
+			return fmt.Errorf("invalid data type for parquet writer: %T", result.Data)
+		}
+
+		for _, entry := range entries {
+			if err := w.enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.enc.Close()
+}
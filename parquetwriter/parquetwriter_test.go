@@ -0,0 +1,80 @@
+package parquetwriter_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/parquetwriter"
+)
+
+func Test_Encoder(t *testing.T) {
+	reviewed := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	entries := []*gmaps.Entry{
+		{
+			Title:            "Cafe",
+			DataID:           "0x1:0x2",
+			Categories:       []string{"Coffee shop", "Bakery"},
+			Latitude:         52.52,
+			Longtitude:       13.405,
+			OpenHours:        map[string][]string{"Tuesday": {"9-17"}, "Monday": {"9-12", "13-17"}},
+			PopularTimes:     map[string]map[int]int{"Monday": {9: 40, 8: 10}},
+			ReviewsPerRating: map[int]int{5: 10, 1: 2},
+			Images:           []gmaps.Image{{Title: "All", Image: "https://example.com/a.jpg"}},
+			About: []gmaps.About{{
+				ID:      "accessibility",
+				Name:    "Accessibility",
+				Options: []gmaps.Option{{Name: "Wheelchair accessible entrance", Enabled: true}},
+			}},
+			UserReviews: []gmaps.Review{
+				{Name: "Ann", Rating: 5, Description: "Great", Time: reviewed},
+				{Name: "Bob", Rating: 3},
+			},
+		},
+		{Title: "Empty"},
+	}
+
+	var buf bytes.Buffer
+
+	enc := parquetwriter.NewEncoder(&buf)
+
+	for _, e := range entries {
+		require.NoError(t, enc.Encode(e))
+	}
+
+	require.NoError(t, enc.Close())
+
+	places, err := parquet.Read[parquetwriter.Place](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, places, 2)
+
+	p := places[0]
+
+	require.Equal(t, "Cafe", p.Title)
+	require.Equal(t, []string{"Coffee shop", "Bakery"}, p.Categories)
+	require.Equal(t, 13.405, p.Longitude)
+	require.Equal(t, []parquetwriter.OpenHours{
+		{Day: "Monday", Hours: []string{"9-12", "13-17"}},
+		{Day: "Tuesday", Hours: []string{"9-17"}},
+	}, p.OpenHours)
+	require.Equal(t, []parquetwriter.PopularTime{
+		{Day: "Monday", Hour: 8, Traffic: 10},
+		{Day: "Monday", Hour: 9, Traffic: 40},
+	}, p.PopularTimes)
+	require.Equal(t, []parquetwriter.RatingCount{{Rating: 1, Count: 2}, {Rating: 5, Count: 10}}, p.ReviewsPerRating)
+	require.Equal(t, "https://example.com/a.jpg", p.Images[0].Image)
+	require.True(t, p.About[0].Options[0].Enabled)
+
+	require.Len(t, p.UserReviews, 2)
+	require.Equal(t, "Ann", p.UserReviews[0].Author)
+	require.Equal(t, reviewed, p.UserReviews[0].Time.UTC())
+	require.Nil(t, p.UserReviews[1].Time)
+
+	require.Equal(t, "Empty", places[1].Title)
+	require.Empty(t, places[1].UserReviews)
+}
@@ -0,0 +1,247 @@
+package parquetwriter
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// Place is a row of the Parquet file, a gmaps.Entry with the maps turned
+// into lists, so the nested fields can be queried without parsing JSON.
+// The column names are the ones of the CSV.
+type Place struct {
+	InputID             string          `parquet:"input_id"`
+	Link                string          `parquet:"link"`
+	Title               string          `parquet:"title"`
+	Categories          []string        `parquet:"categories,list"`
+	Category            string          `parquet:"category"`
+	Address             string          `parquet:"address"`
+	OpenHours           []OpenHours     `parquet:"open_hours,list"`
+	PopularTimes        []PopularTime   `parquet:"popular_times,list"`
+	Website             string          `parquet:"website"`
+	Phone               string          `parquet:"phone"`
+	PlusCode            string          `parquet:"plus_code"`
+	ReviewCount         int64           `parquet:"review_count"`
+	ReviewRating        float64         `parquet:"review_rating"`
+	ReviewsPerRating    []RatingCount   `parquet:"reviews_per_rating,list"`
+	Latitude            float64         `parquet:"latitude"`
+	Longitude           float64         `parquet:"longitude"`
+	Cid                 string          `parquet:"cid"`
+	Status              string          `parquet:"status"`
+	Description         string          `parquet:"descriptions"`
+	ReviewsLink         string          `parquet:"reviews_link"`
+	Thumbnail           string          `parquet:"thumbnail"`
+	Timezone            string          `parquet:"timezone"`
+	PriceRange          string          `parquet:"price_range"`
+	DataID              string          `parquet:"data_id"`
+	PlaceID             string          `parquet:"place_id"`
+	Images              []Image         `parquet:"images,list"`
+	Reservations        []LinkSource    `parquet:"reservations,list"`
+	OrderOnline         []LinkSource    `parquet:"order_online,list"`
+	Menu                LinkSource      `parquet:"menu"`
+	Owner               Owner           `parquet:"owner"`
+	CompleteAddress     CompleteAddress `parquet:"complete_address"`
+	About               []About         `parquet:"about,list"`
+	UserReviews         []Review        `parquet:"user_reviews,list"`
+	UserReviewsExtended []Review        `parquet:"user_reviews_extended,list"`
+	Emails              []string        `parquet:"emails,list"`
+}
+
+type OpenHours struct {
+	Day   string   `parquet:"day"`
+	Hours []string `parquet:"hours,list"`
+}
+
+// PopularTime is how busy the place is in an hour of a day, from 0 to 100.
+type PopularTime struct {
+	Day     string `parquet:"day"`
+	Hour    int32  `parquet:"hour"`
+	Traffic int32  `parquet:"traffic"`
+}
+
+type RatingCount struct {
+	Rating int32 `parquet:"rating"`
+	Count  int64 `parquet:"count"`
+}
+
+type Image struct {
+	Title string `parquet:"title"`
+	Image string `parquet:"image"`
+}
+
+type LinkSource struct {
+	Link   string `parquet:"link"`
+	Source string `parquet:"source"`
+}
+
+type Owner struct {
+	ID   string `parquet:"id"`
+	Name string `parquet:"name"`
+	Link string `parquet:"link"`
+}
+
+type CompleteAddress struct {
+	Borough    string `parquet:"borough"`
+	Street     string `parquet:"street"`
+	City       string `parquet:"city"`
+	PostalCode string `parquet:"postal_code"`
+	State      string `parquet:"state"`
+	Country    string `parquet:"country"`
+}
+
+type About struct {
+	ID      string   `parquet:"id"`
+	Name    string   `parquet:"name"`
+	Options []Option `parquet:"options,list"`
+}
+
+type Option struct {
+	Name    string `parquet:"name"`
+	Enabled bool   `parquet:"enabled"`
+}
+
+// Review has the columns of the reviews export. The times are missing when
+// Google did not show them.
+type Review struct {
+	ReviewID          string     `parquet:"review_id"`
+	Author            string     `parquet:"author"`
+	AuthorPicture     string     `parquet:"author_picture"`
+	Rating            int32      `parquet:"rating"`
+	Text              string     `parquet:"text"`
+	When              string     `parquet:"when"`
+	Time              *time.Time `parquet:"time,optional"`
+	TimeEstimated     bool       `parquet:"time_estimated"`
+	RelativeTime      string     `parquet:"relative_time"`
+	OwnerResponse     string     `parquet:"owner_response"`
+	OwnerResponseTime *time.Time `parquet:"owner_response_time,optional"`
+	Images            []string   `parquet:"images,list"`
+}
+
+// NewPlace converts the entry to a row. The maps are sorted by key, so the
+// same entry always gives the same row.
+func NewPlace(e *gmaps.Entry) Place {
+	ans := Place{
+		InputID:         e.ID,
+		Link:            e.Link,
+		Title:           e.Title,
+		Categories:      e.Categories,
+		Category:        e.Category,
+		Address:         e.Address,
+		Website:         e.WebSite,
+		Phone:           e.Phone,
+		PlusCode:        e.PlusCode,
+		ReviewCount:     int64(e.ReviewCount),
+		ReviewRating:    e.ReviewRating,
+		Latitude:        e.Latitude,
+		Longitude:       e.Longtitude,
+		Cid:             e.Cid,
+		Status:          e.Status,
+		Description:     e.Description,
+		ReviewsLink:     e.ReviewsLink,
+		Thumbnail:       e.Thumbnail,
+		Timezone:        e.Timezone,
+		PriceRange:      e.PriceRange,
+		DataID:          e.DataID,
+		PlaceID:         e.PlaceID,
+		Menu:            LinkSource(e.Menu),
+		Owner:           Owner(e.Owner),
+		CompleteAddress: CompleteAddress(e.CompleteAddress),
+		Emails:          e.Emails,
+	}
+
+	for _, day := range sortedKeys(e.OpenHours) {
+		ans.OpenHours = append(ans.OpenHours, OpenHours{Day: day, Hours: e.OpenHours[day]})
+	}
+
+	for _, day := range sortedKeys(e.PopularTimes) {
+		for _, hour := range sortedKeys(e.PopularTimes[day]) {
+			ans.PopularTimes = append(ans.PopularTimes, PopularTime{
+				Day:     day,
+				Hour:    int32(hour),
+				Traffic: int32(e.PopularTimes[day][hour]),
+			})
+		}
+	}
+
+	for _, rating := range sortedKeys(e.ReviewsPerRating) {
+		ans.ReviewsPerRating = append(ans.ReviewsPerRating, RatingCount{
+			Rating: int32(rating),
+			Count:  int64(e.ReviewsPerRating[rating]),
+		})
+	}
+
+	for _, img := range e.Images {
+		ans.Images = append(ans.Images, Image(img))
+	}
+
+	for _, r := range e.Reservations {
+		ans.Reservations = append(ans.Reservations, LinkSource(r))
+	}
+
+	for _, o := range e.OrderOnline {
+		ans.OrderOnline = append(ans.OrderOnline, LinkSource(o))
+	}
+
+	for _, a := range e.About {
+		about := About{ID: a.ID, Name: a.Name}
+
+		for _, o := range a.Options {
+			about.Options = append(about.Options, Option(o))
+		}
+
+		ans.About = append(ans.About, about)
+	}
+
+	ans.UserReviews = newReviews(e.DataID, e.Cid, e.UserReviews)
+	ans.UserReviewsExtended = newReviews(e.DataID, e.Cid, e.UserReviewsExtended)
+
+	return ans
+}
+
+func newReviews(dataID, cid string, reviews []gmaps.Review) []Review {
+	rows := gmaps.NewReviewRows(dataID, cid, reviews)
+
+	var ans []Review
+
+	for i := range rows {
+		r := &rows[i]
+
+		ans = append(ans, Review{
+			ReviewID:          r.ReviewID,
+			Author:            r.Author,
+			AuthorPicture:     r.AuthorPicture,
+			Rating:            int32(r.Rating),
+			Text:              r.Text,
+			When:              r.When,
+			Time:              timePtr(r.Time),
+			TimeEstimated:     r.TimeEstimated,
+			RelativeTime:      r.RelativeTime,
+			OwnerResponse:     r.OwnerResponse,
+			OwnerResponseTime: timePtr(r.OwnerResponseTime),
+			Images:            r.Images,
+		})
+	}
+
+	return ans
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// sortedKeys returns the keys of the map, numbers in numeric order.
+func sortedKeys[K string | int, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
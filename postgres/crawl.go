@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// LoadCrawl returns the places written to the results table from from
+// until to, with the latest result of each place. A zero time leaves that
+// side of the range open. Results written before their time was tracked
+// count as older than any time.
+func LoadCrawl(ctx context.Context, db *sql.DB, from, to time.Time) ([]*gmaps.Entry, error) {
+	const q = `SELECT DISTINCT ON (place_key) data FROM (
+			SELECT id, data, COALESCE(NULLIF(data->>'cid', ''), data->>'data_id') AS place_key
+			FROM results
+			WHERE ($1::timestamptz IS NULL OR created_at >= $1)
+			AND ($2::timestamptz IS NULL OR created_at IS NULL OR created_at < $2)
+		) AS crawl
+		ORDER BY place_key, id DESC`
+
+	rows, err := db.QueryContext(ctx, q, nullTime(from), nullTime(to))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []*gmaps.Entry
+
+	for rows.Next() {
+		var data []byte
+
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var entry gmaps.Entry
+
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+
+		ans = append(ans, &entry)
+	}
+
+	return ans, rows.Err()
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/gosom/scrapemate"
 
 	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/ratelimit"
 )
 
 const (
@@ -29,6 +30,9 @@ type provider struct {
 	errc      chan error
 	started   bool
 	batchSize int
+
+	reviewStore gmaps.ReviewStore
+	limiter     *ratelimit.Limiter
 }
 
 func NewProvider(db *sql.DB, opts ...ProviderOption) scrapemate.JobProvider {
@@ -60,6 +64,22 @@ func WithBatchSize(size int) ProviderOption {
 	}
 }
 
+// WithReviewStore sets the review store of the jobs, to fetch only the
+// reviews posted since the last crawl.
+func WithReviewStore(store gmaps.ReviewStore) ProviderOption {
+	return func(p *provider) {
+		p.reviewStore = store
+	}
+}
+
+// WithRateLimiter sets the rate limiter of the jobs, shared by the jobs of
+// the process.
+func WithRateLimiter(l *ratelimit.Limiter) ProviderOption {
+	return func(p *provider) {
+		p.limiter = l
+	}
+}
+
 //nolint:gocritic // it contains about unnamed results
 func (p *provider) Jobs(ctx context.Context) (<-chan scrapemate.IJob, <-chan error) {
 	outc := make(chan scrapemate.IJob)
@@ -204,6 +224,8 @@ func (p *provider) fetchJobs(ctx context.Context) {
 				return
 			}
 
+			p.prepare(job)
+
 			jobs = append(jobs, job)
 		}
 
@@ -243,6 +265,27 @@ func (p *provider) fetchJobs(ctx context.Context) {
 	}
 }
 
+// prepare sets what the jobs need from this process, it is not part of the
+// stored payload.
+func (p *provider) prepare(job scrapemate.IJob) {
+	switch j := job.(type) {
+	case *gmaps.GmapJob:
+		if p.reviewStore != nil {
+			gmaps.WithReviewStore(p.reviewStore)(j)
+		}
+
+		gmaps.WithRateLimiter(p.limiter)(j)
+	case *gmaps.PlaceJob:
+		if p.reviewStore != nil {
+			gmaps.WithPlaceJobReviewStore(p.reviewStore)(j)
+		}
+
+		gmaps.WithPlaceJobRateLimiter(p.limiter)(j)
+	case *gmaps.EmailExtractJob:
+		gmaps.WithEmailJobRateLimiter(p.limiter)(j)
+	}
+}
+
 func decodeJob(payloadType string, payload []byte) (scrapemate.IJob, error) {
 	buf := bytes.NewBuffer(payload)
 	dec := gob.NewDecoder(buf)
@@ -14,12 +14,29 @@ import (
 	"github.com/gosom/google-maps-scraper/gmaps"
 )
 
-func NewResultWriter(db *sql.DB) scrapemate.ResultWriter {
-	return &resultWriter{db: db}
+type ResultWriterOption func(*resultWriter)
+
+// WithReviews also writes the reviews of the places to the reviews table,
+// one row per review.
+func WithReviews() ResultWriterOption {
+	return func(r *resultWriter) {
+		r.reviews = true
+	}
+}
+
+func NewResultWriter(db *sql.DB, opts ...ResultWriterOption) scrapemate.ResultWriter {
+	ans := resultWriter{db: db}
+
+	for _, opt := range opts {
+		opt(&ans)
+	}
+
+	return &ans
 }
 
 type resultWriter struct {
-	db *sql.DB
+	db      *sql.DB
+	reviews bool
 }
 
 func (r *resultWriter) Run(ctx context.Context, in <-chan scrapemate.Result) error {
@@ -96,7 +113,69 @@ func (r *resultWriter) batchSave(ctx context.Context, entries []*gmaps.Entry) er
 		return err
 	}
 
+	if r.reviews {
+		if err := saveReviews(ctx, tx, entries); err != nil {
+			return err
+		}
+	}
+
 	err = tx.Commit()
 
 	return err
 }
+
+// saveReviews inserts the reviews of the entries. Reviews that are already
+// stored only get their owner response updated.
+func saveReviews(ctx context.Context, tx *sql.Tx, entries []*gmaps.Entry) error {
+	const q = `INSERT INTO reviews
+		(data_id, cid, review_id, author, author_picture, rating, text, review_date,
+		posted_at, posted_at_estimated, relative_time, owner_response, owner_response_at, images)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (data_id, review_id) DO UPDATE SET
+			owner_response = EXCLUDED.owner_response,
+			owner_response_at = EXCLUDED.owner_response_at`
+
+	stmt, err := tx.PrepareContext(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	for _, entry := range entries {
+		rows := entry.ReviewRows()
+
+		for i := range rows {
+			images, err := json.Marshal(rows[i].Images)
+			if err != nil {
+				return err
+			}
+
+			_, err = stmt.ExecContext(ctx,
+				rows[i].DataID,
+				rows[i].Cid,
+				rows[i].ReviewID,
+				rows[i].Author,
+				rows[i].AuthorPicture,
+				rows[i].Rating,
+				rows[i].Text,
+				rows[i].When,
+				nullTime(rows[i].Time),
+				rows[i].TimeEstimated,
+				rows[i].RelativeTime,
+				rows[i].OwnerResponse,
+				nullTime(rows[i].OwnerResponseTime),
+				images,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
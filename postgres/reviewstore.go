@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// reviewStore reads the review checkpoints from the reviews table, and
+// from the results table for places crawled before the reviews table was
+// written. The reviews themselves are saved by the result writer, which
+// must be created with WithReviews.
+type reviewStore struct {
+	db *sql.DB
+}
+
+var _ gmaps.ReviewStore = (*reviewStore)(nil)
+
+// NewReviewStore returns the review store of the database, whose schema
+// was migrated with Migrate.
+func NewReviewStore(db *sql.DB) gmaps.ReviewStore {
+	return &reviewStore{db: db}
+}
+
+func (s *reviewStore) Checkpoint(ctx context.Context, dataID string) (gmaps.ReviewCheckpoint, error) {
+	const (
+		qReviews = `SELECT review_id, posted_at, posted_at_estimated FROM reviews
+			WHERE data_id = $1
+			ORDER BY posted_at DESC NULLS LAST
+			LIMIT $2`
+
+		qResults = `SELECT COALESCE(r->>'ID', ''), (r->>'Time')::timestamptz, COALESCE((r->>'TimeEstimated')::boolean, false)
+			FROM (SELECT data FROM results WHERE data->>'data_id' = $1 ORDER BY id DESC LIMIT 1) AS latest
+			CROSS JOIN LATERAL jsonb_path_query(latest.data::jsonb, '$.user_reviews_extended[*]') AS r
+			LIMIT $2`
+	)
+
+	if dataID == "" {
+		return gmaps.ReviewCheckpoint{}, nil
+	}
+
+	reviews, err := s.query(ctx, qReviews, dataID)
+	if err != nil {
+		return gmaps.ReviewCheckpoint{}, err
+	}
+
+	if len(reviews) == 0 {
+		reviews, err = s.query(ctx, qResults, dataID)
+		if err != nil {
+			return gmaps.ReviewCheckpoint{}, err
+		}
+	}
+
+	return gmaps.ReviewCheckpoint{}.Merge(reviews), nil
+}
+
+func (s *reviewStore) query(ctx context.Context, q, dataID string) ([]gmaps.Review, error) {
+	const limit = 50
+
+	rows, err := s.db.QueryContext(ctx, q, dataID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []gmaps.Review
+
+	for rows.Next() {
+		var (
+			r        gmaps.Review
+			postedAt sql.NullTime
+		)
+
+		if err := rows.Scan(&r.ID, &postedAt, &r.TimeEstimated); err != nil {
+			return nil, err
+		}
+
+		if postedAt.Valid {
+			r.Time = postedAt.Time.In(time.UTC)
+		}
+
+		ans = append(ans, r)
+	}
+
+	return ans, rows.Err()
+}
+
+// Save is a no-op, the result writer stores the reviews.
+func (s *reviewStore) Save(context.Context, string, []gmaps.Review) error {
+	return nil
+}
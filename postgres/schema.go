@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations bring the schema of the results database up to date. They
+// can run any number of times.
+var migrations = []struct {
+	name string
+	stmt string
+}{
+	// crawls are told apart by the time their results were written. The
+	// column is added without a default so the existing rows are not
+	// rewritten, they stay NULL for "written before it was tracked".
+	{"add created_at to results", `ALTER TABLE results ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ`},
+	{"default created_at of results", `ALTER TABLE results ALTER COLUMN created_at SET DEFAULT NOW()`},
+	{"create reviews table", createReviewsTable},
+}
+
+// Migrate updates the schema of the database the results are written to.
+// It expects the results table to exist.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	for _, m := range migrations {
+		if _, err := db.ExecContext(ctx, m.stmt); err != nil {
+			return fmt.Errorf("failed to %s: %w", m.name, err)
+		}
+	}
+
+	return nil
+}
+
+const createReviewsTable = `CREATE TABLE IF NOT EXISTS reviews (
+	data_id TEXT NOT NULL,
+	cid TEXT NOT NULL,
+	review_id TEXT NOT NULL,
+	author TEXT NOT NULL,
+	author_picture TEXT NOT NULL,
+	rating INT NOT NULL,
+	text TEXT NOT NULL,
+	review_date TEXT NOT NULL,
+	posted_at TIMESTAMPTZ,
+	posted_at_estimated BOOLEAN NOT NULL,
+	relative_time TEXT NOT NULL,
+	owner_response TEXT NOT NULL,
+	owner_response_at TIMESTAMPTZ,
+	images JSONB NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (data_id, review_id)
+)`
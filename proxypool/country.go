@@ -0,0 +1,156 @@
+package proxypool
+
+import (
+	"strconv"
+	"strings"
+)
+
+// langCountries has the languages that are spoken mostly in one country.
+// Languages such as English are not pinned to a country.
+var langCountries = map[string]string{
+	"bg": "BG",
+	"cs": "CZ",
+	"da": "DK",
+	"de": "DE",
+	"el": "GR",
+	"es": "ES",
+	"fi": "FI",
+	"fr": "FR",
+	"he": "IL",
+	"hi": "IN",
+	"hu": "HU",
+	"it": "IT",
+	"iw": "IL",
+	"ja": "JP",
+	"ko": "KR",
+	"nb": "NO",
+	"nl": "NL",
+	"no": "NO",
+	"pl": "PL",
+	"pt": "PT",
+	"ro": "RO",
+	"ru": "RU",
+	"sk": "SK",
+	"sv": "SE",
+	"tr": "TR",
+	"uk": "UA",
+	"zh": "CN",
+}
+
+// bbox is the bounding box of a country: min lat, min lon, max lat, max lon.
+type bbox [4]float64
+
+func (b bbox) contains(lat, lon float64) bool {
+	return lat >= b[0] && lat <= b[2] && lon >= b[1] && lon <= b[3]
+}
+
+// countryBoxes are the bounding boxes of the mainland of the countries
+// proxies are usually sold for. Neighbours overlap, see Country.
+var countryBoxes = map[string]bbox{
+	"AE": {22.63, 51.58, 26.08, 56.38},
+	"AR": {-55.06, -73.58, -21.78, -53.59},
+	"AT": {46.37, 9.53, 49.02, 17.16},
+	"AU": {-43.64, 113.34, -10.67, 153.57},
+	"BE": {49.50, 2.54, 51.51, 6.41},
+	"BG": {41.23, 22.36, 44.22, 28.61},
+	"BR": {-33.75, -73.99, 5.27, -34.79},
+	"CA": {41.68, -141.00, 83.11, -52.62},
+	"CH": {45.82, 5.96, 47.81, 10.49},
+	"CN": {18.16, 73.50, 53.56, 134.77},
+	"CZ": {48.55, 12.09, 51.06, 18.86},
+	"DE": {47.27, 5.87, 55.06, 15.04},
+	"DK": {54.56, 8.07, 57.75, 15.20},
+	"ES": {35.95, -9.39, 43.79, 4.33},
+	"FI": {59.81, 20.55, 70.09, 31.59},
+	"FR": {41.33, -5.14, 51.09, 9.56},
+	"GB": {49.96, -8.65, 60.86, 1.77},
+	"GR": {34.80, 19.37, 41.75, 28.25},
+	"HU": {45.74, 16.11, 48.59, 22.90},
+	"IE": {51.42, -10.48, 55.39, -5.99},
+	"IL": {29.50, 34.27, 33.33, 35.90},
+	"IN": {6.75, 68.18, 35.50, 97.40},
+	"IT": {36.62, 6.63, 47.09, 18.52},
+	"JP": {24.25, 122.93, 45.52, 145.82},
+	"KR": {33.11, 124.61, 38.61, 131.87},
+	"MX": {14.53, -118.40, 32.72, -86.71},
+	"NL": {50.75, 3.36, 53.56, 7.23},
+	"NO": {57.98, 4.65, 71.19, 31.08},
+	"NZ": {-47.29, 166.43, -34.39, 178.55},
+	"PL": {49.00, 14.12, 54.84, 24.15},
+	"PT": {36.96, -9.50, 42.15, -6.19},
+	"RO": {43.62, 20.26, 48.27, 29.69},
+	"SE": {55.34, 11.11, 69.06, 24.17},
+	"SK": {47.73, 16.83, 49.61, 22.57},
+	"TR": {35.82, 25.66, 42.11, 44.82},
+	"UA": {44.39, 22.14, 52.38, 40.23},
+	"US": {24.52, -124.77, 49.38, -66.95},
+	"ZA": {-34.84, 16.46, -22.13, 32.89},
+}
+
+// Country returns the country of the proxies for a job, from its language
+// and its coordinates, "lat,lon", either may be empty. It returns an empty
+// string when the country is not known.
+//
+// The country of the language is the region of the language, as in pt-BR,
+// or the country where it is mostly spoken. The coordinates win when they
+// are in the bounding box of a single country. Near a border they may be in
+// several, and the country of the language is taken when it is one of them,
+// else the country is not known.
+func Country(lang, coords string) string {
+	langCountry := countryOfLang(lang)
+
+	var in []string
+
+	if lat, lon, ok := parseCoords(coords); ok {
+		for code, box := range countryBoxes {
+			if box.contains(lat, lon) {
+				in = append(in, code)
+			}
+		}
+	}
+
+	switch {
+	case len(in) == 1:
+		return in[0]
+	case len(in) > 1:
+		for _, code := range in {
+			if code == langCountry {
+				return code
+			}
+		}
+
+		return ""
+	}
+
+	return langCountry
+}
+
+func countryOfLang(lang string) string {
+	lang = strings.ReplaceAll(strings.TrimSpace(lang), "_", "-")
+
+	base, region, ok := strings.Cut(lang, "-")
+	if ok && len(region) == 2 {
+		return strings.ToUpper(region)
+	}
+
+	return langCountries[strings.ToLower(base)]
+}
+
+func parseCoords(coords string) (lat, lon float64, ok bool) {
+	a, b, found := strings.Cut(coords, ",")
+	if !found {
+		return 0, 0, false
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	lon, err = strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return lat, lon, true
+}
@@ -0,0 +1,547 @@
+package proxypool
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	xproxy "golang.org/x/net/proxy"
+
+	"github.com/gosom/scrapemate"
+)
+
+const (
+	// dialTimeout is how long connecting through a proxy may take before
+	// it counts as a failure.
+	dialTimeout = 20 * time.Second
+	// attempts is how many proxies are tried for a connection.
+	attempts = 3
+	// defaultKey is the key of clients without a user name.
+	defaultKey = "default"
+)
+
+// hopHeaders are not forwarded by the gateway.
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Gateway is a local HTTP proxy that sends each connection through a proxy
+// of the pool and records how it went. Clients log in with the password of
+// the gateway, and their user name is the key of PolicySticky, followed by
+// ",country=XX" to use the proxies of a country.
+type Gateway struct {
+	pool     *Pool
+	listener net.Listener
+	srv      *http.Server
+	secret   string
+
+	// transports has an http.Transport per proxy for plain HTTP requests.
+	transports sync.Map
+
+	mu sync.Mutex
+	// tunnels has the open tunnels of each key.
+	tunnels map[string]map[net.Conn]struct{}
+}
+
+// NewGateway starts a gateway to the pool on a free port of the loopback
+// interface.
+func NewGateway(pool *Pool) (*Gateway, error) {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	ans := Gateway{
+		pool:     pool,
+		listener: listener,
+		secret:   hex.EncodeToString(b),
+		tunnels:  map[string]map[net.Conn]struct{}{},
+	}
+
+	ans.srv = &http.Server{
+		Handler:           &ans,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	go func() {
+		_ = ans.srv.Serve(listener)
+	}()
+
+	return &ans, nil
+}
+
+func (g *Gateway) Pool() *Pool {
+	return g.pool
+}
+
+// URL returns the proxy URL for the clients of the key.
+func (g *Gateway) URL(key string) string {
+	return g.CountryURL(key, "")
+}
+
+// CountryURL returns the proxy URL for the clients of the key that use the
+// proxies of the country, see Pool.AcquireIn.
+func (g *Gateway) CountryURL(key, country string) string {
+	user := cmp.Or(key, defaultKey)
+	if country != "" {
+		user += ",country=" + country
+	}
+
+	u := url.URL{
+		Scheme: "http",
+		User:   url.UserPassword(user, g.secret),
+		Host:   g.listener.Addr().String(),
+	}
+
+	return u.String()
+}
+
+// Close stops accepting connections and the Watch of the pool. The open
+// tunnels end with their clients.
+func (g *Gateway) Close() error {
+	g.pool.Close()
+
+	g.transports.Range(func(_, v any) bool {
+		v.(*http.Transport).CloseIdleConnections()
+
+		return true
+	})
+
+	return g.srv.Close()
+}
+
+// Rotate moves the clients of the key to other proxies after the target
+// blocked them, for blocks that are only seen in the pages, such as a
+// CAPTCHA inside a TLS connection. The proxy of the latest connection of
+// the key is quarantined, see Pool.Blocked, and the open tunnels of the key
+// are closed, so that the clients connect again through another proxy.
+func (g *Gateway) Rotate(key, reason string) {
+	key = cmp.Or(key, defaultKey)
+
+	g.pool.Blocked(key, reason)
+
+	g.mu.Lock()
+	conns := g.tunnels[key]
+	delete(g.tunnels, key)
+	g.mu.Unlock()
+
+	for conn := range conns {
+		conn.Close()
+	}
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := g.authenticate(r)
+	if !ok {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="proxypool"`)
+		http.Error(w, "proxy authentication required", http.StatusProxyAuthRequired)
+
+		return
+	}
+
+	key, country := parseUser(user)
+
+	acquire := func() (*Lease, error) {
+		return g.pool.AcquireIn(key, country)
+	}
+
+	if r.Method == http.MethodConnect {
+		g.connect(w, r, key, acquire)
+
+		return
+	}
+
+	g.forward(w, r, acquire)
+}
+
+// parseUser splits the user name of a client into its key and the country
+// of its proxies.
+func parseUser(user string) (key, country string) {
+	key, params, _ := strings.Cut(user, ",")
+
+	for _, param := range strings.Split(params, ",") {
+		if v, ok := strings.CutPrefix(param, "country="); ok {
+			country = v
+		}
+	}
+
+	return cmp.Or(key, defaultKey), country
+}
+
+// authenticate returns the user name of the client when it has the
+// password of the gateway.
+func (g *Gateway) authenticate(r *http.Request) (string, bool) {
+	auth, ok := strings.CutPrefix(r.Header.Get("Proxy-Authorization"), "Basic ")
+	if !ok {
+		return "", false
+	}
+
+	b, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return "", false
+	}
+
+	user, password, _ := strings.Cut(string(b), ":")
+
+	if subtle.ConstantTimeCompare([]byte(password), []byte(g.secret)) != 1 {
+		return "", false
+	}
+
+	return user, true
+}
+
+// connect opens a tunnel to the host of a CONNECT request through a proxy
+// of the pool, trying another one when a proxy fails.
+func (g *Gateway) connect(w http.ResponseWriter, r *http.Request, key string, acquire func() (*Lease, error)) {
+	var (
+		upstream net.Conn
+		err      error
+	)
+
+	for range attempts {
+		var lease *Lease
+
+		lease, err = acquire()
+		if err != nil {
+			break
+		}
+
+		upstream, err = dial(r.Context(), lease.Proxy, r.Host)
+		if err == nil {
+			lease.Succeeded()
+
+			break
+		}
+
+		release(lease, err)
+	}
+
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+
+		return
+	}
+
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+
+		return
+	}
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		client.Close()
+		upstream.Close()
+
+		return
+	}
+
+	g.track(key, client, true)
+	defer g.track(key, client, false)
+
+	tunnel(client, buf.Reader, upstream)
+}
+
+// track adds or removes an open tunnel of the key.
+func (g *Gateway) track(key string, conn net.Conn, open bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if open {
+		if g.tunnels[key] == nil {
+			g.tunnels[key] = map[net.Conn]struct{}{}
+		}
+
+		g.tunnels[key][conn] = struct{}{}
+
+		return
+	}
+
+	delete(g.tunnels[key], conn)
+
+	if len(g.tunnels[key]) == 0 {
+		delete(g.tunnels, key)
+	}
+}
+
+// forward sends a plain HTTP request through a proxy of the pool. Requests
+// with a body are not tried again, as the body is read once.
+func (g *Gateway) forward(w http.ResponseWriter, r *http.Request, acquire func() (*Lease, error)) {
+	if r.URL.Host == "" {
+		http.Error(w, "absolute URL required", http.StatusBadRequest)
+
+		return
+	}
+
+	req := r.Clone(r.Context())
+	req.RequestURI = ""
+
+	for _, h := range hopHeaders {
+		req.Header.Del(h)
+	}
+
+	var (
+		resp *http.Response
+		err  error
+	)
+
+	for range attempts {
+		var lease *Lease
+
+		lease, err = acquire()
+		if err != nil {
+			break
+		}
+
+		resp, err = g.transport(lease.Proxy).RoundTrip(req)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			lease.Blocked(resp.Status)
+
+			break
+		}
+
+		if err == nil {
+			lease.Succeeded()
+
+			break
+		}
+
+		release(lease, err)
+
+		if r.ContentLength != 0 {
+			break
+		}
+	}
+
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	defer resp.Body.Close()
+
+	for _, h := range hopHeaders {
+		resp.Header.Del(h)
+	}
+
+	for k, vv := range resp.Header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+
+	w.WriteHeader(resp.StatusCode)
+
+	_, _ = io.Copy(w, resp.Body)
+}
+
+func (g *Gateway) transport(p scrapemate.Proxy) *http.Transport {
+	if tr, ok := g.transports.Load(p.URL); ok {
+		return tr.(*http.Transport)
+	}
+
+	// the URL was parsed when the pool was created
+	u, _ := url.Parse(p.FullURL())
+
+	tr, _ := g.transports.LoadOrStore(p.URL, &http.Transport{
+		Proxy:                 http.ProxyURL(u),
+		TLSHandshakeTimeout:   dialTimeout,
+		ResponseHeaderTimeout: dialTimeout,
+		IdleConnTimeout:       90 * time.Second,
+	})
+
+	return tr.(*http.Transport)
+}
+
+// blockedError is a refusal of the proxy that quarantines it at once.
+type blockedError struct {
+	status string
+}
+
+func (e *blockedError) Error() string {
+	return "blocked: " + e.status
+}
+
+func release(lease *Lease, err error) {
+	var blocked *blockedError
+	if errors.As(err, &blocked) {
+		lease.Blocked(blocked.status)
+
+		return
+	}
+
+	lease.Failed(err)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusBadGateway
+	if errors.Is(err, ErrNoProxy) {
+		code = http.StatusServiceUnavailable
+	}
+
+	http.Error(w, err.Error(), code)
+}
+
+// dial connects to addr through the proxy.
+func dial(ctx context.Context, p scrapemate.Proxy, addr string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		var auth *xproxy.Auth
+		if p.Username != "" {
+			auth = &xproxy.Auth{User: p.Username, Password: p.Password}
+		}
+
+		dialer, err := xproxy.SOCKS5("tcp", u.Host, auth, &net.Dialer{})
+		if err != nil {
+			return nil, err
+		}
+
+		return dialer.(xproxy.ContextDialer).DialContext(ctx, "tcp", addr)
+	default:
+		return dialHTTP(ctx, u, p, addr)
+	}
+}
+
+// dialHTTP opens a tunnel to addr with a CONNECT request to an HTTP proxy.
+func dialHTTP(ctx context.Context, u *url.URL, p scrapemate.Proxy, addr string) (net.Conn, error) {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12})
+
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+
+			return nil, err
+		}
+
+		conn = tlsConn
+	}
+
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+
+	if p.Username != "" {
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(p.Username+":"+p.Password)))
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		conn.Close()
+
+		return nil, &blockedError{status: resp.Status}
+	case resp.StatusCode != http.StatusOK:
+		conn.Close()
+
+		return nil, fmt.Errorf("proxy refused CONNECT: %s", resp.Status)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+
+	return conn, nil
+}
+
+// bufferedConn reads what was buffered while reading the CONNECT response
+// first.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// tunnel copies between the client and the upstream connection until
+// either side closes. client may have buffered data in r.
+func tunnel(client net.Conn, r io.Reader, upstream net.Conn) {
+	done := make(chan struct{}, 2)
+
+	go func() {
+		_, _ = io.Copy(upstream, r)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+
+	<-done
+
+	client.Close()
+	upstream.Close()
+
+	<-done
+}
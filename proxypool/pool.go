@@ -0,0 +1,499 @@
+// Package proxypool tracks the health of the proxies of a run and picks one
+// for each connection. Proxies that fail or get blocked are quarantined for
+// a cool-down that doubles each time they are quarantined again.
+//
+// Proxies can carry tags, such as their country, and the list can be read
+// from a file or a URL and kept up to date, see Load and Pool.Watch.
+//
+// scrapemate picks the proxies itself, so the pool is used through a
+// Gateway, a local proxy that scrapemate is given as its only proxy.
+package proxypool
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosom/scrapemate"
+)
+
+const (
+	// PolicyRoundRobin uses the healthy proxies in turn.
+	PolicyRoundRobin = "round-robin"
+	// PolicyLeastUsed uses the healthy proxy with the fewest open
+	// connections, then the fewest uses.
+	PolicyLeastUsed = "least-used"
+	// PolicySticky keeps using the same proxy for a key, such as a job,
+	// until it is quarantined, so the job keeps one IP address.
+	PolicySticky = "sticky"
+)
+
+const (
+	defaultFailureThreshold = 3
+	defaultCooldown         = time.Minute
+	defaultMaxCooldown      = 30 * time.Minute
+	// latencyWeight is the weight of the latest latency in the moving
+	// average.
+	latencyWeight = 0.2
+)
+
+// ErrNoProxy is returned when all the proxies are quarantined.
+var ErrNoProxy = errors.New("all proxies are quarantined")
+
+// IsPolicy reports whether the policy is one of the rotation policies.
+func IsPolicy(policy string) bool {
+	return policy == PolicyRoundRobin || policy == PolicyLeastUsed || policy == PolicySticky
+}
+
+// Stats is the health of a proxy.
+type Stats struct {
+	// Proxy is the URL of the proxy without the credentials.
+	Proxy       string            `json:"proxy"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Status      string            `json:"status"`
+	Uses        int               `json:"uses"`
+	Active      int               `json:"active"`
+	Successes   int               `json:"successes"`
+	Failures    int               `json:"failures"`
+	Blocks      int               `json:"blocks"`
+	SuccessRate float64           `json:"success_rate"`
+	// LatencyMS is the moving average of the time to connect through the
+	// proxy.
+	LatencyMS int64 `json:"latency_ms"`
+	// Quarantines is how many times in a row the proxy was quarantined. It
+	// is reset by a success after the cool-down.
+	Quarantines      int       `json:"quarantines"`
+	QuarantinedUntil time.Time `json:"quarantined_until,omitzero"`
+	LastError        string    `json:"last_error,omitempty"`
+}
+
+const (
+	StatusHealthy     = "healthy"
+	StatusQuarantined = "quarantined"
+)
+
+type proxy struct {
+	scrapemate.Proxy
+
+	tags    map[string]string
+	country string
+
+	uses        int
+	active      int
+	successes   int
+	failures    int
+	blocks      int
+	latency     time.Duration
+	consecutive int
+	quarantines int
+	until       time.Time
+	lastError   string
+}
+
+func (p *proxy) available(now time.Time) bool {
+	return !now.Before(p.until)
+}
+
+// Pool is a set of proxies with their health.
+type Pool struct {
+	mu      sync.Mutex
+	proxies []*proxy
+	next    int
+	sticky  map[string]*proxy
+	// last has the proxy of the latest lease of each key, for Blocked.
+	last map[string]*proxy
+	// byURL has the proxies by their URL with the credentials, to keep
+	// their health when the list is set again.
+	byURL map[string]*proxy
+	stop  chan struct{}
+	once  sync.Once
+
+	policy           string
+	failureThreshold int
+	cooldown         time.Duration
+	maxCooldown      time.Duration
+	now              func() time.Time
+}
+
+type Option func(*Pool)
+
+// WithPolicy sets the rotation policy, PolicyRoundRobin by default.
+func WithPolicy(policy string) Option {
+	return func(p *Pool) {
+		p.policy = policy
+	}
+}
+
+// WithCooldown sets the first quarantine of a proxy and the longest one.
+// Each quarantine in a row is twice as long as the one before.
+func WithCooldown(cooldown, maxCooldown time.Duration) Option {
+	return func(p *Pool) {
+		p.cooldown = cooldown
+		p.maxCooldown = maxCooldown
+	}
+}
+
+// WithFailureThreshold sets how many failures in a row quarantine a proxy.
+// A block quarantines it at once.
+func WithFailureThreshold(n int) Option {
+	return func(p *Pool) {
+		p.failureThreshold = n
+	}
+}
+
+// New returns a pool of the proxies, in the formats of the -proxies flag.
+func New(proxies []string, opts ...Option) (*Pool, error) {
+	entries := make([]Entry, 0, len(proxies))
+
+	for _, u := range proxies {
+		entries = append(entries, Entry{URL: u})
+	}
+
+	return NewFromEntries(entries, opts...)
+}
+
+// NewFromEntries returns a pool of the proxies with their tags.
+func NewFromEntries(entries []Entry, opts ...Option) (*Pool, error) {
+	ans := Pool{
+		sticky:           map[string]*proxy{},
+		last:             map[string]*proxy{},
+		byURL:            map[string]*proxy{},
+		stop:             make(chan struct{}),
+		policy:           PolicyRoundRobin,
+		failureThreshold: defaultFailureThreshold,
+		cooldown:         defaultCooldown,
+		maxCooldown:      defaultMaxCooldown,
+		now:              time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(&ans)
+	}
+
+	if !IsPolicy(ans.policy) {
+		return nil, fmt.Errorf("unknown proxy policy %q", ans.policy)
+	}
+
+	if err := ans.Set(entries); err != nil {
+		return nil, err
+	}
+
+	return &ans, nil
+}
+
+// Set replaces the proxies of the pool. The proxies that were in the pool
+// already keep their health, with the new tags. An empty list is an error
+// and leaves the pool as it was.
+func (p *Pool) Set(entries []Entry) error {
+	if len(entries) == 0 {
+		return errors.New("no proxies")
+	}
+
+	parsed := make([]scrapemate.Proxy, 0, len(entries))
+
+	for _, e := range entries {
+		px, err := scrapemate.NewProxy(e.URL)
+		if err != nil {
+			return err
+		}
+
+		parsed = append(parsed, px)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxies := make([]*proxy, 0, len(entries))
+	byURL := make(map[string]*proxy, len(entries))
+
+	for i, e := range entries {
+		key := parsed[i].FullURL()
+
+		if _, ok := byURL[key]; ok {
+			continue
+		}
+
+		px := p.byURL[key]
+		if px == nil {
+			px = &proxy{Proxy: parsed[i]}
+		}
+
+		px.tags = maps.Clone(e.Tags)
+		px.country = strings.ToUpper(e.Tags[TagCountry])
+
+		proxies = append(proxies, px)
+		byURL[key] = px
+	}
+
+	for key, px := range p.sticky {
+		if byURL[px.FullURL()] != px {
+			delete(p.sticky, key)
+		}
+	}
+
+	for key, px := range p.last {
+		if byURL[px.FullURL()] != px {
+			delete(p.last, key)
+		}
+	}
+
+	p.proxies = proxies
+	p.byURL = byURL
+
+	return nil
+}
+
+// Len returns how many proxies the pool has.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.proxies)
+}
+
+func (p *Pool) Policy() string {
+	return p.policy
+}
+
+// Lease is the use of a proxy for a connection. Exactly one of its methods
+// must be called when the outcome is known.
+type Lease struct {
+	Proxy scrapemate.Proxy
+
+	pool  *Pool
+	proxy *proxy
+	start time.Time
+}
+
+// Acquire picks a proxy for a connection of the key. The key picks the
+// proxy for PolicySticky only, with every policy it tells Blocked which
+// proxy the key used last. It returns ErrNoProxy when all the proxies are
+// quarantined.
+func (p *Pool) Acquire(key string) (*Lease, error) {
+	return p.AcquireIn(key, "")
+}
+
+// AcquireIn is Acquire with the proxies of the country only, the ISO 3166
+// code of their TagCountry. All the proxies are used when none is in the
+// country, so that jobs for other countries still run.
+func (p *Pool) AcquireIn(key, country string) (*Lease, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	match := p.matcher(strings.ToUpper(country))
+
+	var picked *proxy
+
+	switch p.policy {
+	case PolicySticky:
+		if current := p.sticky[key]; current != nil && current.available(now) && match(current) {
+			picked = current
+		} else {
+			picked = p.leastUsed(now, match)
+			p.sticky[key] = picked
+		}
+	case PolicyLeastUsed:
+		picked = p.leastUsed(now, match)
+	default:
+		picked = p.roundRobin(now, match)
+	}
+
+	if picked == nil {
+		return nil, ErrNoProxy
+	}
+
+	picked.uses++
+	picked.active++
+
+	p.last[key] = picked
+
+	return &Lease{Proxy: picked.Proxy, pool: p, proxy: picked, start: now}, nil
+}
+
+// matcher returns whether a proxy may be used for the country.
+func (p *Pool) matcher(country string) func(*proxy) bool {
+	all := func(*proxy) bool { return true }
+
+	if country == "" {
+		return all
+	}
+
+	inCountry := func(px *proxy) bool { return px.country == country }
+
+	for _, px := range p.proxies {
+		if inCountry(px) {
+			return inCountry
+		}
+	}
+
+	return all
+}
+
+func (p *Pool) roundRobin(now time.Time, match func(*proxy) bool) *proxy {
+	for range p.proxies {
+		candidate := p.proxies[p.next%len(p.proxies)]
+		p.next++
+
+		if candidate.available(now) && match(candidate) {
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+func (p *Pool) leastUsed(now time.Time, match func(*proxy) bool) *proxy {
+	var ans *proxy
+
+	for _, candidate := range p.proxies {
+		if !candidate.available(now) || !match(candidate) {
+			continue
+		}
+
+		if ans == nil || candidate.active < ans.active || (candidate.active == ans.active && candidate.uses < ans.uses) {
+			ans = candidate
+		}
+	}
+
+	return ans
+}
+
+// Succeeded records that the connection went through the proxy.
+func (l *Lease) Succeeded() {
+	p := l.pool
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+
+	l.proxy.active--
+	l.proxy.successes++
+	l.proxy.consecutive = 0
+
+	// connections opened before a quarantine do not end it
+	if l.proxy.available(now) {
+		l.proxy.quarantines = 0
+	}
+
+	latency := now.Sub(l.start)
+
+	if l.proxy.latency == 0 {
+		l.proxy.latency = latency
+	} else {
+		l.proxy.latency += time.Duration(latencyWeight * float64(latency-l.proxy.latency))
+	}
+}
+
+// Failed records that the proxy could not be reached or timed out. The
+// proxy is quarantined after the failure threshold in a row.
+func (l *Lease) Failed(err error) {
+	p := l.pool
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l.proxy.active--
+	l.proxy.failures++
+	l.proxy.consecutive++
+	l.proxy.lastError = err.Error()
+
+	if l.proxy.consecutive >= p.failureThreshold {
+		p.quarantine(l.proxy)
+	}
+}
+
+// Blocked records that the target refused the proxy, with a 429 or a
+// CAPTCHA. The proxy is quarantined at once.
+func (l *Lease) Blocked(reason string) {
+	p := l.pool
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l.proxy.active--
+	p.block(l.proxy, reason)
+}
+
+// Blocked records that the target refused the proxy of the latest lease of
+// the key, for blocks that are only seen in the pages, such as a CAPTCHA
+// inside a TLS connection. With PolicySticky that is the proxy the key
+// sticks to.
+func (p *Pool) Blocked(key, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if current := p.last[key]; current != nil && current.available(p.now()) {
+		p.block(current, reason)
+	}
+}
+
+// Forget drops the proxies of the key, when the key is not used anymore.
+func (p *Pool) Forget(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.sticky, key)
+	delete(p.last, key)
+}
+
+func (p *Pool) block(px *proxy, reason string) {
+	px.blocks++
+	px.lastError = reason
+
+	p.quarantine(px)
+}
+
+func (p *Pool) quarantine(px *proxy) {
+	cooldown := p.cooldown << min(px.quarantines, 20)
+	if cooldown > p.maxCooldown || cooldown <= 0 {
+		cooldown = p.maxCooldown
+	}
+
+	px.quarantines++
+	px.consecutive = 0
+	px.until = p.now().Add(cooldown)
+}
+
+// Stats returns the health of the proxies, in the order they were given.
+func (p *Pool) Stats() []Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+
+	ans := make([]Stats, 0, len(p.proxies))
+
+	for _, px := range p.proxies {
+		s := Stats{
+			Proxy:       px.URL,
+			Tags:        maps.Clone(px.tags),
+			Status:      StatusHealthy,
+			Uses:        px.uses,
+			Active:      px.active,
+			Successes:   px.successes,
+			Failures:    px.failures,
+			Blocks:      px.blocks,
+			LatencyMS:   px.latency.Milliseconds(),
+			Quarantines: px.quarantines,
+			LastError:   px.lastError,
+		}
+
+		if total := px.successes + px.failures + px.blocks; total > 0 {
+			s.SuccessRate = float64(px.successes) / float64(total)
+		}
+
+		if !px.available(now) {
+			s.Status = StatusQuarantined
+			s.QuarantinedUntil = px.until
+		}
+
+		ans = append(ans, s)
+	}
+
+	return ans
+}
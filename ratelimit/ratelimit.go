@@ -0,0 +1,221 @@
+// Package ratelimit spaces the requests of a run with a token bucket per
+// target host.
+package ratelimit
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GoogleDomain is the domain of the Google Maps requests, www.google.com
+// and maps.google.com share its bucket.
+const GoogleDomain = "google.com"
+
+// maxBuckets is how many host buckets are kept before the idle ones are
+// dropped.
+const maxBuckets = 10_000
+
+// Option configures the Limiter.
+type Option func(*Limiter)
+
+// WithDomainRate limits the requests to the domain and its subdomains, all
+// of them together, to perMinute. A zero rate does not limit them.
+func WithDomainRate(domain string, perMinute float64) Option {
+	return func(l *Limiter) {
+		l.domains = append(l.domains, domainRate{
+			domain:   strings.ToLower(strings.TrimPrefix(domain, ".")),
+			interval: interval(perMinute),
+		})
+	}
+}
+
+// WithHostRate limits the requests to each host without a domain rate to
+// perMinute, every host on its own. A zero rate does not limit them.
+func WithHostRate(perMinute float64) Option {
+	return func(l *Limiter) {
+		l.host = interval(perMinute)
+	}
+}
+
+// WithJitter waits up to the fraction of the interval of a bucket longer,
+// at random, so the requests are not evenly spaced.
+func WithJitter(fraction float64) Option {
+	return func(l *Limiter) {
+		l.jitter = max(fraction, 0)
+	}
+}
+
+// Limiter makes the requests wait for their turn in the bucket of their
+// host. A bucket holds one token, so after a pause one request goes at once
+// and the next ones are spaced by the interval of the rate. A nil Limiter
+// does not limit anything.
+type Limiter struct {
+	domains []domainRate
+	host    time.Duration
+	jitter  float64
+	scope   string
+
+	*buckets
+}
+
+type buckets struct {
+	mu sync.Mutex
+	// turns is when each bucket has its token again.
+	turns map[string]time.Time
+}
+
+type domainRate struct {
+	domain   string
+	interval time.Duration
+}
+
+// New returns a Limiter without limits, unless options set some.
+func New(opts ...Option) *Limiter {
+	l := Limiter{
+		buckets: &buckets{turns: make(map[string]time.Time)},
+	}
+
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	return &l
+}
+
+// Scoped returns a limiter sharing the buckets and rates of l, whose
+// WaitEvery keys are apart from the ones of other scopes.
+func (l *Limiter) Scoped(scope string) *Limiter {
+	if l == nil {
+		return nil
+	}
+
+	ans := *l
+	ans.scope = scope
+
+	return &ans
+}
+
+// Every returns the rate per minute of one request every d.
+func Every(d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+
+	return float64(time.Minute) / float64(d)
+}
+
+// Wait waits for the turn of a request to the host of rawURL. It returns
+// the error of the context when it is done first, and the turn is given
+// back when no later request took one.
+func (l *Limiter) Wait(ctx context.Context, rawURL string) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	key, every := l.bucket(rawURL)
+
+	return l.wait(ctx, key, every)
+}
+
+// WaitEvery waits for the turn of a request in the bucket of key, which
+// spaces its requests by every. The key is apart from the host buckets, so
+// a request usually waits for both.
+func (l *Limiter) WaitEvery(ctx context.Context, key string, every time.Duration) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	return l.wait(ctx, l.scope+"\x00"+key, every)
+}
+
+func (l *Limiter) wait(ctx context.Context, key string, every time.Duration) error {
+	if every <= 0 {
+		return ctx.Err()
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+
+	l.sweep(now)
+
+	at := l.turns[key]
+	if at.Before(now) {
+		at = now
+	}
+
+	next := at.Add(every)
+	l.turns[key] = next
+
+	l.mu.Unlock()
+
+	wait := at.Sub(now)
+	if l.jitter > 0 {
+		wait += time.Duration(rand.Float64() * l.jitter * float64(every))
+	}
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		if l.turns[key].Equal(next) {
+			l.turns[key] = at
+		}
+		l.mu.Unlock()
+
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// bucket returns the bucket of the URL and its interval.
+func (l *Limiter) bucket(rawURL string) (string, time.Duration) {
+	host := rawURL
+
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+
+	host = strings.ToLower(host)
+
+	for _, d := range l.domains {
+		if host == d.domain || strings.HasSuffix(host, "."+d.domain) {
+			return d.domain, d.interval
+		}
+	}
+
+	return host, l.host
+}
+
+// sweep drops the buckets whose turn has passed when there are too many.
+// The caller holds the lock.
+func (l *Limiter) sweep(now time.Time) {
+	if len(l.turns) < maxBuckets {
+		return
+	}
+
+	for key, at := range l.turns {
+		if at.Before(now) {
+			delete(l.turns, key)
+		}
+	}
+}
+
+func interval(perMinute float64) time.Duration {
+	if perMinute <= 0 {
+		return 0
+	}
+
+	return time.Duration(float64(time.Minute) / perMinute)
+}
@@ -0,0 +1,60 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/ratelimit"
+)
+
+func Test_Limiter(t *testing.T) {
+	const every = 100 * time.Millisecond
+
+	l := ratelimit.New(
+		ratelimit.WithDomainRate(ratelimit.GoogleDomain, ratelimit.Every(every)),
+		ratelimit.WithHostRate(ratelimit.Every(every)),
+	)
+
+	start := time.Now()
+
+	// the Google hosts share a bucket, the first request goes at once
+	require.NoError(t, l.Wait(t.Context(), "https://www.google.com/maps/search/cafe"))
+	require.Less(t, time.Since(start), every/2)
+
+	require.NoError(t, l.Wait(t.Context(), "https://maps.google.com/search?q=cafe"))
+	require.GreaterOrEqual(t, time.Since(start), every)
+
+	// every website has its own bucket
+	start = time.Now()
+
+	require.NoError(t, l.Wait(t.Context(), "https://example.com/contact"))
+	require.NoError(t, l.Wait(t.Context(), "https://example.org/"))
+	require.Less(t, time.Since(start), every/2)
+
+	// a cancelled wait returns at once and gives its turn back
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, l.Wait(ctx, "https://example.com/"), context.DeadlineExceeded)
+
+	time.Sleep(every)
+
+	start = time.Now()
+
+	require.NoError(t, l.Wait(t.Context(), "https://example.com/"))
+	require.Less(t, time.Since(start), every/2)
+
+	// the keys of other scopes are apart
+	start = time.Now()
+
+	require.NoError(t, l.Scoped("a").WaitEvery(t.Context(), "search", time.Minute))
+	require.NoError(t, l.Scoped("b").WaitEvery(t.Context(), "search", time.Minute))
+	require.Less(t, time.Since(start), every/2)
+
+	var nilLimiter *ratelimit.Limiter
+
+	require.NoError(t, nilLimiter.Wait(t.Context(), "https://www.google.com/"))
+}
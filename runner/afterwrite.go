@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/gosom/scrapemate"
+)
+
+// afterWriteTick is how long the latest result taken by a write-through
+// writer is waited for when no other result follows it.
+const afterWriteTick = time.Second
+
+// WriteThrough marks a results writer that has written and flushed a
+// result by the time it takes the next one, as the csv and json writers
+// and the writer of the web jobs do. The writers that buffer results, such
+// as the parquet, geo and database writers, must not be marked.
+func WriteThrough(w scrapemate.ResultWriter) scrapemate.ResultWriter {
+	return writeThrough{w}
+}
+
+type writeThrough struct {
+	scrapemate.ResultWriter
+}
+
+// AfterWrite wraps the results writer so fn is called with each result
+// once it has been written. Behind a write-through writer, a result is
+// written once the writer takes the next one, or a tick after it when none
+// follows. Behind any other writer, fn is called for all the results once
+// the writer returned without an error. An error of fn stops the writer.
+//
+// AfterWrite over an AfterWrite writer adds fn to it, so the results are
+// not held back by one more writer.
+func AfterWrite(next scrapemate.ResultWriter, fn func(scrapemate.Result) error) scrapemate.ResultWriter {
+	if w, ok := next.(*afterWriter); ok {
+		return &afterWriter{next: w.next, through: w.through, fns: append(slices.Clip(w.fns), fn)}
+	}
+
+	_, through := next.(writeThrough)
+
+	return &afterWriter{next: next, through: through, fns: []func(scrapemate.Result) error{fn}}
+}
+
+type afterWriter struct {
+	next    scrapemate.ResultWriter
+	through bool
+	fns     []func(scrapemate.Result) error
+}
+
+func (w *afterWriter) Run(ctx context.Context, in <-chan scrapemate.Result) error {
+	out := make(chan scrapemate.Result)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- w.next.Run(ctx, out)
+	}()
+
+	var tick <-chan time.Time
+
+	if w.through {
+		ticker := time.NewTicker(afterWriteTick)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	var (
+		pending  []scrapemate.Result
+		lastSent time.Time
+	)
+
+loop:
+	for {
+		select {
+		case result, ok := <-in:
+			if !ok {
+				break loop
+			}
+
+			select {
+			case out <- result:
+			case err := <-errc:
+				return err
+			}
+
+			if w.through {
+				if err := w.written(pending); err != nil {
+					close(out)
+
+					return err
+				}
+
+				pending = pending[:0]
+			}
+
+			pending = append(pending, result)
+			lastSent = time.Now()
+		case <-tick:
+			if len(pending) > 0 && time.Since(lastSent) >= afterWriteTick {
+				if err := w.written(pending); err != nil {
+					close(out)
+
+					return err
+				}
+
+				pending = pending[:0]
+			}
+		}
+	}
+
+	close(out)
+
+	if err := <-errc; err != nil {
+		return err
+	}
+
+	return w.written(pending)
+}
+
+func (w *afterWriter) written(results []scrapemate.Result) error {
+	for i := range results {
+		for _, fn := range w.fns {
+			if err := fn(results[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package runner_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/runner"
+)
+
+// memoryWriter writes the results it takes at once, or, when it buffers,
+// once its input is closed, like the parquet writer with its row groups.
+type memoryWriter struct {
+	buffer bool
+
+	mu      sync.Mutex
+	written []any
+}
+
+func (w *memoryWriter) Run(_ context.Context, in <-chan scrapemate.Result) error {
+	var buffered []any
+
+	for result := range in {
+		if w.buffer {
+			buffered = append(buffered, result.Data)
+
+			continue
+		}
+
+		w.write(result.Data)
+	}
+
+	w.write(buffered...)
+
+	return nil
+}
+
+func (w *memoryWriter) write(data ...any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.written = append(w.written, data...)
+}
+
+func (w *memoryWriter) isWritten(data any) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return slices.Contains(w.written, data)
+}
+
+func runAfterWrite(t *testing.T, w scrapemate.ResultWriter, data ...any) error {
+	t.Helper()
+
+	in := make(chan scrapemate.Result, len(data))
+	for i := range data {
+		in <- scrapemate.Result{Data: data[i]}
+	}
+
+	close(in)
+
+	return w.Run(t.Context(), in)
+}
+
+func Test_AfterWrite(t *testing.T) {
+	for _, buffer := range []bool{false, true} {
+		next := &memoryWriter{buffer: buffer}
+
+		var w scrapemate.ResultWriter = next
+		if !buffer {
+			w = runner.WriteThrough(next)
+		}
+
+		var first, second []any
+
+		// the writers over another one are merged into it
+		w = runner.AfterWrite(runner.AfterWrite(w, func(result scrapemate.Result) error {
+			require.True(t, next.isWritten(result.Data))
+
+			first = append(first, result.Data)
+
+			return nil
+		}), func(result scrapemate.Result) error {
+			second = append(second, result.Data)
+
+			return nil
+		})
+
+		require.NoError(t, runAfterWrite(t, w, "a", "b", "c"))
+		require.Equal(t, []any{"a", "b", "c"}, first)
+		require.Equal(t, first, second)
+	}
+}
+
+func Test_AfterWriteError(t *testing.T) {
+	errStop := errors.New("stop")
+
+	w := runner.AfterWrite(runner.WriteThrough(&memoryWriter{}), func(scrapemate.Result) error {
+		return errStop
+	})
+
+	require.ErrorIs(t, runAfterWrite(t, w, "a", "b"), errStop)
+}
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/gosom/google-maps-scraper/common/logger"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/proxypool"
+)
+
+// maxBlockCooldown is the longest cool-down after blocks in a row.
+const maxBlockCooldown = 15 * time.Minute
+
+// NewBackoff returns the backoff of a run after Google blocks its jobs,
+// with the -block-cooldown of the config. When a cool-down starts, the
+// clients of the key on the proxy gateway, which may be nil, are moved to
+// other proxies.
+func NewBackoff(cfg *Config, gw *proxypool.Gateway, key string) *gmaps.Backoff {
+	return gmaps.NewBackoff(cfg.BlockCooldown, max(cfg.BlockCooldown, maxBlockCooldown), OnBlock(gw, key))
+}
+
+// OnBlock returns the onBlock of the backoff of the jobs of the key, see
+// gmaps.Backoff.Scoped. It moves the clients of the key on the proxy
+// gateway, which may be nil, to other proxies.
+func OnBlock(gw *proxypool.Gateway, key string) func(*gmaps.BlockedError) {
+	return func(err *gmaps.BlockedError) {
+		logger.Warn("blocked by google, cooling down", "key", key, "reason", err.Reason, "url", err.URL)
+
+		if gw != nil {
+			gw.Rotate(key, err.Reason)
+		}
+	}
+}
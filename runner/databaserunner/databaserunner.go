@@ -10,7 +10,9 @@ import (
 	// postgres driver
 	_ "github.com/jackc/pgx/v5/stdlib"
 
+	"github.com/gosom/google-maps-scraper/gmaps"
 	"github.com/gosom/google-maps-scraper/postgres"
+	"github.com/gosom/google-maps-scraper/proxypool"
 	"github.com/gosom/google-maps-scraper/runner"
 	"github.com/gosom/google-maps-scraper/tlmt"
 	"github.com/gosom/scrapemate"
@@ -23,6 +25,7 @@ type dbrunner struct {
 	produce  bool
 	app      *scrapemateapp.ScrapemateApp
 	conn     *sql.DB
+	proxies  *proxypool.Gateway
 }
 
 func New(cfg *runner.Config) (runner.Runner, error) {
@@ -35,9 +38,29 @@ func New(cfg *runner.Config) (runner.Runner, error) {
 		return nil, err
 	}
 
+	if err := postgres.Migrate(context.Background(), conn); err != nil {
+		return nil, err
+	}
+
+	var (
+		providerOpts []postgres.ProviderOption
+		writerOpts   []postgres.ResultWriterOption
+	)
+
+	// the review store reads the reviews the writer saves
+	if cfg.ExportReviews || (cfg.IncrementalReviews && !cfg.ProduceOnly) {
+		writerOpts = append(writerOpts, postgres.WithReviews())
+	}
+
+	if cfg.IncrementalReviews && !cfg.ProduceOnly {
+		providerOpts = append(providerOpts, postgres.WithReviewStore(postgres.NewReviewStore(conn)))
+	}
+
+	providerOpts = append(providerOpts, postgres.WithRateLimiter(runner.NewRateLimiter(cfg)))
+
 	ans := dbrunner{
 		cfg:      cfg,
-		provider: postgres.NewProvider(conn),
+		provider: postgres.NewProvider(conn, providerOpts...),
 		produce:  cfg.ProduceOnly,
 		conn:     conn,
 	}
@@ -46,22 +69,34 @@ func New(cfg *runner.Config) (runner.Runner, error) {
 		return &ans, nil
 	}
 
-	psqlWriter := postgres.NewResultWriter(conn)
+	psqlWriter := postgres.NewResultWriter(conn, writerOpts...)
 
 	writers := []scrapemate.ResultWriter{
 		psqlWriter,
 	}
 
+	provider := ans.provider
+	if cfg.FastMode {
+		provider = gmaps.NewWaitProvider(provider)
+	}
+
 	opts := []func(*scrapemateapp.Config) error{
 		// scrapemateapp.WithCache("leveldb", "cache"),
 		scrapemateapp.WithConcurrency(cfg.Concurrency),
-		scrapemateapp.WithProvider(ans.provider),
+		scrapemateapp.WithProvider(provider),
 		scrapemateapp.WithExitOnInactivity(cfg.ExitOnInactivityDuration),
 	}
 
-	if len(cfg.Proxies) > 0 {
+	ans.proxies, err = runner.NewProxyGateway(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if ans.proxies != nil {
+		country := proxypool.Country(cfg.LangCode, cfg.GeoCoordinates)
+
 		opts = append(opts,
-			scrapemateapp.WithProxies(cfg.Proxies),
+			scrapemateapp.WithProxies([]string{ans.proxies.CountryURL("", country)}),
 		)
 	}
 
@@ -112,6 +147,12 @@ func (d *dbrunner) Run(ctx context.Context) error {
 }
 
 func (d *dbrunner) Close(context.Context) error {
+	if d.proxies != nil {
+		runner.LogProxyStats(d.proxies)
+
+		_ = d.proxies.Close()
+	}
+
 	if d.app != nil {
 		return d.app.Close()
 	}
@@ -144,6 +185,7 @@ func (d *dbrunner) produceSeedJobs(ctx context.Context) error {
 		d.cfg.FastMode,
 		d.cfg.LangCode,
 		input,
+		d.cfg.InputFormat,
 		d.cfg.MaxDepth,
 		d.cfg.Email,
 		d.cfg.GeoCoordinates,
@@ -152,7 +194,11 @@ func (d *dbrunner) produceSeedJobs(ctx context.Context) error {
 		nil,
 		nil,
 		d.cfg.ExtraReviews,
+		d.cfg.ReviewOptions,
 		0,
+		nil,
+		nil,
+		nil,
 	)
 	if err != nil {
 		return err
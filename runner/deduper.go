@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+
+	"github.com/gosom/google-maps-scraper/deduper"
+)
+
+const (
+	DedupMemory   = "memory"
+	DedupSQLite   = "sqlite"
+	DedupPostgres = "postgres"
+)
+
+// NewDeduper creates the deduper selected with -dedup. The namespace is
+// used unless -dedup-namespace shares the seen places between runs.
+// Callers must release it with deduper.Close.
+func NewDeduper(cfg *Config, namespace string) (deduper.Deduper, error) {
+	opts := []deduper.Option{
+		deduper.WithNamespace(cmp.Or(cfg.DedupNamespace, namespace)),
+		deduper.WithTTL(cfg.DedupTTL),
+	}
+
+	switch cfg.DedupBackend {
+	case DedupSQLite:
+		if err := os.MkdirAll(cfg.DataFolder, os.ModePerm); err != nil {
+			return nil, err
+		}
+
+		return deduper.NewSQLite(filepath.Join(cfg.DataFolder, "dedup.db"), opts...)
+	case DedupPostgres:
+		return deduper.NewPostgres(cmp.Or(cfg.DedupDsn, cfg.Dsn), opts...)
+	default:
+		return deduper.New(), nil
+	}
+}
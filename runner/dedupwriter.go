@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/gosom/scrapemate"
+
+	"github.com/gosom/google-maps-scraper/common/logger"
+	"github.com/gosom/google-maps-scraper/deduper"
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// DedupWriter wraps the results writer so the keys of the places are
+// committed to the deduper once the places have been written, see
+// AfterWrite. Places that failed are not committed and are scraped again
+// by the next run. next is returned as is for dedupers that do not persist
+// keys.
+func DedupWriter(d deduper.Deduper, next scrapemate.ResultWriter) scrapemate.ResultWriter {
+	if _, ok := d.(deduper.Committer); !ok {
+		return next
+	}
+
+	return AfterWrite(next, func(result scrapemate.Result) error {
+		// the place is scraped again by a later run at worst
+		if err := deduper.Commit(context.Background(), d, PlaceKeys(result)...); err != nil {
+			logger.Warn("committing dedup keys failed", "error", err)
+		}
+
+		return nil
+	})
+}
+
+// PlaceKeys returns the keys the jobs of the result deduplicated its
+// places by.
+func PlaceKeys(result scrapemate.Result) []string {
+	switch job := result.Job.(type) {
+	case *gmaps.SearchJob:
+		entries, _ := result.Data.([]*gmaps.Entry)
+		keys := make([]string, 0, len(entries))
+
+		for _, e := range entries {
+			keys = append(keys, gmaps.PlaceKey(e))
+		}
+
+		return keys
+	case *gmaps.PlaceJob:
+		return []string{job.GetURL()}
+	case *gmaps.EmailExtractJob:
+		if job.PlaceURL != "" {
+			return []string{job.PlaceURL}
+		}
+	}
+
+	return nil
+}
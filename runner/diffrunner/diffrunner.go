@@ -0,0 +1,160 @@
+package diffrunner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	// postgres driver
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/gosom/google-maps-scraper/common/logger"
+	"github.com/gosom/google-maps-scraper/diff"
+	"github.com/gosom/google-maps-scraper/postgres"
+	"github.com/gosom/google-maps-scraper/runner"
+)
+
+// diffrunner compares two crawls, either two results files or two time
+// ranges of the postgres results table, and writes the changes.
+type diffrunner struct {
+	cfg  *runner.Config
+	conn *sql.DB
+}
+
+func New(cfg *runner.Config) (runner.Runner, error) {
+	if cfg.RunMode != runner.RunModeDiff {
+		return nil, fmt.Errorf("%w: %d", runner.ErrInvalidRunMode, cfg.RunMode)
+	}
+
+	ans := diffrunner{cfg: cfg}
+
+	if cfg.Dsn != "" {
+		conn, err := sql.Open("pgx", cfg.Dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := conn.Ping(); err != nil {
+			_ = conn.Close()
+
+			return nil, err
+		}
+
+		ans.conn = conn
+	}
+
+	return &ans, nil
+}
+
+func (d *diffrunner) Run(ctx context.Context) error {
+	oldPlaces, err := d.load(ctx, d.cfg.DiffOld)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", d.cfg.DiffOld, err)
+	}
+
+	newPlaces, err := d.load(ctx, d.cfg.DiffNew)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", d.cfg.DiffNew, err)
+	}
+
+	report := diff.Compare(oldPlaces, newPlaces)
+
+	var out io.Writer = os.Stdout
+
+	if d.cfg.ResultsFile != "stdout" {
+		f, err := os.Create(d.cfg.ResultsFile)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		out = f
+	}
+
+	if d.cfg.JSON {
+		err = diff.WriteJSON(out, &report)
+	} else {
+		err = diff.WriteCSV(out, &report)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	logger.Info("crawls compared", "added", report.Added, "removed", report.Removed, "changed", report.Changed)
+
+	return nil
+}
+
+func (d *diffrunner) Close(context.Context) error {
+	if d.conn != nil {
+		return d.conn.Close()
+	}
+
+	return nil
+}
+
+func (d *diffrunner) load(ctx context.Context, source string) ([]diff.Place, error) {
+	if d.conn == nil {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+
+		defer f.Close()
+
+		return diff.ReadPlaces(f)
+	}
+
+	from, to, err := parseRange(source)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := postgres.LoadCrawl(ctx, d.conn, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	places := make([]diff.Place, 0, len(entries))
+	for _, e := range entries {
+		places = append(places, diff.NewPlace(e))
+	}
+
+	return places, nil
+}
+
+// parseRange parses a FROM..TO range of dates or RFC 3339 times. Either
+// side may be empty.
+func parseRange(s string) (from, to time.Time, err error) {
+	fromStr, toStr, ok := strings.Cut(s, "..")
+	if !ok {
+		return from, to, fmt.Errorf("invalid time range %q, use FROM..TO", s)
+	}
+
+	if from, err = parseTime(fromStr); err != nil {
+		return from, to, err
+	}
+
+	to, err = parseTime(toStr)
+
+	return from, to, err
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
@@ -1,20 +1,31 @@
 package filerunner
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/gosom/google-maps-scraper/common/logger"
 	"github.com/gosom/google-maps-scraper/deduper"
 	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/geo"
+	"github.com/gosom/google-maps-scraper/gmaps"
 	"github.com/gosom/google-maps-scraper/leadsdb"
+	"github.com/gosom/google-maps-scraper/parquetwriter"
+	"github.com/gosom/google-maps-scraper/proxypool"
+	"github.com/gosom/google-maps-scraper/ratelimit"
 	"github.com/gosom/google-maps-scraper/runner"
 	"github.com/gosom/google-maps-scraper/tlmt"
 	"github.com/gosom/scrapemate"
+	memprovider "github.com/gosom/scrapemate/adapters/providers/memory"
 	"github.com/gosom/scrapemate/adapters/writers/csvwriter"
 	"github.com/gosom/scrapemate/adapters/writers/jsonwriter"
 	"github.com/gosom/scrapemate/scrapemateapp"
@@ -26,6 +37,13 @@ type fileRunner struct {
 	writers []scrapemate.ResultWriter
 	app     *scrapemateapp.ScrapemateApp
 	outfile *os.File
+	journal *journal
+
+	reviewsFile *os.File
+	reviewState *reviewState
+
+	proxies *proxypool.Gateway
+	dedup   deduper.Deduper
 }
 
 func New(cfg *runner.Config) (runner.Runner, error) {
@@ -33,6 +51,18 @@ func New(cfg *runner.Config) (runner.Runner, error) {
 		return nil, fmt.Errorf("%w: %d", runner.ErrInvalidRunMode, cfg.RunMode)
 	}
 
+	if cfg.Resume && (cfg.CustomWriter != "" || cfg.LeadsDBAPIKey != "") {
+		return nil, errors.New("resume is only supported when writing to a results file")
+	}
+
+	if cfg.ExportReviews && (cfg.ResultsFile == "stdout" || cfg.CustomWriter != "" || cfg.LeadsDBAPIKey != "") {
+		return nil, errors.New("exporting reviews is only supported when writing to a results file")
+	}
+
+	if cfg.IncrementalReviews && cfg.ReviewsStateFile == "" && cfg.ResultsFile == "stdout" {
+		return nil, errors.New("incremental reviews need -reviews-state when writing to stdout")
+	}
+
 	ans := &fileRunner{
 		cfg: cfg,
 	}
@@ -41,6 +71,22 @@ func New(cfg *runner.Config) (runner.Runner, error) {
 		return nil, err
 	}
 
+	if cfg.IncrementalReviews {
+		var err error
+
+		ans.reviewState, err = openReviewState(cmp.Or(cfg.ReviewsStateFile, cfg.ResultsFile+reviewStateSuffix))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dedup, err := runner.NewDeduper(cfg, filepath.Base(cfg.InputFile))
+	if err != nil {
+		return nil, err
+	}
+
+	ans.dedup = dedup
+
 	if err := ans.setWriters(); err != nil {
 		return nil, err
 	}
@@ -73,27 +119,52 @@ func (r *fileRunner) Run(ctx context.Context) (err error) {
 		_ = runner.Telemetry().Send(ctx, evt)
 	}()
 
-	dedup := deduper.New()
 	exitMonitor := exiter.New()
+	backoff := runner.NewBackoff(r.cfg, r.proxies, "")
+	limiter := runner.NewRateLimiter(r.cfg)
+
+	if r.journal == nil {
+		seedJobs, err = runner.CreateSeedJobs(
+			r.cfg.FastMode,
+			r.cfg.LangCode,
+			r.input,
+			r.cfg.InputFormat,
+			r.cfg.MaxDepth,
+			r.cfg.Email,
+			r.cfg.GeoCoordinates,
+			r.cfg.Zoom,
+			r.cfg.Radius,
+			r.dedup,
+			exitMonitor,
+			r.cfg.ExtraReviews,
+			r.cfg.ReviewOptions,
+			0,
+			backoff,
+			limiter,
+			nil,
+		)
+	} else {
+		seedJobs, err = r.createJournaledSeedJobs(ctx, r.dedup, exitMonitor, backoff, limiter)
+	}
 
-	seedJobs, err = runner.CreateSeedJobs(
-		r.cfg.FastMode,
-		r.cfg.LangCode,
-		r.input,
-		r.cfg.MaxDepth,
-		r.cfg.Email,
-		r.cfg.GeoCoordinates,
-		r.cfg.Zoom,
-		r.cfg.Radius,
-		dedup,
-		exitMonitor,
-		r.cfg.ExtraReviews,
-		0,
-	)
 	if err != nil {
 		return err
 	}
 
+	if len(seedJobs) == 0 && r.cfg.Resume {
+		logger.Info("all queries finished in a previous run, nothing to resume", "results", r.cfg.ResultsFile)
+
+		return nil
+	}
+
+	if r.reviewState != nil {
+		for _, job := range seedJobs {
+			if j, ok := job.(*gmaps.GmapJob); ok {
+				gmaps.WithReviewStore(r.reviewState)(j)
+			}
+		}
+	}
+
 	exitMonitor.SetSeedCount(len(seedJobs))
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -108,7 +179,95 @@ func (r *fileRunner) Run(ctx context.Context) (err error) {
 	return err
 }
 
+// createJournaledSeedJobs creates the seed jobs with one exit monitor per
+// query so finished queries are recorded in the journal. When resuming,
+// queries that finished before are skipped and the places already written
+// are added to the deduper.
+func (r *fileRunner) createJournaledSeedJobs(ctx context.Context, dedup deduper.Deduper, exitMonitor exiter.Exiter, backoff *gmaps.Backoff, limiter *ratelimit.Limiter) ([]scrapemate.IJob, error) {
+	specs, err := runner.ParseQueries(r.input, r.cfg.InputFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range r.journal.placeKeys() {
+		dedup.AddIfNotExists(ctx, key)
+	}
+
+	var seedJobs []scrapemate.IJob
+
+	for i := range specs {
+		key := specs[i].Key()
+
+		if r.journal.seedDone(key) {
+			continue
+		}
+
+		tracker := r.journal.track(key, exitMonitor)
+
+		jobs, err := runner.CreateSeedJobsFromQueries(
+			r.cfg.FastMode,
+			r.cfg.LangCode,
+			specs[i:i+1],
+			r.cfg.MaxDepth,
+			r.cfg.Email,
+			r.cfg.GeoCoordinates,
+			r.cfg.Zoom,
+			r.cfg.Radius,
+			dedup,
+			tracker,
+			r.cfg.ExtraReviews,
+			r.cfg.ReviewOptions,
+			0,
+			backoff,
+			limiter,
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(jobs) == 0 {
+			// e.g. a fast mode query without coordinates, it is done
+			if err := r.journal.addSeed(key); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		tracker.addSeeds(len(jobs))
+
+		seedJobs = append(seedJobs, jobs...)
+	}
+
+	return seedJobs, nil
+}
+
 func (r *fileRunner) Close(context.Context) error {
+	if r.proxies != nil {
+		runner.LogProxyStats(r.proxies)
+
+		_ = r.proxies.Close()
+	}
+
+	if r.journal != nil {
+		_ = r.journal.Close()
+	}
+
+	if r.reviewsFile != nil {
+		_ = r.reviewsFile.Close()
+	}
+
+	if r.reviewState != nil {
+		if err := r.reviewState.Close(); err != nil {
+			logger.Error("failed to write the reviews state", "path", r.reviewState.path, "error", err)
+		}
+	}
+
+	if r.dedup != nil {
+		_ = deduper.Close(r.dedup)
+	}
+
 	if r.app != nil {
 		return r.app.Close()
 	}
@@ -157,9 +316,9 @@ func (r *fileRunner) setWriters() error {
 			return err
 		}
 
-		r.writers = append(r.writers, customWriter)
+		r.writers = append(r.writers, r.afterWrite(customWriter))
 	case r.cfg.LeadsDBAPIKey != "":
-		r.writers = append(r.writers, leadsdb.New(r.cfg.LeadsDBAPIKey))
+		r.writers = append(r.writers, r.afterWrite(leadsdb.New(r.cfg.LeadsDBAPIKey)))
 	default:
 		var resultsWriter io.Writer
 
@@ -167,7 +326,7 @@ func (r *fileRunner) setWriters() error {
 		case "stdout":
 			resultsWriter = os.Stdout
 		default:
-			f, err := os.Create(r.cfg.ResultsFile)
+			f, err := openResultsFile(r.cfg.ResultsFile, r.cfg.Resume)
 			if err != nil {
 				return err
 			}
@@ -175,20 +334,79 @@ func (r *fileRunner) setWriters() error {
 			r.outfile = f
 
 			resultsWriter = r.outfile
+
+			r.journal, err = openJournal(r.cfg.ResultsFile+journalSuffix, r.cfg.Resume)
+			if err != nil {
+				return err
+			}
+
+			// the header is already in the file we append to
+			if r.cfg.Format == runner.FormatCSV {
+				if info, err := f.Stat(); err == nil && info.Size() > 0 {
+					resultsWriter = &skipFirstLineWriter{w: f}
+				}
+			}
 		}
 
-		csvWriter := csvwriter.NewCsvWriter(csv.NewWriter(resultsWriter))
+		var w scrapemate.ResultWriter
 
-		if r.cfg.JSON {
-			r.writers = append(r.writers, jsonwriter.NewJSONWriter(resultsWriter))
-		} else {
-			r.writers = append(r.writers, csvWriter)
+		switch r.cfg.Format {
+		case runner.FormatJSON:
+			w = runner.WriteThrough(jsonwriter.NewJSONWriter(resultsWriter))
+		case geo.FormatGeoJSON, geo.FormatKML:
+			var err error
+
+			w, err = geo.NewWriter(r.cfg.Format, resultsWriter, nil)
+			if err != nil {
+				return err
+			}
+		case runner.FormatParquet:
+			w = parquetwriter.New(resultsWriter)
+		default:
+			w = runner.WriteThrough(csvwriter.NewCsvWriter(csv.NewWriter(resultsWriter)))
+		}
+
+		// the checkpoints share one runner.AfterWrite over the results
+		// writer, and the reviews writer wraps them so the reviews of a
+		// place are written before the place
+		w = r.afterWrite(w)
+
+		if r.journal != nil {
+			w = r.journal.writer(w)
 		}
+
+		if r.cfg.ExportReviews {
+			f, err := openResultsFile(reviewsFileName(r.cfg.ResultsFile, r.cfg.Format), r.cfg.Resume)
+			if err != nil {
+				return err
+			}
+
+			r.reviewsFile = f
+
+			info, err := f.Stat()
+			if err != nil {
+				return err
+			}
+
+			w = newReviewsWriter(w, f, r.cfg.JSON, info.Size() > 0)
+		}
+
+		r.writers = append(r.writers, w)
 	}
 
 	return nil
 }
 
+// afterWrite wraps the results writer to keep the review checkpoints and
+// the dedup keys of the places once they have been written.
+func (r *fileRunner) afterWrite(w scrapemate.ResultWriter) scrapemate.ResultWriter {
+	if r.reviewState != nil {
+		w = r.reviewState.writer(w)
+	}
+
+	return runner.DedupWriter(r.dedup, w)
+}
+
 func (r *fileRunner) setApp() error {
 	opts := []func(*scrapemateapp.Config) error{
 		// scrapemateapp.WithCache("leveldb", "cache"),
@@ -196,9 +414,18 @@ func (r *fileRunner) setApp() error {
 		scrapemateapp.WithExitOnInactivity(r.cfg.ExitOnInactivityDuration),
 	}
 
-	if len(r.cfg.Proxies) > 0 {
+	gw, err := runner.NewProxyGateway(r.cfg)
+	if err != nil {
+		return err
+	}
+
+	if gw != nil {
+		r.proxies = gw
+
+		country := proxypool.Country(r.cfg.LangCode, r.cfg.GeoCoordinates)
+
 		opts = append(opts,
-			scrapemateapp.WithProxies(r.cfg.Proxies),
+			scrapemateapp.WithProxies([]string{gw.CountryURL("", country)}),
 		)
 	}
 
@@ -212,7 +439,10 @@ func (r *fileRunner) setApp() error {
 			opts = append(opts, scrapemateapp.WithJS(scrapemateapp.DisableImages()))
 		}
 	} else {
-		opts = append(opts, scrapemateapp.WithStealth("firefox"))
+		opts = append(opts,
+			scrapemateapp.WithStealth("firefox"),
+			scrapemateapp.WithProvider(gmaps.NewWaitProvider(memprovider.New())),
+		)
 	}
 
 	if !r.cfg.DisablePageReuse {
@@ -237,3 +467,39 @@ func (r *fileRunner) setApp() error {
 
 	return nil
 }
+
+// openResultsFile truncates the results file, or appends to it when
+// resuming.
+func openResultsFile(path string, resume bool) (*os.File, error) {
+	if !resume {
+		return os.Create(path)
+	}
+
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// skipFirstLineWriter drops everything up to and including the first new
+// line written to it.
+type skipFirstLineWriter struct {
+	w       io.Writer
+	skipped bool
+}
+
+func (s *skipFirstLineWriter) Write(p []byte) (int, error) {
+	if s.skipped {
+		return s.w.Write(p)
+	}
+
+	idx := bytes.IndexByte(p, '\n')
+	if idx == -1 {
+		return len(p), nil
+	}
+
+	s.skipped = true
+
+	if _, err := s.w.Write(p[idx+1:]); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
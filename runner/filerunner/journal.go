@@ -0,0 +1,348 @@
+package filerunner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gosom/scrapemate"
+
+	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/runner"
+)
+
+const (
+	journalSuffix = ".journal"
+
+	// journalTick is how long a finished seed waits to be journaled. Like
+	// the exit monitor, a job counts as done before its results reach the
+	// writer, so the seed waits a tick for them to be written.
+	journalTick = time.Second
+)
+
+// journalRecord is one line of the checkpoint journal. Exactly one of the
+// fields is set.
+type journalRecord struct {
+	Seed  string `json:"seed,omitempty"`
+	Place string `json:"place,omitempty"`
+}
+
+// journal is an append-only log of the seed queries that finished and the
+// places that were written to the results file.
+type journal struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+
+	seeds  map[string]bool
+	places []string
+
+	trackers map[string]*seedTracker
+	pending  map[string]time.Time
+}
+
+// openJournal opens the journal at path. When resume is set the existing
+// records are loaded and new ones are appended, otherwise the journal is
+// truncated.
+func openJournal(path string, resume bool) (*journal, error) {
+	j := journal{
+		seeds:    map[string]bool{},
+		trackers: map[string]*seedTracker{},
+		pending:  map[string]time.Time{},
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+
+	if resume {
+		if err := j.load(path); err != nil {
+			return nil, err
+		}
+
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if resume {
+		if err := endLastLine(f); err != nil {
+			_ = f.Close()
+
+			return nil, err
+		}
+	}
+
+	j.f = f
+	j.enc = json.NewEncoder(f)
+
+	return &j, nil
+}
+
+// endLastLine ends a last line that was cut off, so the records appended
+// after it are not lost with it.
+func endLastLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	r, err := os.Open(f.Name())
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	last := make([]byte, 1)
+	if _, err := r.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+
+	if last[0] == '\n' {
+		return nil
+	}
+
+	_, err = f.Write([]byte{'\n'})
+
+	return err
+}
+
+func (j *journal) load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var rec journalRecord
+
+		// the last line is cut off when the process was killed mid write
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+
+		switch {
+		case rec.Seed != "":
+			j.seeds[rec.Seed] = true
+		case rec.Place != "":
+			j.places = append(j.places, rec.Place)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// seedDone reports whether the seed query finished in a previous run.
+func (j *journal) seedDone(key string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.seeds[key]
+}
+
+// placeKeys returns the deduplication keys of the places written in
+// previous runs.
+func (j *journal) placeKeys() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.places
+}
+
+// track returns the exit monitor for the jobs of the seed query with the
+// given key. Queries with the same key share one tracker.
+func (j *journal) track(key string, parent exiter.Exiter) *seedTracker {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	t, ok := j.trackers[key]
+	if !ok {
+		t = &seedTracker{key: key, parent: parent, j: j}
+		j.trackers[key] = t
+	}
+
+	return t
+}
+
+func (j *journal) seedFinished(key string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.pending[key] = time.Now()
+}
+
+// addSeed records a seed that has nothing left to write, such as a query
+// that created no jobs.
+func (j *journal) addSeed(key string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.writeSeed(key)
+}
+
+// commitSeeds records the seeds that finished at least one tick ago, or
+// all of them once every result has been written.
+func (j *journal) commitSeeds(all bool) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for key, finishedAt := range j.pending {
+		if !all && time.Since(finishedAt) < journalTick {
+			continue
+		}
+
+		if err := j.writeSeed(key); err != nil {
+			return err
+		}
+
+		delete(j.pending, key)
+	}
+
+	return nil
+}
+
+func (j *journal) writeSeed(key string) error {
+	if err := j.enc.Encode(journalRecord{Seed: key}); err != nil {
+		return err
+	}
+
+	j.seeds[key] = true
+
+	return nil
+}
+
+func (j *journal) addPlaces(keys []string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, key := range keys {
+		if err := j.enc.Encode(journalRecord{Place: key}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (j *journal) Close() error {
+	return j.f.Close()
+}
+
+// writer wraps the results writer so the places are journaled once they
+// have been written, see runner.AfterWrite. The seeds that finished are
+// journaled along with them, and all of them once the writer is done.
+func (j *journal) writer(next scrapemate.ResultWriter) scrapemate.ResultWriter {
+	return &journalWriter{j: j, next: runner.AfterWrite(next, j.written)}
+}
+
+type journalWriter struct {
+	j    *journal
+	next scrapemate.ResultWriter
+}
+
+func (w *journalWriter) Run(ctx context.Context, in <-chan scrapemate.Result) error {
+	if err := w.next.Run(ctx, in); err != nil {
+		return err
+	}
+
+	return w.j.commitSeeds(true)
+}
+
+func (j *journal) written(result scrapemate.Result) error {
+	if err := j.addPlaces(runner.PlaceKeys(result)); err != nil {
+		return err
+	}
+
+	return j.commitSeeds(false)
+}
+
+// seedTracker is the exit monitor of the jobs of one seed query. It
+// forwards everything to the exit monitor of the run and reports the seed
+// to the journal once all of its jobs are done.
+type seedTracker struct {
+	key    string
+	parent exiter.Exiter
+	j      *journal
+
+	mu              sync.Mutex
+	seedCount       int
+	seedCompleted   int
+	placesFound     int
+	placesCompleted int
+	done            bool
+}
+
+var _ exiter.Exiter = (*seedTracker)(nil)
+
+func (t *seedTracker) addSeeds(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seedCount += n
+}
+
+func (t *seedTracker) SetSeedCount(val int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seedCount = val
+}
+
+func (t *seedTracker) SetCancelFunc(context.CancelFunc) {}
+
+func (t *seedTracker) IncrSeedCompleted(val int) {
+	t.parent.IncrSeedCompleted(val)
+	t.update(func() { t.seedCompleted += val })
+}
+
+func (t *seedTracker) IncrPlacesFound(val int) {
+	t.parent.IncrPlacesFound(val)
+	t.update(func() { t.placesFound += val })
+}
+
+func (t *seedTracker) IncrPlacesCompleted(val int) {
+	t.parent.IncrPlacesCompleted(val)
+	t.update(func() { t.placesCompleted += val })
+}
+
+func (t *seedTracker) IncrErrors(val int) {
+	t.parent.IncrErrors(val)
+}
+
+func (t *seedTracker) IncrBlocks(val int) {
+	t.parent.IncrBlocks(val)
+}
+
+func (t *seedTracker) Run(context.Context) {}
+
+func (t *seedTracker) update(fn func()) {
+	t.mu.Lock()
+
+	fn()
+
+	finished := !t.done && t.seedCompleted >= t.seedCount && t.placesFound == t.placesCompleted
+	if finished {
+		t.done = true
+	}
+
+	t.mu.Unlock()
+
+	if finished {
+		t.j.seedFinished(t.key)
+	}
+}
@@ -0,0 +1,161 @@
+package filerunner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+type collectWriter struct {
+	results []scrapemate.Result
+}
+
+func (w *collectWriter) Run(_ context.Context, in <-chan scrapemate.Result) error {
+	for result := range in {
+		w.results = append(w.results, result)
+	}
+
+	return nil
+}
+
+func Test_JournalResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.csv"+journalSuffix)
+
+	j, err := openJournal(path, false)
+	require.NoError(t, err)
+
+	tracker := j.track("coffee", exiter.New())
+	tracker.addSeeds(1)
+
+	// a query without jobs is done at once
+	require.NoError(t, j.addSeed("empty"))
+
+	in := make(chan scrapemate.Result, 2)
+	next := &collectWriter{}
+
+	place := gmaps.NewPlaceJob("seed", "en", "https://www.google.com/maps/place/a", false, false)
+	in <- scrapemate.Result{Job: place, Data: &gmaps.Entry{Title: "a"}}
+
+	tracker.IncrPlacesFound(1)
+	tracker.IncrSeedCompleted(1)
+	tracker.IncrPlacesCompleted(1)
+
+	close(in)
+
+	require.NoError(t, j.writer(next).Run(t.Context(), in))
+	require.Len(t, next.results, 1)
+	require.NoError(t, j.Close())
+
+	// the process was killed while writing the next record
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+
+	_, err = f.WriteString(`{"seed":"cu`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	j, err = openJournal(path, true)
+	require.NoError(t, err)
+
+	require.True(t, j.seedDone("coffee"))
+	require.True(t, j.seedDone("empty"))
+	require.False(t, j.seedDone("cu"))
+	require.Equal(t, []string{place.GetURL()}, j.placeKeys())
+
+	require.NoError(t, j.addSeed("bakery"))
+	require.NoError(t, j.Close())
+
+	// records are appended on resume
+	j, err = openJournal(path, true)
+	require.NoError(t, err)
+
+	require.True(t, j.seedDone("coffee"))
+	require.True(t, j.seedDone("bakery"))
+	require.NoError(t, j.Close())
+
+	// and dropped on a fresh run
+	j, err = openJournal(path, false)
+	require.NoError(t, err)
+
+	require.False(t, j.seedDone("coffee"))
+	require.Empty(t, j.placeKeys())
+	require.NoError(t, j.Close())
+}
+
+func Test_JournalUnfinishedSeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.csv"+journalSuffix)
+
+	j, err := openJournal(path, false)
+	require.NoError(t, err)
+
+	tracker := j.track("coffee", exiter.New())
+	tracker.addSeeds(1)
+	tracker.IncrPlacesFound(2)
+	tracker.IncrSeedCompleted(1)
+	tracker.IncrPlacesCompleted(1)
+
+	in := make(chan scrapemate.Result)
+	close(in)
+
+	require.NoError(t, j.writer(&collectWriter{}).Run(t.Context(), in))
+	require.NoError(t, j.Close())
+
+	j, err = openJournal(path, true)
+	require.NoError(t, err)
+
+	defer j.Close()
+
+	require.False(t, j.seedDone("coffee"))
+}
+
+func Test_SkipFirstLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := &skipFirstLineWriter{w: &buf}
+
+	for _, chunk := range []string{"ti", "tle,url", "\nfirst,", "a\n", "second,b\n"} {
+		n, err := w.Write([]byte(chunk))
+		require.NoError(t, err)
+		require.Equal(t, len(chunk), n)
+	}
+
+	require.Equal(t, "first,a\nsecond,b\n", buf.String())
+}
+
+func Test_OpenResultsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.csv")
+
+	f, err := openResultsFile(path, false)
+	require.NoError(t, err)
+
+	_, err = f.WriteString("header\nfirst\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	f, err = openResultsFile(path, true)
+	require.NoError(t, err)
+
+	_, err = f.WriteString("second\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "header\nfirst\nsecond\n", string(data))
+
+	f, err = openResultsFile(path, false)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Empty(t, data)
+}
@@ -0,0 +1,118 @@
+package filerunner
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gosom/scrapemate"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/runner"
+)
+
+// reviewsFileName returns the path of the reviews export for the results
+// file, output.csv becomes output_reviews.csv. The reviews of GeoJSON and
+// KML results, which have no location of their own, and of Parquet results
+// go to a CSV file.
+func reviewsFileName(resultsFile, format string) string {
+	ext := filepath.Ext(resultsFile)
+	base := strings.TrimSuffix(resultsFile, ext)
+
+	if format != runner.FormatCSV && format != runner.FormatJSON {
+		ext = ".csv"
+	}
+
+	return base + "_reviews" + ext
+}
+
+// reviewsWriter writes one row per review to the reviews file and passes
+// the results on to the places writer.
+type reviewsWriter struct {
+	next       scrapemate.ResultWriter
+	w          io.Writer
+	json       bool
+	headerDone bool
+}
+
+func newReviewsWriter(next scrapemate.ResultWriter, w io.Writer, asJSON, appending bool) scrapemate.ResultWriter {
+	return &reviewsWriter{
+		next:       next,
+		w:          w,
+		json:       asJSON,
+		headerDone: appending,
+	}
+}
+
+func (rw *reviewsWriter) Run(ctx context.Context, in <-chan scrapemate.Result) error {
+	out := make(chan scrapemate.Result)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- rw.next.Run(ctx, out)
+	}()
+
+	csvWriter := csv.NewWriter(rw.w)
+	enc := json.NewEncoder(rw.w)
+
+	for result := range in {
+		for _, entry := range resultEntries(result.Data) {
+			rows := entry.ReviewRows()
+
+			for i := range rows {
+				var err error
+
+				switch {
+				case rw.json:
+					err = enc.Encode(&rows[i])
+				case !rw.headerDone:
+					rw.headerDone = true
+
+					if err = csvWriter.Write(rows[i].CsvHeaders()); err == nil {
+						err = csvWriter.Write(rows[i].CsvRow())
+					}
+				default:
+					err = csvWriter.Write(rows[i].CsvRow())
+				}
+
+				if err != nil {
+					close(out)
+
+					return err
+				}
+			}
+		}
+
+		csvWriter.Flush()
+
+		if err := csvWriter.Error(); err != nil {
+			close(out)
+
+			return err
+		}
+
+		select {
+		case out <- result:
+		case err := <-errc:
+			return err
+		}
+	}
+
+	close(out)
+
+	return <-errc
+}
+
+func resultEntries(data any) []*gmaps.Entry {
+	switch v := data.(type) {
+	case *gmaps.Entry:
+		return []*gmaps.Entry{v}
+	case []*gmaps.Entry:
+		return v
+	default:
+		return nil
+	}
+}
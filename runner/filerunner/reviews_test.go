@@ -0,0 +1,104 @@
+package filerunner
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func reviewResults() []scrapemate.Result {
+	return []scrapemate.Result{
+		{Data: &gmaps.Entry{
+			DataID:      "0x1",
+			Cid:         "1",
+			UserReviews: []gmaps.Review{{ID: "a1", Name: "Ana", Rating: 5}},
+			UserReviewsExtended: []gmaps.Review{
+				{ID: "a1", Name: "Ana", Rating: 5},
+				{ID: "a2", Name: "Ben", Rating: 2},
+			},
+		}},
+		{Data: &gmaps.Entry{DataID: "0x2", Cid: "2"}},
+		{Data: []*gmaps.Entry{
+			{DataID: "0x3", Cid: "3", UserReviews: []gmaps.Review{{ID: "c1", Name: "Cara", Rating: 4}}},
+		}},
+	}
+}
+
+func runReviewsWriter(t *testing.T, asJSON, appending bool) (string, []scrapemate.Result) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	next := &collectWriter{}
+
+	in := make(chan scrapemate.Result)
+
+	go func() {
+		defer close(in)
+
+		for _, result := range reviewResults() {
+			in <- result
+		}
+	}()
+
+	require.NoError(t, newReviewsWriter(next, &buf, asJSON, appending).Run(t.Context(), in))
+
+	return buf.String(), next.results
+}
+
+func Test_ReviewsWriterCSV(t *testing.T) {
+	out, results := runReviewsWriter(t, false, false)
+
+	// every result goes on to the places writer
+	require.Len(t, results, 3)
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	require.Equal(t, (&gmaps.ReviewRow{}).CsvHeaders(), records[0])
+
+	var ids []string
+	for _, record := range records[1:] {
+		ids = append(ids, record[0]+"/"+record[2])
+	}
+
+	require.Equal(t, []string{"0x1/a1", "0x1/a2", "0x3/c1"}, ids)
+
+	// a resumed run appends the rows without a header
+	out, _ = runReviewsWriter(t, false, true)
+
+	records, err = csv.NewReader(strings.NewReader(out)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, "a1", records[0][2])
+}
+
+func Test_ReviewsWriterJSON(t *testing.T) {
+	out, results := runReviewsWriter(t, true, false)
+	require.Len(t, results, 3)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+
+	var row gmaps.ReviewRow
+
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &row))
+	require.Equal(t, "0x3", row.DataID)
+	require.Equal(t, "c1", row.ReviewID)
+	require.Equal(t, "Cara", row.Author)
+}
+
+func Test_ReviewsFileName(t *testing.T) {
+	require.Equal(t, "out/results_reviews.csv", reviewsFileName("out/results.csv", "csv"))
+	require.Equal(t, "results_reviews.json", reviewsFileName("results.json", "json"))
+	require.Equal(t, "results_reviews.csv", reviewsFileName("results.geojson", "geojson"))
+	require.Equal(t, "results_reviews.csv", reviewsFileName("results.parquet", "parquet"))
+}
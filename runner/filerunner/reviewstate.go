@@ -0,0 +1,169 @@
+package filerunner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gosom/scrapemate"
+
+	"github.com/gosom/google-maps-scraper/common/logger"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/runner"
+)
+
+const (
+	reviewStateSuffix = ".reviews_state.json"
+
+	// reviewStateInterval is how often the state file is rewritten during
+	// a run.
+	reviewStateInterval = 30 * time.Second
+)
+
+// reviewState is the review store of the file runner. It keeps the review
+// checkpoint of each place, by data id, in a JSON file. A checkpoint is
+// only kept once the place has been written to the results, so a run that
+// did not end cleanly fetches some reviews again rather than missing any.
+type reviewState struct {
+	path string
+
+	mu      sync.Mutex
+	places  map[string]gmaps.ReviewCheckpoint
+	pending map[string]gmaps.ReviewCheckpoint
+	dirty   bool
+	written time.Time
+}
+
+var _ gmaps.ReviewStore = (*reviewState)(nil)
+
+func openReviewState(path string) (*reviewState, error) {
+	s := reviewState{
+		path:    path,
+		places:  map[string]gmaps.ReviewCheckpoint{},
+		pending: map[string]gmaps.ReviewCheckpoint{},
+		written: time.Now(),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &s, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &s.places); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (s *reviewState) Checkpoint(_ context.Context, dataID string) (gmaps.ReviewCheckpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkpoint(dataID), nil
+}
+
+func (s *reviewState) Save(_ context.Context, dataID string, reviews []gmaps.Review) error {
+	if dataID == "" || len(reviews) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[dataID] = s.checkpoint(dataID).Merge(reviews)
+
+	return nil
+}
+
+func (s *reviewState) checkpoint(dataID string) gmaps.ReviewCheckpoint {
+	if c, ok := s.pending[dataID]; ok {
+		return c
+	}
+
+	return s.places[dataID]
+}
+
+// commit keeps the checkpoints of the places, which have been written, and
+// rewrites the state file when it was last written an interval ago.
+func (s *reviewState) commit(dataIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range dataIDs {
+		if c, ok := s.pending[id]; ok {
+			s.places[id] = c
+			s.dirty = true
+
+			delete(s.pending, id)
+		}
+	}
+
+	if !s.dirty || time.Since(s.written) < reviewStateInterval {
+		return nil
+	}
+
+	return s.write()
+}
+
+// Close writes the state file.
+func (s *reviewState) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+
+	return s.write()
+}
+
+// write replaces the state file, through a temporary file so a crash does
+// not leave half of it. The caller holds the lock.
+func (s *reviewState) write() error {
+	data, err := json.Marshal(s.places)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.dirty = false
+	s.written = time.Now()
+
+	return nil
+}
+
+// writer wraps the results writer so the checkpoints of the places are
+// kept once the places have been written, see runner.AfterWrite.
+func (s *reviewState) writer(next scrapemate.ResultWriter) scrapemate.ResultWriter {
+	return runner.AfterWrite(next, func(result scrapemate.Result) error {
+		var dataIDs []string
+
+		for _, entry := range resultEntries(result.Data) {
+			dataIDs = append(dataIDs, entry.DataID)
+		}
+
+		// the state is written again on close
+		if err := s.commit(dataIDs); err != nil {
+			logger.Warn("writing the reviews state failed", "path", s.path, "error", err)
+		}
+
+		return nil
+	})
+}
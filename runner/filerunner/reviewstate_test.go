@@ -0,0 +1,79 @@
+package filerunner
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+func Test_ReviewStateKeepsWrittenPlaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.csv"+reviewStateSuffix)
+
+	s, err := openReviewState(path)
+	require.NoError(t, err)
+
+	posted := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.Save(t.Context(), "0x1", []gmaps.Review{{ID: "a1", Time: posted}}))
+	require.NoError(t, s.Save(t.Context(), "0x2", []gmaps.Review{{ID: "b1", Time: posted}}))
+
+	// the run sees its own checkpoints
+	c, err := s.Checkpoint(t.Context(), "0x2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b1"}, c.IDs)
+
+	// only the place whose result was written is kept
+	in := make(chan scrapemate.Result, 1)
+	in <- scrapemate.Result{Data: &gmaps.Entry{DataID: "0x1"}}
+	close(in)
+
+	require.NoError(t, s.writer(&collectWriter{}).Run(t.Context(), in))
+	require.NoError(t, s.Close())
+
+	s, err = openReviewState(path)
+	require.NoError(t, err)
+
+	c, err = s.Checkpoint(t.Context(), "0x1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a1"}, c.IDs)
+	require.Equal(t, posted, c.Latest)
+
+	c, err = s.Checkpoint(t.Context(), "0x2")
+	require.NoError(t, err)
+	require.True(t, c.IsZero())
+}
+
+func Test_ReviewStateWritesPeriodically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.csv"+reviewStateSuffix)
+
+	s, err := openReviewState(path)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Save(t.Context(), "0x1", []gmaps.Review{{ID: "a1"}}))
+	require.NoError(t, s.commit([]string{"0x1"}))
+
+	// not yet, the state was written less than an interval ago
+	require.NoFileExists(t, path)
+
+	s.written = time.Now().Add(-reviewStateInterval)
+
+	require.NoError(t, s.Save(t.Context(), "0x2", []gmaps.Review{{ID: "b1"}}))
+	require.NoError(t, s.commit([]string{"0x2"}))
+	require.FileExists(t, path)
+	require.NoFileExists(t, path+".tmp")
+
+	// the run was killed, the state has both places
+	s, err = openReviewState(path)
+	require.NoError(t, err)
+
+	for _, id := range []string{"0x1", "0x2"} {
+		c, err := s.Checkpoint(t.Context(), id)
+		require.NoError(t, err)
+		require.False(t, c.IsZero(), id)
+	}
+}
@@ -1,7 +1,7 @@
 package runner
 
 import (
-	"bufio"
+	"cmp"
 	"fmt"
 	"io"
 	"os"
@@ -10,9 +10,11 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/gosom/google-maps-scraper/budget"
 	"github.com/gosom/google-maps-scraper/deduper"
 	"github.com/gosom/google-maps-scraper/exiter"
 	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/ratelimit"
 	"github.com/gosom/scrapemate"
 )
 
@@ -20,6 +22,7 @@ func CreateSeedJobs(
 	fastmode bool,
 	langCode string,
 	r io.Reader,
+	inputFormat string,
 	maxDepth int,
 	email bool,
 	geoCoordinates string,
@@ -28,7 +31,56 @@ func CreateSeedJobs(
 	dedup deduper.Deduper,
 	exitMonitor exiter.Exiter,
 	extraReviews bool,
+	reviewOpts gmaps.ReviewOptions,
 	searchDelay int,
+	backoff *gmaps.Backoff,
+	limiter *ratelimit.Limiter,
+	share *budget.Share,
+) ([]scrapemate.IJob, error) {
+	specs, err := ParseQueries(r, inputFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateSeedJobsFromQueries(
+		fastmode,
+		langCode,
+		specs,
+		maxDepth,
+		email,
+		geoCoordinates,
+		zoom,
+		radius,
+		dedup,
+		exitMonitor,
+		extraReviews,
+		reviewOpts,
+		searchDelay,
+		backoff,
+		limiter,
+		share,
+	)
+}
+
+// CreateSeedJobsFromQueries is CreateSeedJobs for queries that were already
+// parsed.
+func CreateSeedJobsFromQueries(
+	fastmode bool,
+	langCode string,
+	specs []QuerySpec,
+	maxDepth int,
+	email bool,
+	geoCoordinates string,
+	zoom int,
+	radius float64,
+	dedup deduper.Deduper,
+	exitMonitor exiter.Exiter,
+	extraReviews bool,
+	reviewOpts gmaps.ReviewOptions,
+	searchDelay int,
+	backoff *gmaps.Backoff,
+	limiter *ratelimit.Limiter,
+	share *budget.Share,
 ) (jobs []scrapemate.IJob, err error) {
 	var lat, lon float64
 
@@ -59,43 +111,48 @@ func CreateSeedJobs(
 		}
 	}
 
-	scanner := bufio.NewScanner(r)
+	for i := range specs {
+		spec := &specs[i]
 
-	for scanner.Scan() {
-		query := strings.TrimSpace(scanner.Text())
-		if query == "" {
-			continue
-		}
+		queryLang := cmp.Or(spec.Lang, langCode)
+		queryDepth := cmp.Or(spec.Depth, maxDepth)
+		queryZoom := cmp.Or(spec.Zoom, zoom)
+		queryRadius := cmp.Or(spec.Radius, radius)
+		querySearchDelay := cmp.Or(spec.SearchDelay, searchDelay)
 
-		var id string
+		queryEmail := email
+		if spec.Email != nil {
+			queryEmail = *spec.Email
+		}
 
-		if before, after, ok := strings.Cut(query, "#!#"); ok {
-			query = strings.TrimSpace(before)
-			id = strings.TrimSpace(after)
+		queryExtraReviews := extraReviews
+		if spec.ExtraReviews != nil {
+			queryExtraReviews = *spec.ExtraReviews
 		}
 
-		// Parse per-query geo coordinates (format: query#!geo#lat,lon)
+		// Use per-query geo if available, otherwise global
 		queryLat, queryLon := lat, lon
 		queryGeo := geoCoordinates
-		hasPerQueryGeo := false
-
-		if before, after, ok := strings.Cut(query, "#!geo#"); ok {
-			query = strings.TrimSpace(before)
-			geoParts := strings.Split(strings.TrimSpace(after), ",")
-			if len(geoParts) == 2 {
-				if qlat, err := strconv.ParseFloat(geoParts[0], 64); err == nil {
-					if qlon, err := strconv.ParseFloat(geoParts[1], 64); err == nil {
-						queryLat = qlat
-						queryLon = qlon
-						queryGeo = geoParts[0] + "," + geoParts[1]
-						hasPerQueryGeo = true
-					}
-				}
+
+		if spec.HasGeo() {
+			queryLat, queryLon = *spec.Lat, *spec.Lon
+			queryGeo = spec.Geo()
+		}
+
+		if area := spec.GridArea(); area != nil {
+			if fastmode {
+				jobs = append(jobs, createGridSearchJobs(area, spec.Query, queryLang, queryDepth, queryZoom, dedup, exitMonitor, querySearchDelay, backoff, limiter, share)...)
+
+				continue
 			}
+
+			// normal mode scrolls the results instead, search around the center
+			center := area.Bounds.Center()
+			queryGeo = fmt.Sprintf("%f,%f", center.Lat, center.Lon)
 		}
 
 		// For FastMode: verify per-query coords are valid if global coords were not set
-		if fastmode && !hasPerQueryGeo && (lat == 0 && lon == 0) {
+		if fastmode && !spec.HasGeo() && (lat == 0 && lon == 0) {
 			continue // skip queries without valid coordinates in fast mode
 		}
 
@@ -112,28 +169,39 @@ func CreateSeedJobs(
 				opts = append(opts, gmaps.WithExitMonitor(exitMonitor))
 			}
 
-			if extraReviews {
-				opts = append(opts, gmaps.WithExtraReviews())
+			if queryExtraReviews {
+				opts = append(opts, gmaps.WithExtraReviews(), gmaps.WithReviewOptions(spec.ReviewOptions(reviewOpts)))
+			}
+
+			if querySearchDelay > 0 {
+				opts = append(opts, gmaps.WithSearchDelay(querySearchDelay))
+			}
+
+			if backoff != nil {
+				opts = append(opts, gmaps.WithBackoff(backoff))
+			}
+
+			if limiter != nil {
+				opts = append(opts, gmaps.WithRateLimiter(limiter))
 			}
 
-			if searchDelay > 0 {
-				opts = append(opts, gmaps.WithSearchDelay(searchDelay))
+			if share != nil {
+				opts = append(opts, gmaps.WithBudget(share))
 			}
 
-			// Use per-query geo if available, otherwise global
-			job = gmaps.NewGmapJob(id, langCode, query, maxDepth, email, queryGeo, zoom, opts...)
+			job = gmaps.NewGmapJob(spec.ID, queryLang, spec.Query, queryDepth, queryEmail, queryGeo, queryZoom, opts...)
 		} else {
 			jparams := gmaps.MapSearchParams{
 				Location: gmaps.MapLocation{
 					Lat:     queryLat,
 					Lon:     queryLon,
-					ZoomLvl: float64(zoom),
-					Radius:  radius,
+					ZoomLvl: float64(queryZoom),
+					Radius:  queryRadius,
 				},
-				Query:     query,
+				Query:     spec.Query,
 				ViewportW: 1920,
 				ViewportH: 450,
-				Hl:        langCode,
+				Hl:        queryLang,
 			}
 
 			opts := []gmaps.SearchJobOptions{}
@@ -146,13 +214,25 @@ func CreateSeedJobs(
 				opts = append(opts, gmaps.WithSearchJobExitMonitor(exitMonitor))
 			}
 
-			if searchDelay > 0 {
-				opts = append(opts, gmaps.WithSearchJobDelay(searchDelay))
+			if querySearchDelay > 0 {
+				opts = append(opts, gmaps.WithSearchJobDelay(querySearchDelay))
+			}
+
+			if backoff != nil {
+				opts = append(opts, gmaps.WithSearchJobBackoff(backoff))
+			}
+
+			if limiter != nil {
+				opts = append(opts, gmaps.WithSearchJobRateLimiter(limiter))
+			}
+
+			if share != nil {
+				opts = append(opts, gmaps.WithSearchJobBudget(share))
 			}
 
 			// Use depth as max pages for pagination (1 = no pagination, 2+ = paginate)
-			if maxDepth > 1 {
-				opts = append(opts, gmaps.WithSearchJobMaxPages(maxDepth))
+			if queryDepth > 1 {
+				opts = append(opts, gmaps.WithSearchJobMaxPages(queryDepth))
 			}
 
 			job = gmaps.NewSearchJob(&jparams, opts...)
@@ -161,7 +241,70 @@ func CreateSeedJobs(
 		jobs = append(jobs, job)
 	}
 
-	return jobs, scanner.Err()
+	return jobs, nil
+}
+
+// createGridSearchJobs creates one fast mode search per grid cell. Cells
+// that come back full are split further by the search jobs themselves.
+func createGridSearchJobs(
+	area *gmaps.GridArea,
+	query, langCode string,
+	maxDepth, zoom int,
+	dedup deduper.Deduper,
+	exitMonitor exiter.Exiter,
+	searchDelay int,
+	backoff *gmaps.Backoff,
+	limiter *ratelimit.Limiter,
+	share *budget.Share,
+) []scrapemate.IJob {
+	cells := gmaps.NewGridCells(area, zoom)
+	jobs := make([]scrapemate.IJob, 0, len(cells))
+
+	for i := range cells {
+		jparams := gmaps.MapSearchParams{
+			Location:  cells[i].Location(),
+			Query:     query,
+			ViewportW: 1920,
+			ViewportH: 450,
+			Hl:        langCode,
+		}
+
+		opts := []gmaps.SearchJobOptions{
+			gmaps.WithSearchJobGridCell(&cells[i]),
+		}
+
+		if dedup != nil {
+			opts = append(opts, gmaps.WithSearchJobDeduper(dedup))
+		}
+
+		if exitMonitor != nil {
+			opts = append(opts, gmaps.WithSearchJobExitMonitor(exitMonitor))
+		}
+
+		if searchDelay > 0 {
+			opts = append(opts, gmaps.WithSearchJobDelay(searchDelay))
+		}
+
+		if backoff != nil {
+			opts = append(opts, gmaps.WithSearchJobBackoff(backoff))
+		}
+
+		if limiter != nil {
+			opts = append(opts, gmaps.WithSearchJobRateLimiter(limiter))
+		}
+
+		if share != nil {
+			opts = append(opts, gmaps.WithSearchJobBudget(share))
+		}
+
+		if maxDepth > 1 {
+			opts = append(opts, gmaps.WithSearchJobMaxPages(maxDepth))
+		}
+
+		jobs = append(jobs, gmaps.NewSearchJob(&jparams, opts...))
+	}
+
+	return jobs
 }
 
 func LoadCustomWriter(pluginDir, pluginName string) (scrapemate.ResultWriter, error) {
@@ -16,6 +16,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 
 	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/gmaps"
 	"github.com/gosom/google-maps-scraper/runner"
 	"github.com/gosom/scrapemate"
 	"github.com/gosom/scrapemate/adapters/writers/csvwriter"
@@ -82,6 +83,7 @@ func (l *lambdaAwsRunner) handler(ctx context.Context, input lInput) error {
 		false, // TODO supoort fast mode
 		input.Language,
 		in,
+		runner.InputFormatText,
 		input.Depth,
 		false,
 		"",
@@ -90,7 +92,11 @@ func (l *lambdaAwsRunner) handler(ctx context.Context, input lInput) error {
 		nil,
 		exitMonitor,
 		input.ExtraReviews,
+		gmaps.ReviewOptions{},
 		0,
+		nil,
+		nil,
+		nil,
 	)
 	if err != nil {
 		return err
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/gosom/google-maps-scraper/common/logger"
+	"github.com/gosom/google-maps-scraper/proxypool"
+)
+
+// maxCooldownFactor is how many times -proxy-cooldown the longest
+// quarantine is.
+const maxCooldownFactor = 30
+
+// NewProxyGateway starts a pool of the -proxies or the -proxy-source of the
+// config, and a gateway to it. The proxies of a source are kept up to date
+// until the gateway is closed. It returns nil when there are no proxies.
+// scrapemate is given the URL of the gateway instead of the proxies.
+// Callers must close it.
+func NewProxyGateway(cfg *Config) (*proxypool.Gateway, error) {
+	if cfg.ProxySource == "" {
+		if len(cfg.Proxies) == 0 {
+			return nil, nil
+		}
+
+		return NewJobProxyGateway(cfg, cfg.Proxies)
+	}
+
+	entries, err := proxypool.Load(context.Background(), cfg.ProxySource)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := proxypool.NewFromEntries(entries, poolOptions(cfg)...)
+	if err != nil {
+		return nil, err
+	}
+
+	pool.Watch(cfg.ProxySource, cfg.ProxyRefresh)
+
+	logger.Info("loaded proxies", "source", cfg.ProxySource, "proxies", pool.Len())
+
+	return newGateway(pool)
+}
+
+// NewJobProxyGateway starts a pool of the proxies of a job, with the
+// -proxy-policy and -proxy-cooldown of the config, and a gateway to it.
+// Callers must close it.
+func NewJobProxyGateway(cfg *Config, proxies []string) (*proxypool.Gateway, error) {
+	pool, err := proxypool.New(proxies, poolOptions(cfg)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGateway(pool)
+}
+
+func newGateway(pool *proxypool.Pool) (*proxypool.Gateway, error) {
+	gw, err := proxypool.NewGateway(pool)
+	if err != nil {
+		pool.Close()
+
+		return nil, err
+	}
+
+	return gw, nil
+}
+
+func poolOptions(cfg *Config) []proxypool.Option {
+	return []proxypool.Option{
+		proxypool.WithPolicy(cfg.ProxyPolicy),
+		proxypool.WithCooldown(cfg.ProxyCooldown, maxCooldownFactor*cfg.ProxyCooldown),
+	}
+}
+
+// LogProxyStats logs the health of the proxies of the gateway at the end of
+// a run.
+func LogProxyStats(gw *proxypool.Gateway) {
+	for _, s := range gw.Pool().Stats() {
+		logger.Info("proxy stats",
+			"proxy", s.Proxy,
+			"country", s.Tags[proxypool.TagCountry],
+			"status", s.Status,
+			"uses", s.Uses,
+			"successes", s.Successes,
+			"failures", s.Failures,
+			"blocks", s.Blocks,
+			"latency_ms", s.LatencyMS,
+			"last_error", s.LastError,
+		)
+	}
+}
@@ -0,0 +1,520 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+const (
+	InputFormatText  = "text"
+	InputFormatJSONL = "jsonl"
+	InputFormatYAML  = "yaml"
+)
+
+// QuerySpec is a single seed query. Zero values fall back to the global
+// settings of the run.
+type QuerySpec struct {
+	ID           string      `json:"id" yaml:"id"`
+	Query        string      `json:"query" yaml:"query"`
+	Lang         string      `json:"lang" yaml:"lang"`
+	Lat          *float64    `json:"lat" yaml:"lat"`
+	Lon          *float64    `json:"lon" yaml:"lon"`
+	Zoom         int         `json:"zoom" yaml:"zoom"`
+	Radius       float64     `json:"radius" yaml:"radius"`
+	Depth        int         `json:"depth" yaml:"depth"`
+	Email        *bool       `json:"email" yaml:"email"`
+	ExtraReviews *bool       `json:"extra_reviews" yaml:"extra_reviews"`
+	SearchDelay  int         `json:"search_delay" yaml:"search_delay"`
+	BBox         []float64   `json:"bbox" yaml:"bbox"`
+	Polygon      [][]float64 `json:"polygon" yaml:"polygon"`
+	ReviewSort   string      `json:"review_sort" yaml:"review_sort"`
+	MaxReviews   int         `json:"max_reviews" yaml:"max_reviews"`
+	ReviewsSince string      `json:"reviews_since" yaml:"reviews_since"`
+
+	line    int
+	area    *gmaps.GridArea
+	reviews gmaps.ReviewOptions
+}
+
+// Line is the line of the input the query was read from.
+func (q *QuerySpec) Line() int {
+	return q.line
+}
+
+// GridArea returns the grid sweep area of the query or nil.
+func (q *QuerySpec) GridArea() *gmaps.GridArea {
+	return q.area
+}
+
+// Key identifies the query across runs over the same input. Queries with
+// the same key produce the same results.
+func (q *QuerySpec) Key() string {
+	data, _ := json.Marshal(q)
+
+	return string(data)
+}
+
+// ReviewOptions returns the review options of the query, with the ones it
+// does not set taken from defaults.
+func (q *QuerySpec) ReviewOptions(defaults gmaps.ReviewOptions) gmaps.ReviewOptions {
+	return gmaps.ReviewOptions{
+		Sort:  cmp.Or(q.reviews.Sort, defaults.Sort),
+		Max:   cmp.Or(q.reviews.Max, defaults.Max),
+		Since: cmp.Or(q.reviews.Since, defaults.Since),
+	}
+}
+
+func (q *QuerySpec) HasGeo() bool {
+	return q.Lat != nil && q.Lon != nil
+}
+
+// Geo returns the query coordinates in the "lat,lon" format.
+func (q *QuerySpec) Geo() string {
+	if !q.HasGeo() {
+		return ""
+	}
+
+	return strconv.FormatFloat(*q.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(*q.Lon, 'f', -1, 64)
+}
+
+// Validate checks the query and prepares its grid area.
+func (q *QuerySpec) Validate() error {
+	q.Query = strings.TrimSpace(q.Query)
+
+	if q.Query == "" {
+		return errors.New("missing query")
+	}
+
+	if q.Lang != "" && len(q.Lang) != 2 {
+		return fmt.Errorf("invalid lang %q", q.Lang)
+	}
+
+	if (q.Lat == nil) != (q.Lon == nil) {
+		return errors.New("lat and lon must be set together")
+	}
+
+	if q.HasGeo() && (*q.Lat < -90 || *q.Lat > 90 || *q.Lon < -180 || *q.Lon > 180) {
+		return fmt.Errorf("invalid coordinates %s", q.Geo())
+	}
+
+	if q.Zoom < 0 || q.Zoom > 21 {
+		return fmt.Errorf("zoom must be between 0 and 21, got %d", q.Zoom)
+	}
+
+	if q.Radius < 0 {
+		return fmt.Errorf("radius must not be negative, got %v", q.Radius)
+	}
+
+	if q.Depth < 0 {
+		return fmt.Errorf("depth must not be negative, got %d", q.Depth)
+	}
+
+	if q.SearchDelay < 0 {
+		return fmt.Errorf("search_delay must not be negative, got %d", q.SearchDelay)
+	}
+
+	if q.MaxReviews < 0 {
+		return fmt.Errorf("max_reviews must not be negative, got %d", q.MaxReviews)
+	}
+
+	var err error
+
+	q.reviews.Max = q.MaxReviews
+
+	if q.reviews.Sort, err = gmaps.ParseReviewSort(q.ReviewSort); err != nil {
+		return err
+	}
+
+	if q.reviews.Since, err = gmaps.ParseReviewsSince(q.ReviewsSince); err != nil {
+		return err
+	}
+
+	switch {
+	case len(q.BBox) > 0 && len(q.Polygon) > 0:
+		return errors.New("bbox and polygon are mutually exclusive")
+	case len(q.BBox) > 0:
+		if len(q.BBox) != 4 {
+			return fmt.Errorf("bbox needs 4 values [minLat, minLon, maxLat, maxLon], got %d", len(q.BBox))
+		}
+
+		q.area, err = gmaps.NewBoundingBoxArea(gmaps.BoundingBox{
+			MinLat: q.BBox[0],
+			MinLon: q.BBox[1],
+			MaxLat: q.BBox[2],
+			MaxLon: q.BBox[3],
+		})
+	case len(q.Polygon) > 0:
+		points := make([]gmaps.LatLon, 0, len(q.Polygon))
+
+		for i := range q.Polygon {
+			if len(q.Polygon[i]) != 2 {
+				return fmt.Errorf("polygon point %d needs 2 values [lat, lon]", i)
+			}
+
+			points = append(points, gmaps.LatLon{Lat: q.Polygon[i][0], Lon: q.Polygon[i][1]})
+		}
+
+		q.area, err = gmaps.NewPolygonArea(points)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid grid area: %w", err)
+	}
+
+	return nil
+}
+
+// ParseQueries reads the seed queries from r. When format is empty it is
+// detected from the content: a leading '{' means JSON Lines, a leading
+// "---" or "- " means YAML and everything else is the plain text format.
+//
+// Every query is validated; all errors are returned together with the
+// line they were found on.
+func ParseQueries(r io.Reader, format string) ([]QuerySpec, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = detectInputFormat(data)
+	}
+
+	var specs []QuerySpec
+
+	switch format {
+	case InputFormatText:
+		specs, err = parseTextQueries(data)
+	case InputFormatJSONL:
+		specs, err = parseJSONLQueries(data)
+	case InputFormatYAML:
+		specs, err = parseYAMLQueries(data)
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+
+	var errs []error
+
+	// keep going on decoding errors so every bad line is reported at once
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for i := range specs {
+		if err := specs[i].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", specs[i].line, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return specs, nil
+}
+
+func detectInputFormat(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// comments are allowed at the top of YAML files
+		if line == "" || (strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#!")) {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "{"):
+			return InputFormatJSONL
+		case line == "---" || strings.HasPrefix(line, "- "):
+			return InputFormatYAML
+		default:
+			return InputFormatText
+		}
+	}
+
+	return InputFormatText
+}
+
+func parseJSONLQueries(data []byte) ([]QuerySpec, error) {
+	var (
+		specs []QuerySpec
+		errs  []error
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var spec QuerySpec
+
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(&spec); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", lineNum, err))
+
+			continue
+		}
+
+		spec.line = lineNum
+		specs = append(specs, spec)
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return specs, errors.Join(errs...)
+}
+
+func parseYAMLQueries(data []byte) ([]QuerySpec, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: expected a list of queries", root.Line)
+	}
+
+	var errs []error
+
+	specs := make([]QuerySpec, 0, len(root.Content))
+
+	for _, item := range root.Content {
+		if err := checkYAMLFields(item); err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		var spec QuerySpec
+
+		if err := item.Decode(&spec); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", item.Line, err))
+
+			continue
+		}
+
+		spec.line = item.Line
+		specs = append(specs, spec)
+	}
+
+	return specs, errors.Join(errs...)
+}
+
+// checkYAMLFields rejects the keys QuerySpec does not have, as the JSON
+// Lines decoder does.
+func checkYAMLFields(item *yaml.Node) error {
+	if item.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		key := item.Content[i]
+
+		if !slices.Contains(yamlFields(), key.Value) {
+			return fmt.Errorf("line %d: unknown field %q", key.Line, key.Value)
+		}
+	}
+
+	return nil
+}
+
+var yamlFields = sync.OnceValue(func() []string {
+	t := reflect.TypeFor[QuerySpec]()
+	ans := make([]string, 0, t.NumField())
+
+	for i := range t.NumField() {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); name != "" {
+			ans = append(ans, name)
+		}
+	}
+
+	return ans
+})
+
+// parseTextQueries parses one query per line. A line may carry an input id
+// (query#!#id), coordinates (query#!geo#lat,lon) and a grid sweep area
+// (query#!grid#minLat,minLon,maxLat,maxLon or query#!grid#lat,lon;lat,lon;...),
+// in any order.
+// Like before the structured formats, bad coordinates are ignored instead of
+// failing the input, only a bad grid area is an error.
+func parseTextQueries(data []byte) ([]QuerySpec, error) {
+	var specs []QuerySpec
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+
+		query := strings.TrimSpace(scanner.Text())
+		if query == "" {
+			continue
+		}
+
+		spec := QuerySpec{line: lineNum}
+
+		query, markers := cutMarkers(query)
+
+		if id, ok := markers["#!#"]; ok {
+			spec.ID = id
+		}
+
+		if geo, ok := markers["#!geo#"]; ok {
+			// invalid coordinates are ignored and the global ones are used
+			geoParts := strings.Split(geo, ",")
+			if len(geoParts) == 2 {
+				qlat, latErr := strconv.ParseFloat(strings.TrimSpace(geoParts[0]), 64)
+				qlon, lonErr := strconv.ParseFloat(strings.TrimSpace(geoParts[1]), 64)
+
+				if latErr == nil && lonErr == nil && qlat >= -90 && qlat <= 90 && qlon >= -180 && qlon <= 180 {
+					spec.Lat = &qlat
+					spec.Lon = &qlon
+				}
+			}
+		}
+
+		if area, ok := markers["#!grid#"]; ok {
+			if err := parseGridArea(area, &spec); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		}
+
+		// lines with markers only, such as "#!#id", are skipped as before
+		if query == "" {
+			continue
+		}
+
+		spec.Query = query
+		specs = append(specs, spec)
+	}
+
+	return specs, scanner.Err()
+}
+
+// textMarkers are the markers of the values after the query of a text line.
+var textMarkers = []string{"#!#", "#!geo#", "#!grid#"}
+
+// cutMarkers returns the query of a text line and the value of each of its
+// markers, in whatever order they come.
+func cutMarkers(line string) (string, map[string]string) {
+	type marker struct {
+		name string
+		at   int
+	}
+
+	var found []marker
+
+	for _, name := range textMarkers {
+		if at := strings.Index(line, name); at >= 0 {
+			found = append(found, marker{name: name, at: at})
+		}
+	}
+
+	if len(found) == 0 {
+		return line, nil
+	}
+
+	slices.SortFunc(found, func(a, b marker) int {
+		return cmp.Compare(a.at, b.at)
+	})
+
+	values := make(map[string]string, len(found))
+
+	for i, m := range found {
+		end := len(line)
+		if i+1 < len(found) {
+			end = found[i+1].at
+		}
+
+		values[m.name] = strings.TrimSpace(line[m.at+len(m.name) : end])
+	}
+
+	return strings.TrimSpace(line[:found[0].at]), values
+}
+
+// parseGridArea parses either a bounding box "minLat,minLon,maxLat,maxLon"
+// or a polygon "lat,lon;lat,lon;lat,lon" into the spec.
+func parseGridArea(s string, spec *QuerySpec) error {
+	s = strings.TrimSpace(s)
+
+	if !strings.Contains(s, ";") {
+		parts := strings.Split(s, ",")
+		if len(parts) != 4 {
+			return fmt.Errorf("invalid grid bounding box %q", s)
+		}
+
+		spec.BBox = make([]float64, len(parts))
+
+		for i := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+			if err != nil {
+				return fmt.Errorf("invalid grid bounding box %q: %w", s, err)
+			}
+
+			spec.BBox[i] = v
+		}
+
+		return nil
+	}
+
+	for _, pair := range strings.Split(s, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		latStr, lonStr, ok := strings.Cut(pair, ",")
+		if !ok {
+			return fmt.Errorf("invalid grid polygon point %q", pair)
+		}
+
+		plat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+		if err != nil {
+			return fmt.Errorf("invalid grid polygon point %q: %w", pair, err)
+		}
+
+		plon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+		if err != nil {
+			return fmt.Errorf("invalid grid polygon point %q: %w", pair, err)
+		}
+
+		spec.Polygon = append(spec.Polygon, []float64{plat, plon})
+	}
+
+	return nil
+}
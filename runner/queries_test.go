@@ -0,0 +1,153 @@
+package runner_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/runner"
+)
+
+func Test_ParseQueriesDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		queries []string
+		lines   []int
+	}{
+		{
+			name:    "text",
+			input:   "coffee\n\nbakery #!# b1\n",
+			queries: []string{"coffee", "bakery"},
+			lines:   []int{1, 3},
+		},
+		{
+			name:    "jsonl",
+			input:   "{\"query\": \"coffee\"}\n\n{\"query\": \"bakery\", \"zoom\": 14}\n",
+			queries: []string{"coffee", "bakery"},
+			lines:   []int{1, 3},
+		},
+		{
+			name:    "yaml",
+			input:   "# seeds\n- query: coffee\n- query: bakery\n  lang: de\n",
+			queries: []string{"coffee", "bakery"},
+			lines:   []int{2, 3},
+		},
+		{
+			name:    "yaml document",
+			input:   "---\n- query: coffee\n",
+			queries: []string{"coffee"},
+			lines:   []int{2},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			specs, err := runner.ParseQueries(strings.NewReader(tc.input), "")
+			require.NoError(t, err)
+			require.Len(t, specs, len(tc.queries))
+
+			for i := range specs {
+				require.Equal(t, tc.queries[i], specs[i].Query)
+				require.Equal(t, tc.lines[i], specs[i].Line())
+			}
+		})
+	}
+}
+
+func Test_ParseQueriesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format string
+		errs   []string
+	}{
+		{
+			name:  "unknown jsonl field",
+			input: "{\"query\": \"coffee\", \"qeury\": \"x\"}\n",
+			errs:  []string{"line 1:", `unknown field "qeury"`},
+		},
+		{
+			name:  "unknown yaml field",
+			input: "- query: coffee\n- query: bakery\n  zom: 3\n",
+			errs:  []string{"line 3:", `unknown field "zom"`},
+		},
+		{
+			name:  "every bad line",
+			input: "{\"query\": \"coffee\", \"zoom\": 30}\n{\"query\": \"\"}\n{bad\n",
+			errs:  []string{"line 1: zoom must be between 0 and 21", "line 2: missing query", "line 3:"},
+		},
+		{
+			name:  "bad grid",
+			input: "coffee #!grid# 1,2,3\n",
+			errs:  []string{"line 1:", "invalid grid bounding box"},
+		},
+		{
+			name:   "unknown format",
+			input:  "coffee\n",
+			format: "csv",
+			errs:   []string{`unknown input format "csv"`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := runner.ParseQueries(strings.NewReader(tc.input), tc.format)
+			require.Error(t, err)
+
+			for _, msg := range tc.errs {
+				require.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+func Test_ParseQueriesText(t *testing.T) {
+	input := strings.Join([]string{
+		"coffee #!# c1",
+		"#!# only-an-id",
+		"bakery #!geo# 52.52, 13.40",
+		"pizza #!geo# 200,13.4",
+		"bar #!geo# not,coords",
+		"# not a comment in text files",
+		"cafe #!geo# 37.98,23.73 #!grid# 37.9,23.6,38.1,23.9 #!# c2",
+		"tea #!# c3 #!geo# 37.98,23.73",
+	}, "\n")
+
+	specs, err := runner.ParseQueries(strings.NewReader(input), runner.InputFormatText)
+	require.NoError(t, err)
+	require.Len(t, specs, 7)
+
+	require.Equal(t, "coffee", specs[0].Query)
+	require.Equal(t, "c1", specs[0].ID)
+
+	require.Equal(t, "bakery", specs[1].Query)
+	require.Equal(t, "52.52,13.4", specs[1].Geo())
+
+	// bad coordinates fall back to the global ones
+	require.Equal(t, "pizza", specs[2].Query)
+	require.False(t, specs[2].HasGeo())
+	require.False(t, specs[3].HasGeo())
+
+	require.Equal(t, "# not a comment in text files", specs[4].Query)
+
+	// the markers may come in any order
+	require.Equal(t, "cafe", specs[5].Query)
+	require.Equal(t, "c2", specs[5].ID)
+	require.Equal(t, "37.98,23.73", specs[5].Geo())
+	require.Equal(t, []float64{37.9, 23.6, 38.1, 23.9}, specs[5].BBox)
+
+	require.Equal(t, "tea", specs[6].Query)
+	require.Equal(t, "c3", specs[6].ID)
+	require.Equal(t, "37.98,23.73", specs[6].Geo())
+
+	// a forced format is not detected again
+	specs, err = runner.ParseQueries(strings.NewReader(`{"query": "coffee"}`), runner.InputFormatText)
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+	require.Equal(t, `{"query": "coffee"}`, specs[0].Query)
+
+	_, err = runner.ParseQueries(strings.NewReader("coffee\n"), runner.InputFormatJSONL)
+	require.Error(t, err)
+}
@@ -0,0 +1,16 @@
+package runner
+
+import (
+	"github.com/gosom/google-maps-scraper/ratelimit"
+)
+
+// NewRateLimiter returns the rate limiter of a run, with the -google-rate,
+// -website-rate and -rate-jitter of the config. The requests to Google
+// share one bucket, every business website has its own.
+func NewRateLimiter(cfg *Config) *ratelimit.Limiter {
+	return ratelimit.New(
+		ratelimit.WithDomainRate(ratelimit.GoogleDomain, cfg.GoogleRate),
+		ratelimit.WithHostRate(cfg.WebsiteRate),
+		ratelimit.WithJitter(cfg.RateJitter),
+	)
+}
@@ -0,0 +1,129 @@
+package tokenrunner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/runner"
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/google-maps-scraper/web/sqlite"
+)
+
+// tokenrunner manages the API tokens of the web runner, in the database of
+// its data folder.
+type tokenrunner struct {
+	cfg *runner.Config
+	svc *web.Service
+}
+
+func New(cfg *runner.Config) (runner.Runner, error) {
+	if cfg.RunMode != runner.RunModeTokens {
+		return nil, fmt.Errorf("%w: %d", runner.ErrInvalidRunMode, cfg.RunMode)
+	}
+
+	if err := os.MkdirAll(cfg.DataFolder, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	repo, err := sqlite.New(filepath.Join(cfg.DataFolder, "jobs.db"))
+	if err != nil {
+		return nil, err
+	}
+
+	ans := tokenrunner{
+		cfg: cfg,
+		svc: web.NewService(repo, cfg.DataFolder),
+	}
+
+	return &ans, nil
+}
+
+func (t *tokenrunner) Run(ctx context.Context) error {
+	switch t.cfg.TokensCommand {
+	case "create":
+		token, secret, err := t.svc.CreateToken(ctx, t.cfg.TokenName, t.cfg.TokenOwner, t.cfg.TokenAdmin)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "created token %s (%s) for %s, it is not shown again:\n", token.ID, token.Name, token.Owner)
+		fmt.Println(secret)
+
+		return nil
+	case "list":
+		tokens, err := t.svc.Tokens(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(w, "ID\tNAME\tOWNER\tADMIN\tHINT\tCREATED\tREVOKED")
+
+		for i := range tokens {
+			revoked := "-"
+			if tokens[i].Revoked() {
+				revoked = tokens[i].RevokedAt.Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s...\t%s\t%s\n",
+				tokens[i].ID, tokens[i].Name, tokens[i].Owner, tokens[i].Admin, tokens[i].Hint, tokens[i].CreatedAt.Format(time.RFC3339), revoked)
+		}
+
+		return w.Flush()
+	case "revoke":
+		if err := t.svc.RevokeToken(ctx, t.cfg.TokenID); err != nil {
+			return fmt.Errorf("failed to revoke token %s: %w", t.cfg.TokenID, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "revoked token %s\n", t.cfg.TokenID)
+
+		return nil
+	case "quota":
+		if t.cfg.TokenOwner != "" {
+			quota := web.Quota{
+				Owner:           t.cfg.TokenOwner,
+				MaxJobs:         t.cfg.QuotaMaxJobs,
+				MaxPlacesPerDay: t.cfg.QuotaMaxPlacesPerDay,
+			}
+
+			if err := t.svc.SetQuota(ctx, &quota); err != nil {
+				return err
+			}
+		}
+
+		quotas, err := t.svc.Quotas(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(w, "OWNER\tMAX JOBS\tMAX PLACES PER DAY")
+
+		for i := range quotas {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", quotas[i].Owner, limit(quotas[i].MaxJobs), limit(quotas[i].MaxPlacesPerDay))
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown tokens command: %q", t.cfg.TokensCommand)
+	}
+}
+
+func (t *tokenrunner) Close(context.Context) error {
+	return nil
+}
+
+func limit(n int) string {
+	if n == 0 {
+		return "-"
+	}
+
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,133 @@
+package webrunner
+
+import (
+	"sync"
+
+	"github.com/gosom/google-maps-scraper/proxypool"
+	"github.com/gosom/google-maps-scraper/runner"
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+// proxies has the proxy gateways of the runner. The proxies of the server
+// are shared by all the jobs, the job ID being the key of
+// proxypool.PolicySticky, and each job uses the proxies of its country. A
+// job brings its own proxies only when the server has none, and its gateway
+// lives as long as the job runs.
+type proxies struct {
+	cfg    *runner.Config
+	server *proxypool.Gateway
+
+	mu   sync.Mutex
+	jobs map[string]*proxypool.Gateway
+}
+
+func newProxies(cfg *runner.Config) (*proxies, error) {
+	ans := proxies{
+		cfg:  cfg,
+		jobs: map[string]*proxypool.Gateway{},
+	}
+
+	gw, err := runner.NewProxyGateway(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ans.server = gw
+
+	return &ans, nil
+}
+
+// acquire returns the proxy URL scrapemate uses for the job, or an empty
+// string when neither the server nor the job has proxies.
+func (p *proxies) acquire(job *web.Job) (string, error) {
+	if p.server != nil {
+		var coords string
+		if job.Data.Lat != "" && job.Data.Lon != "" {
+			coords = job.Data.Lat + "," + job.Data.Lon
+		}
+
+		return p.server.CountryURL(job.ID, proxypool.Country(job.Data.Lang, coords)), nil
+	}
+
+	if len(job.Data.Proxies) == 0 {
+		return "", nil
+	}
+
+	gw, err := runner.NewJobProxyGateway(p.cfg, job.Data.Proxies)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	p.jobs[job.ID] = gw
+	p.mu.Unlock()
+
+	return gw.URL(job.ID), nil
+}
+
+// release closes the gateway of the job, or drops its sticky proxy from the
+// pool of the server.
+func (p *proxies) release(jobID string) {
+	if p.server != nil {
+		p.server.Pool().Forget(jobID)
+
+		return
+	}
+
+	p.mu.Lock()
+	gw, ok := p.jobs[jobID]
+	delete(p.jobs, jobID)
+	p.mu.Unlock()
+
+	if ok {
+		runner.LogProxyStats(gw)
+
+		_ = gw.Close()
+	}
+}
+
+// gateway returns the gateway the job uses, or nil when it has no proxies.
+func (p *proxies) gateway(jobID string) *proxypool.Gateway {
+	if p.server != nil {
+		return p.server
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.jobs[jobID]
+}
+
+// ProxyPools implements web.ProxyMonitor.
+func (p *proxies) ProxyPools() []web.ProxyPool {
+	var ans []web.ProxyPool
+
+	if p.server != nil {
+		ans = append(ans, newProxyPool("", p.server))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, gw := range p.jobs {
+		ans = append(ans, newProxyPool(id, gw))
+	}
+
+	return ans
+}
+
+func (p *proxies) close() {
+	if p.server != nil {
+		runner.LogProxyStats(p.server)
+
+		_ = p.server.Close()
+	}
+}
+
+func newProxyPool(jobID string, gw *proxypool.Gateway) web.ProxyPool {
+	return web.ProxyPool{
+		JobID:   jobID,
+		Policy:  gw.Pool().Policy(),
+		Proxies: gw.Pool().Stats(),
+	}
+}
@@ -0,0 +1,87 @@
+package webrunner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/scrapemate"
+)
+
+// ResultWriter implements scrapemate.ResultWriter and stores the places of
+// a job in the results table of the service. Places the job has already,
+// as after it was resumed, are skipped.
+type ResultWriter struct {
+	svc   *web.Service
+	jobID string
+
+	// OnWrite is called with the number of places added.
+	OnWrite func(int)
+	// OnEntry is called with each place added.
+	OnEntry func(*gmaps.Entry)
+}
+
+// NewResultWriter creates a writer for the results of the job.
+func NewResultWriter(svc *web.Service, jobID string) *ResultWriter {
+	return &ResultWriter{
+		svc:   svc,
+		jobID: jobID,
+	}
+}
+
+// Run consumes the results channel until it is closed.
+func (w *ResultWriter) Run(ctx context.Context, in <-chan scrapemate.Result) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case res, ok := <-in:
+			if !ok {
+				return nil
+			}
+
+			if err := w.write(ctx, res.Data); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (w *ResultWriter) write(ctx context.Context, data any) error {
+	var entries []*gmaps.Entry
+
+	switch v := data.(type) {
+	case []*gmaps.Entry:
+		entries = v
+	case *gmaps.Entry:
+		entries = []*gmaps.Entry{v}
+	case gmaps.Entry:
+		entries = []*gmaps.Entry{&v}
+	default:
+		return fmt.Errorf("invalid data type for result writer: %T", data)
+	}
+
+	// without the cancel of the job so that the places being written are
+	// kept
+	added, err := w.svc.AddPlaces(context.WithoutCancel(ctx), w.jobID, entries...)
+	if err != nil {
+		return fmt.Errorf("failed to store places: %w", err)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	if w.OnWrite != nil {
+		w.OnWrite(len(added))
+	}
+
+	if w.OnEntry != nil {
+		for _, entry := range added {
+			w.OnEntry(entry)
+		}
+	}
+
+	return nil
+}
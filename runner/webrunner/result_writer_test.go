@@ -0,0 +1,72 @@
+package webrunner_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/runner/webrunner"
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/google-maps-scraper/web/sqlite"
+)
+
+func Test_ResultWriter(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := sqlite.New(filepath.Join(dir, "jobs.db"))
+	require.NoError(t, err)
+
+	svc := web.NewService(repo, dir)
+
+	job := web.Job{
+		ID:     uuid.New().String(),
+		Name:   "coffee",
+		Date:   time.Now().UTC(),
+		Status: web.StatusWorking,
+		Data:   web.JobData{Keywords: []string{"coffee"}, Lang: "en", Depth: 1},
+	}
+
+	require.NoError(t, repo.Create(t.Context(), &job))
+
+	var (
+		writes  []int
+		entries []string
+	)
+
+	w := webrunner.NewResultWriter(svc, job.ID)
+	w.OnWrite = func(n int) {
+		writes = append(writes, n)
+	}
+	w.OnEntry = func(e *gmaps.Entry) {
+		entries = append(entries, e.DataID)
+	}
+
+	in := make(chan scrapemate.Result, 4)
+	in <- scrapemate.Result{Data: []*gmaps.Entry{{DataID: "a"}, {DataID: "b"}, {DataID: "c"}}}
+	// the places written already, as after a resume, are skipped
+	in <- scrapemate.Result{Data: []*gmaps.Entry{{DataID: "b"}, {DataID: "d"}}}
+	in <- scrapemate.Result{Data: &gmaps.Entry{DataID: "a"}}
+	in <- scrapemate.Result{Data: gmaps.Entry{DataID: "e"}}
+	close(in)
+
+	require.NoError(t, w.Run(t.Context(), in))
+
+	// one call per result with new places
+	require.Equal(t, []int{3, 1, 1}, writes)
+	require.Equal(t, []string{"a", "b", "c", "d", "e"}, entries)
+
+	count, err := svc.CountPlaces(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+
+	in = make(chan scrapemate.Result, 1)
+	in <- scrapemate.Result{Data: "not a place"}
+	close(in)
+
+	require.Error(t, w.Run(t.Context(), in))
+}
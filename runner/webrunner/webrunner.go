@@ -2,32 +2,43 @@ package webrunner
 
 import (
 	"context"
-	"encoding/csv"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gosom/google-maps-scraper/budget"
 	"github.com/gosom/google-maps-scraper/common/logger"
 	"github.com/gosom/google-maps-scraper/deduper"
 	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/ratelimit"
 	"github.com/gosom/google-maps-scraper/runner"
 	"github.com/gosom/google-maps-scraper/tlmt"
 	"github.com/gosom/google-maps-scraper/web"
 	"github.com/gosom/google-maps-scraper/web/sqlite"
 	"github.com/gosom/scrapemate"
+	memprovider "github.com/gosom/scrapemate/adapters/providers/memory"
 	"github.com/gosom/scrapemate/scrapemateapp"
 	"golang.org/x/sync/errgroup"
 )
 
 type webrunner struct {
-	srv *web.Server
-	svc *web.Service
-	cfg *runner.Config
+	srv     *web.Server
+	svc     *web.Service
+	cfg     *runner.Config
+	proxies *proxies
+	limiter *ratelimit.Limiter
+	backoff *gmaps.Backoff
+	budget  *budget.Budget
 }
 
 func New(cfg *runner.Config) (runner.Runner, error) {
@@ -48,22 +59,114 @@ func New(cfg *runner.Config) (runner.Runner, error) {
 		return nil, err
 	}
 
-	svc := web.NewService(repo, cfg.DataFolder)
+	jobProxies, err := newProxies(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	srv, err := web.New(svc, cfg.Addr)
+	secret, err := webhookSecret(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := web.NewService(repo, cfg.DataFolder,
+		web.WithWebhooks(web.WebhookConfig{
+			DefaultURL:   cfg.WebhookURL,
+			Secret:       secret,
+			BaseURL:      cfg.PublicURL,
+			AllowPrivate: cfg.WebhookAllowPrivate,
+		}),
+		web.WithProxyMonitor(jobProxies),
+	)
+
+	var opts []web.ServerOption
+
+	if cfg.WebAuth {
+		opts = append(opts, web.WithAuth())
+
+		if err := warnNoTokens(svc); err != nil {
+			return nil, err
+		}
+	}
+
+	srv, err := web.New(svc, cfg.Addr, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	ans := webrunner{
-		srv: srv,
-		svc: svc,
-		cfg: cfg,
+		srv:     srv,
+		svc:     svc,
+		cfg:     cfg,
+		proxies: jobProxies,
+		limiter: runner.NewRateLimiter(cfg),
+		backoff: runner.NewBackoff(cfg, nil, ""),
+		budget:  budget.New(cfg.Concurrency),
 	}
 
 	return &ans, nil
 }
 
+// webhookSecret returns the secret that signs the webhooks. Without
+// -webhook-secret, one is generated on the first start and kept in the data
+// folder, so the receivers can check the calls across restarts.
+func webhookSecret(cfg *runner.Config) (string, error) {
+	if cfg.WebhookSecret != "" {
+		return cfg.WebhookSecret, nil
+	}
+
+	path := filepath.Join(cfg.DataFolder, "webhook_secret")
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		secret := strings.TrimSpace(string(data))
+		if secret == "" {
+			return "", fmt.Errorf("empty webhook secret in %s", path)
+		}
+
+		return secret, nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	secret := hex.EncodeToString(b)
+
+	if err := os.WriteFile(path, []byte(secret+"\n"), 0o600); err != nil {
+		return "", err
+	}
+
+	logger.Info("generated a webhook secret, give it to the webhook receivers to check the signatures", "path", path)
+
+	return secret, nil
+}
+
+// warnNoTokens warns when the server requires a token but none can be used
+// yet.
+func warnNoTokens(svc *web.Service) error {
+	tokens, err := svc.Tokens(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for i := range tokens {
+		if !tokens[i].Revoked() {
+			return nil
+		}
+	}
+
+	logger.Warn("web auth is enabled but there are no API tokens, create one with: tokens create -name NAME")
+
+	return nil
+}
+
 func (w *webrunner) Run(ctx context.Context) error {
 	egroup, ctx := errgroup.WithContext(ctx)
 
@@ -71,6 +174,14 @@ func (w *webrunner) Run(ctx context.Context) error {
 		return w.work(ctx)
 	})
 
+	egroup.Go(func() error {
+		return w.schedule(ctx)
+	})
+
+	egroup.Go(func() error {
+		return w.deliver(ctx)
+	})
+
 	egroup.Go(func() error {
 		return w.srv.Start(ctx)
 	})
@@ -79,93 +190,193 @@ func (w *webrunner) Run(ctx context.Context) error {
 }
 
 func (w *webrunner) Close(context.Context) error {
+	w.proxies.close()
+
 	return nil
 }
 
+// work starts the pending jobs, highest priority first, while fewer than
+// cfg.WebJobs are running, so a long job does not hold back the others.
+// The running jobs share the cfg.Concurrency requests in flight of the
+// budget, by priority and then fairly.
 func (w *webrunner) work(ctx context.Context) error {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
+	slots := make(chan struct{}, w.cfg.WebJobs)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			jobs, err := w.svc.SelectPending(ctx)
-			if err != nil {
-				return err
-			}
+			for len(slots) < cap(slots) {
+				jobs, err := w.svc.SelectPending(ctx)
+				if err != nil {
+					return err
+				}
 
-			for i := range jobs {
-				select {
-				case <-ctx.Done():
-					return nil
-				default:
-					t0 := time.Now().UTC()
-					if err := w.scrapeJob(ctx, &jobs[i]); err != nil {
-						params := map[string]any{
-							"job_count": len(jobs[i].Data.Keywords),
-							"duration":  time.Now().UTC().Sub(t0).String(),
-							"error":     err.Error(),
-						}
-
-						evt := tlmt.NewEvent("web_runner", params)
-
-						_ = runner.Telemetry().Send(ctx, evt)
-
-						logger.Error("error scraping job", "job_id", jobs[i].ID, "error", err)
-					} else {
-						params := map[string]any{
-							"job_count": len(jobs[i].Data.Keywords),
-							"duration":  time.Now().UTC().Sub(t0).String(),
-						}
-
-						_ = runner.Telemetry().Send(ctx, tlmt.NewEvent("web_runner", params))
-
-						logger.Info("job scraped successfully", "job_id", jobs[i].ID)
-					}
+				if len(jobs) == 0 {
+					break
 				}
+
+				job := jobs[0]
+
+				// jobCtx is cancelled with a cause when the job is
+				// cancelled, paused or deleted from the API.
+				jobCtx, stop := context.WithCancelCause(ctx)
+
+				done, err := w.svc.StartJob(ctx, &job, stop)
+				if errors.Is(err, web.ErrInvalidStatus) {
+					stop(nil)
+
+					logger.Info("job is no longer pending", "job_id", job.ID, "error", err)
+
+					continue
+				}
+
+				if err != nil {
+					stop(nil)
+
+					return err
+				}
+
+				slots <- struct{}{}
+
+				wg.Add(1)
+
+				go func() {
+					defer func() {
+						stop(nil)
+						done()
+						<-slots
+						wg.Done()
+					}()
+
+					w.runJob(ctx, jobCtx, &job)
+				}()
 			}
 		}
 	}
 }
 
-func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
-	job.Status = web.StatusWorking
+func (w *webrunner) runJob(ctx, jobCtx context.Context, job *web.Job) {
+	t0 := time.Now().UTC()
 
-	err := w.svc.Update(ctx, job)
-	if err != nil {
-		return err
+	defer func() {
+		// also on shutdown, the delivery is sent after a restart
+		if err := w.svc.NotifyJob(context.Background(), job.ID, time.Since(t0)); err != nil {
+			logger.Error("failed to queue webhook", "job_id", job.ID, "error", err)
+		}
+	}()
+
+	if err := w.scrapeJob(ctx, jobCtx, job); err != nil {
+		params := map[string]any{
+			"job_count": len(job.Data.Keywords),
+			"duration":  time.Now().UTC().Sub(t0).String(),
+			"error":     err.Error(),
+		}
+
+		evt := tlmt.NewEvent("web_runner", params)
+
+		_ = runner.Telemetry().Send(ctx, evt)
+
+		logger.Error("error scraping job", "job_id", job.ID, "error", err)
+
+		return
+	}
+
+	params := map[string]any{
+		"job_count": len(job.Data.Keywords),
+		"duration":  time.Now().UTC().Sub(t0).String(),
 	}
 
+	_ = runner.Telemetry().Send(ctx, tlmt.NewEvent("web_runner", params))
+
+	logger.Info("job scraped successfully", "job_id", job.ID)
+}
+
+// schedule creates the jobs of the schedules that are due. The jobs are
+// picked up by work like the ones created from the API.
+func (w *webrunner) schedule(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if err := w.svc.RunDueSchedules(ctx, now.UTC()); err != nil {
+				logger.Error("error running schedules", "error", err)
+			}
+		}
+	}
+}
+
+// deliver sends the webhooks of the finished jobs, and tries the failed
+// ones again once their backoff is over.
+func (w *webrunner) deliver(ctx context.Context) error {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if err := w.svc.DeliverWebhooks(ctx, now.UTC()); err != nil {
+				logger.Error("error delivering webhooks", "error", err)
+			}
+		}
+	}
+}
+
+// scrapeJob runs a job that was started with svc.StartJob. jobCtx is the
+// context whose cancel func was registered.
+func (w *webrunner) scrapeJob(ctx, jobCtx context.Context, job *web.Job) error {
 	if len(job.Data.Keywords) == 0 {
 		job.Status = web.StatusFailed
 
 		return w.svc.Update(ctx, job)
 	}
 
-	// Using RotatingCsvWriter for file rotation
-	baseName := filepath.Join(w.cfg.DataFolder, job.ID)
-	// Remove .csv extension if present (though created by join above without it usually)
-	// but here job.ID is likely just UUID.
+	resultWriter := NewResultWriter(w.svc, job.ID)
 
-	// We initiate the rotating writer
-	// It will create files like jobID_1.csv, jobID_2.csv, etc.
-	rotatingWriter := NewRotatingCsvWriter(baseName, 50000)
 	var countWg sync.WaitGroup
-	rotatingWriter.OnWrite = func(amount int) {
+
+	// written counts the places for the progress events, on top of the
+	// ones of the earlier runs of a resumed job
+	var written atomic.Int64
+
+	written.Store(int64(job.Count))
+
+	resultWriter.OnWrite = func(amount int) {
+		written.Add(int64(amount))
+
 		countWg.Add(1)
 		go func() {
 			defer countWg.Done()
-			_ = w.svc.IncrementJobCount(context.Background(), job.ID, amount)
+			err := w.svc.RecordPlaces(context.Background(), job, amount)
+			if errors.Is(err, web.ErrQuotaExceeded) {
+				logger.Warn("owner reached the daily places quota, pausing job", "job_id", job.ID, "owner", job.Owner, "error", err)
+			}
 		}()
 	}
-	// rotatingWriter := NewRotatingCsvWriter(baseName, 10) // TEST LIMIT
+	resultWriter.OnEntry = func(entry *gmaps.Entry) {
+		if w.svc.HasSubscribers(job.ID) {
+			w.svc.Publish(job.ID, web.Event{Type: web.EventPlace, Data: web.NewPlaceEvent(entry)})
+		}
+	}
+
+	defer w.proxies.release(job.ID)
 
-	mate, err := w.setupMate(ctx, rotatingWriter, job)
+	dedup, err := runner.NewDeduper(w.cfg, dedupNamespace(job))
 	if err != nil {
 		job.Status = web.StatusFailed
-		_ = rotatingWriter.Close()
 
 		err2 := w.svc.Update(ctx, job)
 		if err2 != nil {
@@ -176,39 +387,51 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 	}
 
 	defer func() {
-		_ = rotatingWriter.Close()
-		_ = mate.Close()
+		_ = deduper.Close(dedup)
 	}()
 
-	var coords string
-	if job.Data.Lat != "" && job.Data.Lon != "" {
-		coords = job.Data.Lat + "," + job.Data.Lon
+	mate, err := w.setupMate(ctx, runner.DedupWriter(dedup, runner.WriteThrough(resultWriter)), job)
+	if err != nil {
+		job.Status = web.StatusFailed
+
+		err2 := w.svc.Update(ctx, job)
+		if err2 != nil {
+			logger.Error("failed to update job status", "error", err2)
+		}
+
+		return err
 	}
 
-	dedup := deduper.New()
-	exitMonitor := exiter.New()
+	defer func() {
+		_ = mate.Close()
+	}()
 
-	seedJobs, err := runner.CreateSeedJobs(
-		job.Data.FastMode,
-		job.Data.Lang,
-		strings.NewReader(strings.Join(job.Data.Keywords, "\n")),
-		job.Data.Depth,
-		job.Data.Email,
-		coords,
-		job.Data.Zoom,
-		func() float64 {
-			if job.Data.Radius <= 0 {
-				return 10000 // 10 km
-			}
+	exitMonitor := exiter.New(exiter.WithSnapshots(2*time.Second, func(p exiter.Progress) {
+		w.svc.Publish(job.ID, web.Event{
+			Type: web.EventProgress,
+			Data: web.ProgressEvent{Progress: p, Count: int(written.Load())},
+		})
+	}))
 
-			return float64(job.Data.Radius)
-		}(),
-		dedup,
-		exitMonitor,
-		w.cfg.ExtraReviews,
-		job.Data.SearchDelay,
-	)
+	reviewOpts, err := job.Data.ReviewOptions()
 	if err != nil {
+		job.Status = web.StatusFailed
+
+		err2 := w.svc.Update(ctx, job)
+		if err2 != nil {
+			logger.Error("failed to update job status", "error", err2)
+		}
+
+		return err
+	}
+
+	share := w.budget.Share(job.Data.Priority)
+	defer share.Close()
+
+	seedJobs, err := w.createSeedJobs(job, share, dedup, exitMonitor, reviewOpts)
+	if err != nil {
+		job.Status = web.StatusFailed
+
 		err2 := w.svc.Update(ctx, job)
 		if err2 != nil {
 			logger.Error("failed to update job status", "error", err2)
@@ -225,7 +448,8 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 		if !job.Data.FastMode {
 			estimatedPerJob = 45 // normal mode with browser is slower
 		}
-		concurrency := max(1, w.cfg.Concurrency)
+		// the fair share of the budget when cfg.WebJobs jobs run
+		concurrency := max(1, w.cfg.Concurrency/max(1, w.cfg.WebJobs))
 		minimumRequired := len(seedJobs)*estimatedPerJob*max(1, job.Data.Depth)/concurrency + 120
 		if minimumRequired < 180 {
 			minimumRequired = 180
@@ -250,7 +474,7 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 
 		logger.Info("running job", "job_id", job.ID, "seed_jobs", len(seedJobs), "allowed_seconds", allowedSeconds, "concurrency", concurrency)
 
-		mateCtx, cancel := context.WithTimeout(ctx, time.Duration(allowedSeconds)*time.Second)
+		mateCtx, cancel := context.WithTimeout(jobCtx, time.Duration(allowedSeconds)*time.Second)
 		defer cancel()
 
 		exitMonitor.SetCancelFunc(cancel)
@@ -258,7 +482,7 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 		go exitMonitor.Run(mateCtx)
 
 		err = mate.Start(mateCtx, seedJobs...)
-		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) && context.Cause(jobCtx) == nil {
 			cancel()
 
 			job.Status = web.StatusFailed
@@ -273,24 +497,99 @@ func (w *webrunner) scrapeJob(ctx context.Context, job *web.Job) error {
 		cancel()
 	}
 
-	// Explicitly close writer to flush all data (idempotent, safe with defer)
-	_ = rotatingWriter.Close()
-
 	// Wait for all pending count update goroutines to complete
 	countWg.Wait()
 
-	// Merge rotated CSV files into a single file and count actual rows
-	actualCount, mergeErr := mergeCSVFiles(w.cfg.DataFolder, job.ID)
-	if mergeErr != nil {
-		logger.Warn("failed to merge CSV files", "error", mergeErr)
+	actualCount, err := w.svc.CountPlaces(ctx, job.ID)
+	if err != nil {
+		logger.Warn("failed to count places", "job_id", job.ID, "error", err)
+	} else {
+		job.Count = actualCount
 	}
 
-	job.Count = actualCount
 	job.Status = web.StatusOK
 
+	// partial results are kept like on timeout
+	switch cause := context.Cause(jobCtx); {
+	case errors.Is(cause, web.ErrJobDeleted):
+		// remove the files written since the job was deleted
+		return w.svc.Delete(ctx, job.ID)
+	case errors.Is(cause, web.ErrJobCancelled):
+		job.Status = web.StatusCancelled
+	case errors.Is(cause, web.ErrJobPaused), errors.Is(cause, web.ErrQuotaExceeded):
+		job.Status = web.StatusPaused
+	}
+
 	return w.svc.Update(ctx, job)
 }
 
+// dedupNamespace is the default dedup namespace of the job: jobs of the
+// same owner with the same queries and search settings share it, like the
+// runs of a schedule or a resumed job, so with a persistent deduper they
+// skip the places written before.
+func dedupNamespace(job *web.Job) string {
+	data, _ := json.Marshal(struct {
+		Owner    string
+		Keywords []string
+		Lang     string
+		Zoom     int
+		Lat, Lon string
+		FastMode bool
+		Radius   int
+		Depth    int
+	}{
+		Owner:    job.Owner,
+		Keywords: job.Data.Keywords,
+		Lang:     job.Data.Lang,
+		Zoom:     job.Data.Zoom,
+		Lat:      job.Data.Lat,
+		Lon:      job.Data.Lon,
+		FastMode: job.Data.FastMode,
+		Radius:   job.Data.Radius,
+		Depth:    job.Data.Depth,
+	})
+
+	sum := sha256.Sum256(data)
+
+	return "web:" + hex.EncodeToString(sum[:8])
+}
+
+// createSeedJobs returns the seed jobs of the web job, which take the slots
+// of the share for their requests. The jobs of all the web jobs share the
+// cool-down after a block, as they share the rate limit.
+func (w *webrunner) createSeedJobs(job *web.Job, share *budget.Share, dedup deduper.Deduper, exitMonitor exiter.Exiter, reviewOpts gmaps.ReviewOptions) ([]scrapemate.IJob, error) {
+	var coords string
+	if job.Data.Lat != "" && job.Data.Lon != "" {
+		coords = job.Data.Lat + "," + job.Data.Lon
+	}
+
+	return runner.CreateSeedJobs(
+		job.Data.FastMode,
+		job.Data.Lang,
+		strings.NewReader(strings.Join(job.Data.Keywords, "\n")),
+		runner.InputFormatText,
+		job.Data.Depth,
+		job.Data.Email,
+		coords,
+		job.Data.Zoom,
+		func() float64 {
+			if job.Data.Radius <= 0 {
+				return 10000 // 10 km
+			}
+
+			return float64(job.Data.Radius)
+		}(),
+		dedup,
+		exitMonitor,
+		w.cfg.ExtraReviews || job.Data.ExtraReviews,
+		reviewOpts,
+		job.Data.SearchDelay,
+		w.backoff.Scoped(runner.OnBlock(w.proxies.gateway(job.ID), job.ID)),
+		w.limiter.Scoped(job.ID),
+		share,
+	)
+}
+
 func (w *webrunner) setupMate(_ context.Context, writer scrapemate.ResultWriter, job *web.Job) (*scrapemateapp.ScrapemateApp, error) {
 	opts := []func(*scrapemateapp.Config) error{
 		scrapemateapp.WithConcurrency(w.cfg.Concurrency),
@@ -304,19 +603,19 @@ func (w *webrunner) setupMate(_ context.Context, writer scrapemate.ResultWriter,
 	} else {
 		opts = append(opts,
 			scrapemateapp.WithStealth("firefox"),
+			scrapemateapp.WithProvider(gmaps.NewWaitProvider(memprovider.New())),
 		)
 	}
 
-	hasProxy := false
+	proxyURL, err := w.proxies.acquire(job)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(w.cfg.Proxies) > 0 {
-		opts = append(opts, scrapemateapp.WithProxies(w.cfg.Proxies))
-		hasProxy = true
-	} else if len(job.Data.Proxies) > 0 {
-		opts = append(opts,
-			scrapemateapp.WithProxies(job.Data.Proxies),
-		)
-		hasProxy = true
+	hasProxy := proxyURL != ""
+
+	if hasProxy {
+		opts = append(opts, scrapemateapp.WithProxies([]string{proxyURL}))
 	}
 
 	if !w.cfg.DisablePageReuse {
@@ -327,7 +626,6 @@ func (w *webrunner) setupMate(_ context.Context, writer scrapemate.ResultWriter,
 
 	logger.Info("job proxy status", "job_id", job.ID, "has_proxy", hasProxy)
 
-	// writer is already a scrapemate.ResultWriter (RotatingCsvWriter)
 	writers := []scrapemate.ResultWriter{writer}
 	matecfg, err := scrapemateapp.NewConfig(
 		writers,
@@ -339,75 +637,3 @@ func (w *webrunner) setupMate(_ context.Context, writer scrapemate.ResultWriter,
 
 	return scrapemateapp.NewScrapeMateApp(matecfg)
 }
-
-// mergeCSVFiles merges all rotated CSV files ({jobID}_1.csv, {jobID}_2.csv, ...)
-// into a single {jobID}.csv file and returns the actual data row count.
-func mergeCSVFiles(dataFolder, jobID string) (int, error) {
-	pattern := filepath.Join(dataFolder, jobID+"_*.csv")
-	matches, err := filepath.Glob(pattern)
-	if err != nil || len(matches) == 0 {
-		return 0, err
-	}
-
-	sort.Strings(matches)
-
-	outputPath := filepath.Join(dataFolder, jobID+".csv")
-	outFile, err := os.Create(outputPath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create merged file: %w", err)
-	}
-	defer outFile.Close()
-
-	// Write BOM for Excel compatibility
-	outFile.Write([]byte{0xEF, 0xBB, 0xBF})
-
-	writer := csv.NewWriter(outFile)
-	totalRows := 0
-	headerWritten := false
-
-	for _, match := range matches {
-		records, readErr := readCSVWithBOM(match)
-		if readErr != nil || len(records) == 0 {
-			continue
-		}
-
-		startIdx := 0
-		if !headerWritten {
-			writer.Write(records[0])
-			headerWritten = true
-			startIdx = 1
-		} else {
-			startIdx = 1 // skip header from subsequent files
-		}
-
-		for i := startIdx; i < len(records); i++ {
-			writer.Write(records[i])
-			totalRows++
-		}
-	}
-
-	writer.Flush()
-
-	// Delete the rotated source files
-	for _, match := range matches {
-		os.Remove(match)
-	}
-
-	return totalRows, nil
-}
-
-// readCSVWithBOM reads a CSV file, stripping UTF-8 BOM if present.
-func readCSVWithBOM(filePath string) ([][]string, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-
-	// Strip UTF-8 BOM if present
-	if len(data) >= 3 && data[0] == 0xEF && data[1] == 0xBB && data[2] == 0xBF {
-		data = data[3:]
-	}
-
-	reader := csv.NewReader(strings.NewReader(string(data)))
-	return reader.ReadAll()
-}
@@ -0,0 +1,59 @@
+package webrunner
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gosom/scrapemate"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/proxypool"
+	"github.com/gosom/google-maps-scraper/runner"
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+func Test_SharedBackoff(t *testing.T) {
+	const cooldown = 100 * time.Millisecond
+
+	cfg := &runner.Config{BlockCooldown: cooldown}
+	w := &webrunner{
+		cfg:     cfg,
+		proxies: &proxies{cfg: cfg, jobs: map[string]*proxypool.Gateway{}},
+		backoff: runner.NewBackoff(cfg, nil, ""),
+	}
+
+	search := func(id string) *gmaps.SearchJob {
+		job := web.Job{ID: id, Data: web.JobData{
+			Keywords: []string{"cafe"},
+			Lang:     "en",
+			Lat:      "37.98",
+			Lon:      "23.73",
+			FastMode: true,
+		}}
+
+		seeds, err := w.createSeedJobs(&job, nil, nil, exiter.New(), gmaps.ReviewOptions{})
+		require.NoError(t, err)
+		require.Len(t, seeds, 1)
+
+		ans, ok := seeds[0].(*gmaps.SearchJob)
+		require.True(t, ok)
+
+		return ans
+	}
+
+	blocked, other := search("a"), search("b")
+
+	start := time.Now()
+	resp := scrapemate.Response{StatusCode: http.StatusTooManyRequests}
+
+	_, next, err := blocked.Process(t.Context(), &resp)
+	require.NoError(t, err)
+	require.Len(t, next, 1)
+
+	// the block of one web job holds back the requests of the others
+	require.NoError(t, other.BeforeFetch(t.Context()))
+	require.GreaterOrEqual(t, time.Since(start), cooldown)
+}
@@ -0,0 +1,208 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// tokenPrefix starts every API token, so leaked tokens are easy to
+	// find in logs and repositories.
+	tokenPrefix = "gms_"
+	// sessionTTL is how long a dashboard login lasts.
+	sessionTTL = 7 * 24 * time.Hour
+)
+
+// TokenRepository stores the API tokens and the dashboard sessions. Only
+// the SHA-256 hashes of the secrets are stored. It is optional, the service
+// checks if the job repository implements it.
+type TokenRepository interface {
+	CreateToken(ctx context.Context, token *Token, hash string) error
+	SelectTokens(ctx context.Context) ([]Token, error)
+	// RevokeToken revokes the token and deletes its sessions.
+	RevokeToken(ctx context.Context, id string, at time.Time) error
+	// TokenByHash returns ErrNotFound for unknown hashes.
+	TokenByHash(ctx context.Context, hash string) (Token, error)
+	CreateSession(ctx context.Context, hash, tokenID string, expiresAt time.Time) error
+	// SessionToken returns the token of an unexpired session, or
+	// ErrNotFound.
+	SessionToken(ctx context.Context, hash string, now time.Time) (Token, error)
+	DeleteSession(ctx context.Context, hash string) error
+}
+
+// Token is an API token. The secret is only known when it is created.
+type Token struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Owner is the user or team of the token. Requests with the token only
+	// see the jobs and schedules of the owner.
+	Owner string `json:"owner"`
+	// Admin tokens see the jobs and schedules of every owner, and those
+	// without an owner, which were created before authentication was
+	// turned on. Their own jobs have no owner and no quota.
+	Admin bool `json:"admin,omitempty"`
+	// Hint is the start of the secret, to tell the tokens apart.
+	Hint      string    `json:"hint"`
+	CreatedAt time.Time `json:"created_at"`
+	RevokedAt time.Time `json:"revoked_at,omitzero"`
+}
+
+func (t *Token) Revoked() bool {
+	return !t.RevokedAt.IsZero()
+}
+
+// CreateToken creates an API token for the owner and returns it with its
+// secret. The owner defaults to the name of the token.
+func (s *Service) CreateToken(ctx context.Context, name, owner string, admin bool) (Token, string, error) {
+	if s.tokens == nil {
+		return Token{}, "", ErrNoAuth
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return Token{}, "", errors.New("missing name")
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return Token{}, "", err
+	}
+
+	secret = tokenPrefix + secret
+
+	if owner == "" {
+		owner = name
+	}
+
+	token := Token{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Owner:     owner,
+		Admin:     admin,
+		Hint:      secret[:len(tokenPrefix)+6],
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.tokens.CreateToken(ctx, &token, hashSecret(secret)); err != nil {
+		return Token{}, "", err
+	}
+
+	return token, secret, nil
+}
+
+func (s *Service) Tokens(ctx context.Context) ([]Token, error) {
+	if s.tokens == nil {
+		return nil, ErrNoAuth
+	}
+
+	return s.tokens.SelectTokens(ctx)
+}
+
+// RevokeToken revokes the token. Requests with it and dashboard sessions
+// that logged in with it are rejected from then on.
+func (s *Service) RevokeToken(ctx context.Context, id string) error {
+	if s.tokens == nil {
+		return ErrNoAuth
+	}
+
+	return s.tokens.RevokeToken(ctx, id, time.Now().UTC())
+}
+
+// Authenticate returns the token with the secret. It returns
+// ErrUnauthorized for unknown and revoked tokens.
+func (s *Service) Authenticate(ctx context.Context, secret string) (Token, error) {
+	if s.tokens == nil {
+		return Token{}, ErrNoAuth
+	}
+
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return Token{}, ErrUnauthorized
+	}
+
+	token, err := s.tokens.TokenByHash(ctx, hashSecret(secret))
+	if errors.Is(err, ErrNotFound) || (err == nil && token.Revoked()) {
+		return Token{}, ErrUnauthorized
+	}
+
+	return token, err
+}
+
+// Scope returns the context for the requests with the token: the owner of
+// the token, or every owner for admin tokens.
+func (t *Token) Scope(ctx context.Context) context.Context {
+	if t.Admin {
+		return ctx
+	}
+
+	return WithOwner(ctx, t.Owner)
+}
+
+// Login creates a dashboard session for the API token and returns the
+// session id.
+func (s *Service) Login(ctx context.Context, secret string) (string, error) {
+	token, err := s.Authenticate(ctx, secret)
+	if err != nil {
+		return "", err
+	}
+
+	session, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().UTC().Add(sessionTTL)
+
+	if err := s.tokens.CreateSession(ctx, hashSecret(session), token.ID, expiresAt); err != nil {
+		return "", err
+	}
+
+	return session, nil
+}
+
+// AuthenticateSession returns the token that the session logged in with.
+// It returns ErrUnauthorized for unknown and expired sessions.
+func (s *Service) AuthenticateSession(ctx context.Context, session string) (Token, error) {
+	if s.tokens == nil {
+		return Token{}, ErrNoAuth
+	}
+
+	token, err := s.tokens.SessionToken(ctx, hashSecret(session), time.Now().UTC())
+	if errors.Is(err, ErrNotFound) || (err == nil && token.Revoked()) {
+		return Token{}, ErrUnauthorized
+	}
+
+	return token, err
+}
+
+func (s *Service) Logout(ctx context.Context, session string) error {
+	if s.tokens == nil {
+		return ErrNoAuth
+	}
+
+	return s.tokens.DeleteSession(ctx, hashSecret(session))
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// hashSecret hashes a token or session secret. The secrets are random, so
+// a fast unsalted hash is enough.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
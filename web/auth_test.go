@@ -0,0 +1,200 @@
+package web_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func newAuthServer(t *testing.T) (*web.Service, web.TokenRepository, http.Handler) {
+	t.Helper()
+
+	svc, repo := newTestService(t)
+
+	srv, err := web.New(svc, ":0", web.WithAuth())
+	require.NoError(t, err)
+
+	return svc, repo.(web.TokenRepository), srv.Handler()
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	return rec
+}
+
+func Test_AuthTokenHash(t *testing.T) {
+	svc, tokens, _ := newAuthServer(t)
+
+	token, secret, err := svc.CreateToken(t.Context(), "ci", "", false)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, "gms_"))
+	require.True(t, strings.HasPrefix(secret, token.Hint))
+	require.Equal(t, "ci", token.Owner)
+
+	// only the hash of the secret is stored
+	stored, err := tokens.TokenByHash(t.Context(), hashSecret(secret))
+	require.NoError(t, err)
+	require.Equal(t, token.ID, stored.ID)
+
+	_, err = tokens.TokenByHash(t.Context(), secret)
+	require.ErrorIs(t, err, web.ErrNotFound)
+
+	_, err = svc.Authenticate(t.Context(), secret+"x")
+	require.ErrorIs(t, err, web.ErrUnauthorized)
+
+	// secrets without the prefix are not looked up
+	_, err = svc.Authenticate(t.Context(), strings.TrimPrefix(secret, "gms_"))
+	require.ErrorIs(t, err, web.ErrUnauthorized)
+}
+
+func Test_AuthAPIToken(t *testing.T) {
+	svc, _, h := newAuthServer(t)
+
+	token, secret, err := svc.CreateToken(t.Context(), "ci", "", false)
+	require.NoError(t, err)
+
+	bearer := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", http.NoBody)
+	bearer.Header.Set("Authorization", "Bearer "+secret)
+
+	require.Equal(t, http.StatusOK, serve(h, bearer).Code)
+
+	apiKey := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", http.NoBody)
+	apiKey.Header.Set("X-API-Key", secret)
+
+	require.Equal(t, http.StatusOK, serve(h, apiKey).Code)
+
+	require.NoError(t, svc.RevokeToken(t.Context(), token.ID))
+
+	rec := serve(h, bearer)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serve(h, apiKey)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func Test_AuthUnauthorizedResponses(t *testing.T) {
+	_, _, h := newAuthServer(t)
+
+	// the API answers with a JSON error
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/api/v1/jobs", http.NoBody))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, `Bearer realm="api"`, rec.Header().Get("WWW-Authenticate"))
+
+	var body map[string]any
+
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.EqualValues(t, http.StatusUnauthorized, body["code"])
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", http.NoBody)
+	req.Header.Set("Authorization", "Bearer gms_unknown")
+
+	require.Equal(t, http.StatusUnauthorized, serve(h, req).Code)
+
+	// the dashboard sends browsers to the login page
+	rec = serve(h, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Equal(t, "/login", rec.Header().Get("Location"))
+
+	req = httptest.NewRequest(http.MethodGet, "/jobs", http.NoBody)
+	req.Header.Set("HX-Request", "true")
+
+	rec = serve(h, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, "/login", rec.Header().Get("HX-Redirect"))
+
+	rec = serve(h, httptest.NewRequest(http.MethodPost, "/scrape", http.NoBody))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Empty(t, rec.Header().Get("Location"))
+
+	// the login page itself is open
+	rec = serve(h, httptest.NewRequest(http.MethodGet, "/login", http.NoBody))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func login(t *testing.T, h http.Handler, secret string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	form := url.Values{"token": {secret}}
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return serve(h, req)
+}
+
+func Test_AuthSession(t *testing.T) {
+	svc, tokens, h := newAuthServer(t)
+
+	token, secret, err := svc.CreateToken(t.Context(), "ci", "", false)
+	require.NoError(t, err)
+
+	rec := login(t, h, "gms_wrong")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Empty(t, rec.Result().Cookies())
+
+	rec = login(t, h, secret)
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.True(t, cookies[0].HttpOnly)
+
+	session := cookies[0]
+
+	withSession := func(c *http.Cookie) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", http.NoBody)
+		req.AddCookie(c)
+
+		return req
+	}
+
+	require.Equal(t, http.StatusOK, serve(h, withSession(session)).Code)
+
+	// the session id is hashed like the tokens
+	stored, err := tokens.SessionToken(t.Context(), hashSecret(session.Value), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, token.ID, stored.ID)
+
+	// an expired session is rejected
+	expired := "expired-session"
+	require.NoError(t, tokens.CreateSession(t.Context(), hashSecret(expired), token.ID, time.Now().Add(-time.Minute)))
+
+	require.Equal(t, http.StatusUnauthorized, serve(h, withSession(&http.Cookie{Name: session.Name, Value: expired})).Code)
+
+	// revoking the token ends its sessions
+	require.NoError(t, svc.RevokeToken(t.Context(), token.ID))
+
+	require.Equal(t, http.StatusUnauthorized, serve(h, withSession(session)).Code)
+
+	// logging out deletes the session
+	_, secret, err = svc.CreateToken(t.Context(), "other", "", false)
+	require.NoError(t, err)
+
+	session = login(t, h, secret).Result().Cookies()[0]
+	require.Equal(t, http.StatusOK, serve(h, withSession(session)).Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", http.NoBody)
+	req.AddCookie(session)
+
+	rec = serve(h, req)
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	require.Equal(t, http.StatusUnauthorized, serve(h, withSession(session)).Code)
+}
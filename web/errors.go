@@ -5,4 +5,32 @@ import "errors"
 var (
 	ErrNotFound      = errors.New("not found")
 	ErrAlreadyExists = errors.New("already exists")
+	ErrNoSchedules   = errors.New("schedules are not supported by the job repository")
+	ErrInvalidStatus = errors.New("invalid job status")
+	ErrNoAuth        = errors.New("authentication is not supported by the job repository")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrNoQuotas      = errors.New("quotas are not supported by the job repository")
+	// ErrQuotaExceeded is also the cause of the cancelled context of a job
+	// that was paused because its owner reached the daily places quota.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrNoWebhooks    = errors.New("webhooks are not supported by the job repository")
+	// ErrNoWebhookSecret is returned for webhooks that would be sent
+	// unsigned.
+	ErrNoWebhookSecret = errors.New("webhooks need a secret to sign the payloads")
+	ErrNoPlaces        = errors.New("places are not supported by the job repository")
+	// ErrPrivateWebhook is returned for the webhook URLs of loopback,
+	// link-local and private addresses, unless WebhookConfig.AllowPrivate
+	// is set.
+	ErrPrivateWebhook = errors.New("webhook url is a loopback, link-local or private address")
+	// ErrLegacyResults is returned for formats that need the places of jobs
+	// that ran before the results were stored in the database.
+	ErrLegacyResults = errors.New("the results of the job are only available as csv")
+)
+
+// ErrJobCancelled, ErrJobPaused and ErrJobDeleted are the causes given to
+// the context of a running job when it is stopped from the API.
+var (
+	ErrJobCancelled = errors.New("job cancelled")
+	ErrJobPaused    = errors.New("job paused")
+	ErrJobDeleted   = errors.New("job deleted")
 )
@@ -0,0 +1,146 @@
+package web
+
+import (
+	"sync"
+
+	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+const (
+	// EventStatus has a StatusEvent, sent when the status or the count of
+	// a job changes.
+	EventStatus = "status"
+	// EventProgress has a ProgressEvent, sent every few seconds while a job
+	// runs.
+	EventProgress = "progress"
+	// EventPlace has a PlaceEvent, sent for each place written.
+	EventPlace = "place"
+)
+
+// eventBuffer is how many events a subscriber can fall behind before it
+// misses events.
+const eventBuffer = 64
+
+// Event is an update of a running job.
+type Event struct {
+	Type string
+	Data any
+}
+
+// ProgressEvent is the progress of a running job with the number of places
+// written so far.
+type ProgressEvent struct {
+	exiter.Progress
+	Count int `json:"count"`
+}
+
+type StatusEvent struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// PlaceEvent is a place that was written to the results of a job.
+type PlaceEvent struct {
+	Title       string  `json:"title"`
+	Category    string  `json:"category"`
+	Address     string  `json:"address"`
+	Link        string  `json:"link"`
+	Cid         string  `json:"cid"`
+	DataID      string  `json:"data_id"`
+	ReviewCount int     `json:"review_count"`
+	Rating      float64 `json:"review_rating"`
+}
+
+func NewPlaceEvent(e *gmaps.Entry) PlaceEvent {
+	return PlaceEvent{
+		Title:       e.Title,
+		Category:    e.Category,
+		Address:     e.Address,
+		Link:        e.Link,
+		Cid:         e.Cid,
+		DataID:      e.DataID,
+		ReviewCount: e.ReviewCount,
+		Rating:      e.ReviewRating,
+	}
+}
+
+// IsFinal reports whether no more events follow an event with the status.
+func IsFinal(status string) bool {
+	switch status {
+	case StatusOK, StatusFailed, StatusCancelled, StatusPaused:
+		return true
+	default:
+		return false
+	}
+}
+
+// eventHub fans out the events of each job to its subscribers. Events are
+// dropped for subscribers that do not keep up, rather than slowing the job
+// down.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan Event]struct{}
+}
+
+// Subscribe returns the events of the job and a func that unsubscribes.
+func (s *Service) Subscribe(id string) (<-chan Event, func()) {
+	h := &s.events
+
+	ch := make(chan Event, eventBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subs == nil {
+		h.subs = map[string]map[chan Event]struct{}{}
+	}
+
+	if h.subs[id] == nil {
+		h.subs[id] = map[chan Event]struct{}{}
+	}
+
+	h.subs[id][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subs[id], ch)
+
+		if len(h.subs[id]) == 0 {
+			delete(h.subs, id)
+		}
+	}
+}
+
+// Publish sends the event to the subscribers of the job.
+func (s *Service) Publish(id string, evt Event) {
+	h := &s.events
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs[id] {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+// HasSubscribers reports whether anyone listens to the events of the job,
+// so that expensive events can be skipped.
+func (s *Service) HasSubscribers(id string) bool {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+
+	return len(s.events.subs[id]) > 0
+}
+
+func (s *Service) publishStatus(job *Job) {
+	s.Publish(job.ID, Event{
+		Type: EventStatus,
+		Data: StatusEvent{Status: job.Status, Count: job.Count},
+	})
+}
@@ -0,0 +1,193 @@
+package web_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/exiter"
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+func Test_EventHub(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	require.False(t, svc.HasSubscribers("job"))
+
+	a, unsubscribeA := svc.Subscribe("job")
+	b, unsubscribeB := svc.Subscribe("job")
+	other, unsubscribeOther := svc.Subscribe("other")
+
+	defer unsubscribeOther()
+
+	require.True(t, svc.HasSubscribers("job"))
+
+	evt := web.Event{Type: web.EventStatus, Data: web.StatusEvent{Status: web.StatusWorking}}
+	svc.Publish("job", evt)
+
+	require.Equal(t, evt, <-a)
+	require.Equal(t, evt, <-b)
+	require.Empty(t, other)
+
+	unsubscribeB()
+	require.True(t, svc.HasSubscribers("job"))
+
+	// a subscriber that does not keep up misses the events over its
+	// buffer, the publisher does not wait for it
+	published := make(chan struct{})
+
+	go func() {
+		defer close(published)
+
+		for i := range 100 {
+			svc.Publish("job", web.Event{Type: web.EventProgress, Data: web.ProgressEvent{Count: i}})
+		}
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publishing blocked on a full subscriber")
+	}
+
+	require.Len(t, a, cap(a))
+	require.Equal(t, 0, (<-a).Data.(web.ProgressEvent).Count)
+
+	unsubscribeA()
+	require.False(t, svc.HasSubscribers("job"))
+
+	// publishing without subscribers is fine
+	svc.Publish("job", evt)
+}
+
+type sseEvent struct {
+	typ  string
+	data string
+}
+
+// readEvents returns the events of the stream until it is closed.
+func readEvents(body io.Reader, ch chan<- sseEvent) {
+	defer close(ch)
+
+	var evt sseEvent
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			evt.typ = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			evt.data = strings.TrimPrefix(line, "data: ")
+		case line == "" && evt.typ != "":
+			ch <- evt
+			evt = sseEvent{}
+		}
+	}
+}
+
+func Test_JobEventsStream(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusWorking)
+
+	srv, err := web.New(svc, ":0")
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/v1/jobs/" + job.ID + "/events")
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan sseEvent)
+
+	go readEvents(resp.Body, events)
+
+	next := func() sseEvent {
+		t.Helper()
+
+		select {
+		case evt, ok := <-events:
+			require.True(t, ok, "the stream was closed")
+
+			return evt
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+		}
+
+		return sseEvent{}
+	}
+
+	// the current status comes first
+	evt := next()
+	require.Equal(t, web.EventStatus, evt.typ)
+	require.JSONEq(t, `{"status": "working", "count": 0}`, evt.data)
+
+	svc.Publish(job.ID, web.Event{
+		Type: web.EventProgress,
+		Data: web.ProgressEvent{Progress: exiter.Progress{SeedCount: 2, SeedCompleted: 1}, Count: 7},
+	})
+
+	evt = next()
+	require.Equal(t, web.EventProgress, evt.typ)
+
+	var progress web.ProgressEvent
+
+	require.NoError(t, json.Unmarshal([]byte(evt.data), &progress))
+	require.Equal(t, 7, progress.Count)
+	require.Equal(t, 2, progress.SeedCount)
+
+	job.Status = web.StatusOK
+	job.Count = 7
+
+	require.NoError(t, svc.Update(t.Context(), &job))
+
+	// the final status ends the stream
+	evt = next()
+	require.Equal(t, web.EventStatus, evt.typ)
+	require.JSONEq(t, `{"status": "ok", "count": 7}`, evt.data)
+
+	select {
+	case _, ok := <-events:
+		require.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream was not closed")
+	}
+
+	require.Eventually(t, func() bool { return !svc.HasSubscribers(job.ID) }, time.Second, 10*time.Millisecond)
+}
+
+func Test_JobEventsFinishedJob(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusCancelled)
+
+	srv, err := web.New(svc, ":0")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+job.ID+"/events", http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "event: status\ndata: {\"status\":\"cancelled\",\"count\":0}\n\n", rec.Body.String())
+	require.False(t, svc.HasSubscribers(job.ID))
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+strings.Repeat("0", 8)+"-0000-0000-0000-000000000000/events", http.NoBody))
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,248 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+// numericColumns are written as numbers rather than text, so they can be
+// sorted and summed in a spreadsheet.
+var numericColumns = map[string]bool{
+	"review_count":  true,
+	"review_rating": true,
+	"latitude":      true,
+	"longitude":     true,
+	"rating":        true,
+}
+
+// WriteExcel writes the results of the job as XLSX, only with the fields
+// when there are any. The rows are streamed to temporary files rather than
+// kept in memory, and a sheet that reaches the row limit of Excel goes on in
+// a new one. The opening hours and the reviews are on sheets of their own.
+func (s *Service) WriteExcel(ctx context.Context, w io.Writer, id string, fields []string) error {
+	f := excelize.NewFile()
+
+	defer func() {
+		// removes the temporary files
+		_ = f.Close()
+	}()
+
+	places := &xlsxSheet{file: f, name: "Places"}
+
+	if err := f.SetSheetName("Sheet1", places.name); err != nil {
+		return err
+	}
+
+	var (
+		header  []string
+		columns []int
+		index   = map[string]int{}
+		hours   *xlsxSheet
+		reviews *xlsxSheet
+	)
+
+	column := func(record []string, name string) string {
+		if idx, ok := index[name]; ok && idx < len(record) {
+			return record[idx]
+		}
+
+		return ""
+	}
+
+	err := s.eachRecord(ctx, id, func(record []string) error {
+		if header == nil {
+			header = record
+
+			for i, h := range header {
+				index[h] = i
+			}
+
+			for _, idx := range selectColumns(header, fields) {
+				switch header[idx] {
+				case "open_hours":
+					hours = &xlsxSheet{file: f, name: "Hours"}
+				case "user_reviews", "user_reviews_extended":
+					reviews = &xlsxSheet{file: f, name: "Reviews"}
+				default:
+					columns = append(columns, idx)
+				}
+			}
+
+			places.header = pickColumns(header, columns)
+
+			if hours != nil {
+				hours.header = []string{"data_id", "title", "day", "hours"}
+			}
+
+			if reviews != nil {
+				reviews.header = (&gmaps.ReviewRow{}).CsvHeaders()
+			}
+
+			return nil
+		}
+
+		if err := places.add(pickColumns(record, columns)); err != nil {
+			return err
+		}
+
+		if hours != nil {
+			var openHours map[string][]string
+
+			// the column is empty or "null" for places without hours
+			_ = json.Unmarshal([]byte(column(record, "open_hours")), &openHours)
+
+			for _, day := range slices.Sorted(maps.Keys(openHours)) {
+				row := []string{column(record, "data_id"), column(record, "title"), day, strings.Join(openHours[day], ", ")}
+
+				if err := hours.add(row); err != nil {
+					return err
+				}
+			}
+		}
+
+		if reviews != nil {
+			var inline, extended []gmaps.Review
+
+			_ = json.Unmarshal([]byte(column(record, "user_reviews")), &inline)
+			_ = json.Unmarshal([]byte(column(record, "user_reviews_extended")), &extended)
+
+			rows := gmaps.NewReviewRows(column(record, "data_id"), column(record, "cid"), inline, extended)
+			for i := range rows {
+				if err := reviews.add(rows[i].CsvRow()); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sheet := range []*xlsxSheet{places, hours, reviews} {
+		if sheet == nil {
+			continue
+		}
+
+		if err := sheet.close(); err != nil {
+			return err
+		}
+	}
+
+	return f.Write(w)
+}
+
+// xlsxSheet streams rows to a sheet, and to further sheets named with a
+// number after the name once a sheet is full.
+type xlsxSheet struct {
+	file   *excelize.File
+	name   string
+	header []string
+
+	part   int
+	rows   int
+	stream *excelize.StreamWriter
+}
+
+func (s *xlsxSheet) add(record []string) error {
+	if s.stream != nil && s.rows == excelize.TotalRows {
+		if err := s.flush(); err != nil {
+			return err
+		}
+	}
+
+	if s.stream == nil {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+
+	s.rows++
+
+	return s.stream.SetRow("A"+strconv.Itoa(s.rows), s.values(record))
+}
+
+// start starts the next sheet with the header.
+func (s *xlsxSheet) start() error {
+	s.part++
+
+	name := s.name
+	if s.part > 1 {
+		name = fmt.Sprintf("%s %d", s.name, s.part)
+	}
+
+	if idx, err := s.file.GetSheetIndex(name); err != nil {
+		return err
+	} else if idx == -1 {
+		if _, err := s.file.NewSheet(name); err != nil {
+			return err
+		}
+	}
+
+	stream, err := s.file.NewStreamWriter(name)
+	if err != nil {
+		return err
+	}
+
+	s.stream = stream
+	s.rows = 1
+
+	header := make([]any, len(s.header))
+	for i, h := range s.header {
+		header[i] = h
+	}
+
+	return s.stream.SetRow("A1", header)
+}
+
+// flush ends the current sheet.
+func (s *xlsxSheet) flush() error {
+	if s.stream == nil {
+		return nil
+	}
+
+	err := s.stream.Flush()
+
+	s.stream = nil
+
+	return err
+}
+
+// close ends the last sheet. A sheet without rows gets its header only.
+func (s *xlsxSheet) close() error {
+	if s.part == 0 {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+
+	return s.flush()
+}
+
+// values types the numeric columns of the record.
+func (s *xlsxSheet) values(record []string) []any {
+	ans := make([]any, len(record))
+
+	for i, v := range record {
+		ans[i] = v
+
+		if i < len(s.header) && numericColumns[s.header[i]] {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				ans[i] = n
+			}
+		}
+	}
+
+	return ans
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"time"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
 )
 
 const (
@@ -11,11 +13,19 @@ const (
 	StatusWorking = "working"
 	StatusOK      = "ok"
 	StatusFailed  = "failed"
+	// StatusCancelled and StatusPaused jobs were stopped from the API. Their
+	// partial results are kept, and a paused job can be resumed.
+	StatusCancelled = "cancelled"
+	StatusPaused    = "paused"
 )
 
 type SelectParams struct {
 	Status string
 	Limit  int
+	// Queue orders the jobs like the runner picks them, highest priority
+	// first and then oldest first, instead of newest first.
+	Queue bool
+	Owner string
 }
 
 type JobRepository interface {
@@ -28,12 +38,18 @@ type JobRepository interface {
 }
 
 type Job struct {
-	ID     string
-	Name   string
+	ID   string
+	Name string
+	// Owner is the user or team that created the job, empty when the
+	// server runs without authentication.
+	Owner  string `json:"owner,omitempty"`
 	Date   time.Time
 	Status string
 	Data   JobData `json:"data"`
 	Count  int     `json:"count"`
+	// QueuePosition is the position of a pending job in the queue, starting
+	// at 1. It is not stored.
+	QueuePosition int `json:"queue_position,omitempty"`
 }
 
 func (j *Job) Validate() error {
@@ -73,6 +89,17 @@ type JobData struct {
 	MaxTime     time.Duration `json:"max_time"`
 	Proxies     []string      `json:"proxies"`
 	SearchDelay int           `json:"search_delay"`
+	// Priority orders the pending jobs, higher first. Jobs of the same
+	// priority run in the order they were created.
+	Priority int `json:"priority"`
+	// WebhookURL is called when the job completes, fails or is cancelled,
+	// instead of the default webhook of the server.
+	WebhookURL string `json:"webhook_url,omitempty"`
+
+	ExtraReviews bool   `json:"extra_reviews"`
+	ReviewSort   string `json:"review_sort"`
+	MaxReviews   int    `json:"max_reviews"`
+	ReviewsSince string `json:"reviews_since"`
 }
 
 func (d *JobData) Validate() error {
@@ -98,5 +125,34 @@ func (d *JobData) Validate() error {
 
 	// FastMode geo coordinates are optional here — per-query #!geo# in keywords handles it
 
+	if d.MaxReviews < 0 {
+		return errors.New("invalid max reviews")
+	}
+
+	if _, err := d.ReviewOptions(); err != nil {
+		return err
+	}
+
+	if d.WebhookURL != "" {
+		if err := validateWebhookURL(d.WebhookURL); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// ReviewOptions returns the options of the extra reviews of the job.
+func (d *JobData) ReviewOptions() (gmaps.ReviewOptions, error) {
+	sort, err := gmaps.ParseReviewSort(d.ReviewSort)
+	if err != nil {
+		return gmaps.ReviewOptions{}, err
+	}
+
+	since, err := gmaps.ParseReviewsSince(d.ReviewsSince)
+	if err != nil {
+		return gmaps.ReviewOptions{}, err
+	}
+
+	return gmaps.ReviewOptions{Sort: sort, Max: d.MaxReviews, Since: since}, nil
+}
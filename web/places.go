@@ -0,0 +1,219 @@
+package web
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+)
+
+const (
+	SortRating   = "rating"
+	SortReviews  = "reviews"
+	SortDistance = "distance"
+)
+
+const (
+	defaultPlacesLimit = 50
+	maxPlacesLimit     = 500
+)
+
+// PlaceRepository stores the results of the jobs. The downloads are
+// generated from it in the format asked for. It is optional, the service
+// checks if the job repository implements it.
+type PlaceRepository interface {
+	// AddPlaces stores the places of the job, in one transaction, and
+	// returns the ones that were added. Places the job has already, by data
+	// id, are skipped.
+	AddPlaces(ctx context.Context, jobID string, entries []*gmaps.Entry) ([]*gmaps.Entry, error)
+	SelectPlaces(ctx context.Context, params *PlaceParams) ([]Place, error)
+	CountPlaces(ctx context.Context, jobID string) (int, error)
+	DeletePlaces(ctx context.Context, jobID string) error
+}
+
+// PlaceParams filters and sorts the places of a job. Zero values do not
+// filter.
+type PlaceParams struct {
+	JobID      string
+	Category   string
+	MinRating  float64
+	MinReviews int
+	HasWebsite bool
+	HasPhone   bool
+	HasEmail   bool
+	// BBox is min lat, min lon, max lat, max lon.
+	BBox *[4]float64
+	// Query searches the title, category and address.
+	Query string
+	// Sort is SortRating or SortReviews, highest first, or SortDistance,
+	// closest to Lat and Lon first. Places are in the order they were
+	// written by default.
+	Sort     string
+	Lat, Lon float64
+	Limit    int
+	// After is the cursor of the last place of the previous page.
+	After *PlaceCursor
+}
+
+// PlaceCursor is the position of a place in the sort order. It keeps the
+// sort, and the center of a distance sort, that it was issued for, since
+// its key means nothing in another order.
+type PlaceCursor struct {
+	Key  float64 `json:"k"`
+	Seq  int64   `json:"s"`
+	Sort string  `json:"o,omitempty"`
+	Lat  float64 `json:"la,omitempty"`
+	Lon  float64 `json:"lo,omitempty"`
+}
+
+func (c *PlaceCursor) String() string {
+	b, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func ParsePlaceCursor(s string) (*PlaceCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var ans PlaceCursor
+
+	if err := json.Unmarshal(b, &ans); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &ans, nil
+}
+
+// Place is a place of a job.
+type Place struct {
+	gmaps.Entry
+	// Distance is the distance in meters from the center of a distance
+	// sort.
+	Distance *float64 `json:"distance,omitempty"`
+
+	cursor PlaceCursor
+}
+
+// PlacePage is a page of places. NextCursor is empty on the last page.
+type PlacePage struct {
+	Places     []Place `json:"places"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// AddPlaces stores the places written by the job and returns the ones that
+// were new to it.
+func (s *Service) AddPlaces(ctx context.Context, jobID string, entries ...*gmaps.Entry) ([]*gmaps.Entry, error) {
+	if s.places == nil {
+		return nil, ErrNoPlaces
+	}
+
+	return s.places.AddPlaces(ctx, jobID, entries)
+}
+
+// CountPlaces returns how many places the job wrote.
+func (s *Service) CountPlaces(ctx context.Context, jobID string) (int, error) {
+	if s.places == nil {
+		return 0, ErrNoPlaces
+	}
+
+	return s.places.CountPlaces(ctx, jobID)
+}
+
+// Places returns a page of the places of the job. The distance sort uses
+// the coordinates of the job when params has none.
+func (s *Service) Places(ctx context.Context, p *PlaceParams) (PlacePage, error) {
+	if s.places == nil {
+		return PlacePage{}, ErrNoPlaces
+	}
+
+	params := *p
+
+	job, err := s.Get(ctx, params.JobID)
+	if err != nil {
+		return PlacePage{}, err
+	}
+
+	switch params.Sort {
+	case "", SortRating, SortReviews:
+	case SortDistance:
+		if params.Lat == 0 && params.Lon == 0 {
+			params.Lat, _ = strconv.ParseFloat(job.Data.Lat, 64)
+			params.Lon, _ = strconv.ParseFloat(job.Data.Lon, 64)
+		}
+
+		if params.Lat == 0 && params.Lon == 0 {
+			return PlacePage{}, errors.New("distance sort needs lat and lon")
+		}
+	default:
+		return PlacePage{}, errors.New("invalid sort")
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = defaultPlacesLimit
+	}
+
+	params.Limit = min(params.Limit, maxPlacesLimit)
+
+	if params.After != nil && !params.After.matches(&params) {
+		return PlacePage{}, errors.New("the cursor is for another sort")
+	}
+
+	limit := params.Limit
+
+	// one more tells if there is a next page
+	params.Limit++
+
+	places, err := s.places.SelectPlaces(ctx, &params)
+	if err != nil {
+		return PlacePage{}, err
+	}
+
+	ans := PlacePage{Places: places}
+
+	if len(places) > limit {
+		ans.Places = places[:limit]
+		cursor := ans.Places[limit-1].cursor
+		cursor.Sort = params.Sort
+
+		if params.Sort == SortDistance {
+			cursor.Lat, cursor.Lon = params.Lat, params.Lon
+		}
+
+		ans.NextCursor = cursor.String()
+	}
+
+	if params.Sort == SortDistance {
+		for i := range ans.Places {
+			d := math.Round(gmaps.Haversine(params.Lat, params.Lon, ans.Places[i].Latitude, ans.Places[i].Longtitude))
+			ans.Places[i].Distance = &d
+		}
+	}
+
+	if ans.Places == nil {
+		ans.Places = []Place{}
+	}
+
+	return ans, nil
+}
+
+// matches reports whether the cursor was issued for the order of params.
+func (c *PlaceCursor) matches(params *PlaceParams) bool {
+	if c.Sort != params.Sort {
+		return false
+	}
+
+	return params.Sort != SortDistance || (c.Lat == params.Lat && c.Lon == params.Lon)
+}
+
+// NewPlace returns a place read from a repository, with its position in
+// the sort order.
+func NewPlace(entry *gmaps.Entry, cursor PlaceCursor) Place {
+	return Place{Entry: *entry, cursor: cursor}
+}
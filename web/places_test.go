@@ -0,0 +1,195 @@
+package web_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+func addTestPlaces(t *testing.T, svc *web.Service, jobID string) {
+	t.Helper()
+
+	entries := []*gmaps.Entry{
+		{DataID: "a", Title: "Cafe Alpha", Category: "Cafe", ReviewRating: 4.8, ReviewCount: 120, WebSite: "https://alpha.example", Latitude: 37.980, Longtitude: 23.730},
+		{DataID: "b", Title: "Bakery Beta", Category: "Bakery", Categories: []string{"Bakery", "Cafe"}, ReviewRating: 4.2, ReviewCount: 40, Phone: "+30 210", Latitude: 37.990, Longtitude: 23.740},
+		{DataID: "c", Title: "Gamma Bar", Category: "Bar", Address: "1 Cafe Street", ReviewRating: 3.9, ReviewCount: 300, Emails: []string{"gamma@example.com"}, Latitude: 38.100, Longtitude: 23.800},
+		{DataID: "d", Title: "Delta Cafe", Category: "Cafe", ReviewRating: 4.8, ReviewCount: 15, Latitude: 37.981, Longtitude: 23.731},
+		{DataID: "e", Title: "Epsilon", Category: "Restaurant", ReviewRating: 4.5, ReviewCount: 120, WebSite: "https://epsilon.example", Latitude: 40.640, Longtitude: 22.940},
+	}
+
+	added, err := svc.AddPlaces(t.Context(), jobID, entries...)
+	require.NoError(t, err)
+	require.Equal(t, entries, added)
+}
+
+func Test_AddPlaces(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusOK)
+	other := createTestJob(t, repo, "", web.StatusOK)
+
+	a := &gmaps.Entry{DataID: "a", Title: "Alpha"}
+	b := &gmaps.Entry{DataID: "b", Title: "Beta"}
+
+	added, err := svc.AddPlaces(t.Context(), job.ID, a)
+	require.NoError(t, err)
+	require.Equal(t, []*gmaps.Entry{a}, added)
+
+	// places written again, as after a job was resumed, are skipped
+	added, err = svc.AddPlaces(t.Context(), job.ID, a, b, b)
+	require.NoError(t, err)
+	require.Equal(t, []*gmaps.Entry{b}, added)
+
+	// but other jobs have their own
+	added, err = svc.AddPlaces(t.Context(), other.ID, a)
+	require.NoError(t, err)
+	require.Equal(t, []*gmaps.Entry{a}, added)
+
+	added, err = svc.AddPlaces(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Empty(t, added)
+
+	count, err := svc.CountPlaces(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func placeIDs(page web.PlacePage) []string {
+	ids := make([]string, 0, len(page.Places))
+	for i := range page.Places {
+		ids = append(ids, page.Places[i].DataID)
+	}
+
+	return ids
+}
+
+func Test_PlacesFilters(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusOK)
+	addTestPlaces(t, svc, job.ID)
+
+	count, err := svc.CountPlaces(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+
+	for _, tc := range []struct {
+		name   string
+		params web.PlaceParams
+		want   []string
+	}{
+		{"all", web.PlaceParams{}, []string{"a", "b", "c", "d", "e"}},
+		{"category", web.PlaceParams{Category: "cafe"}, []string{"a", "b", "d"}},
+		{"min rating", web.PlaceParams{MinRating: 4.5}, []string{"a", "d", "e"}},
+		{"min reviews", web.PlaceParams{MinReviews: 100}, []string{"a", "c", "e"}},
+		{"website", web.PlaceParams{HasWebsite: true}, []string{"a", "e"}},
+		{"phone", web.PlaceParams{HasPhone: true}, []string{"b"}},
+		{"email", web.PlaceParams{HasEmail: true}, []string{"c"}},
+		{"bbox", web.PlaceParams{BBox: &[4]float64{37.9, 23.7, 38.0, 23.75}}, []string{"a", "b", "d"}},
+		{"query", web.PlaceParams{Query: "cafe"}, []string{"a", "c", "d"}},
+		{"query escapes like", web.PlaceParams{Query: "%"}, []string{}},
+		{"combined", web.PlaceParams{Category: "cafe", MinRating: 4.5, HasWebsite: true}, []string{"a"}},
+		{"rating sort", web.PlaceParams{Sort: web.SortRating}, []string{"a", "d", "e", "b", "c"}},
+		{"reviews sort", web.PlaceParams{Sort: web.SortReviews}, []string{"c", "a", "e", "b", "d"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := tc.params
+			params.JobID = job.ID
+
+			page, err := svc.Places(t.Context(), &params)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, placeIDs(page))
+			require.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func Test_PlacesPagination(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusOK)
+	addTestPlaces(t, svc, job.ID)
+
+	// the ties of a sort are paged in the order the places were written
+	var ids []string
+
+	params := web.PlaceParams{JobID: job.ID, Sort: web.SortRating, Limit: 2}
+
+	for pages := 1; ; pages++ {
+		page, err := svc.Places(t.Context(), &params)
+		require.NoError(t, err)
+
+		ids = append(ids, placeIDs(page)...)
+
+		if page.NextCursor == "" {
+			require.Equal(t, 3, pages)
+
+			break
+		}
+
+		params.After, err = web.ParsePlaceCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []string{"a", "d", "e", "b", "c"}, ids)
+
+	// a cursor only works with the sort it was issued for
+	page, err := svc.Places(t.Context(), &web.PlaceParams{JobID: job.ID, Sort: web.SortRating, Limit: 2})
+	require.NoError(t, err)
+
+	cursor, err := web.ParsePlaceCursor(page.NextCursor)
+	require.NoError(t, err)
+
+	_, err = svc.Places(t.Context(), &web.PlaceParams{JobID: job.ID, Sort: web.SortReviews, After: cursor})
+	require.Error(t, err)
+
+	_, err = svc.Places(t.Context(), &web.PlaceParams{JobID: job.ID, After: cursor})
+	require.Error(t, err)
+
+	_, err = web.ParsePlaceCursor("not a cursor")
+	require.Error(t, err)
+}
+
+func Test_PlacesDistanceSort(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusOK)
+	addTestPlaces(t, svc, job.ID)
+
+	params := web.PlaceParams{JobID: job.ID, Sort: web.SortDistance, Lat: 37.98, Lon: 23.73, Limit: 3}
+
+	page, err := svc.Places(t.Context(), &params)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "d", "b"}, placeIDs(page))
+	require.NotEmpty(t, page.NextCursor)
+
+	require.Zero(t, *page.Places[0].Distance)
+	require.InDelta(t, 140, *page.Places[1].Distance, 5)
+	require.Less(t, *page.Places[1].Distance, *page.Places[2].Distance)
+
+	cursor, err := web.ParsePlaceCursor(page.NextCursor)
+	require.NoError(t, err)
+
+	params.After = cursor
+
+	page, err = svc.Places(t.Context(), &params)
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "e"}, placeIDs(page))
+	require.Empty(t, page.NextCursor)
+
+	// the keys of another center are not comparable
+	params.Lat = 40.64
+
+	_, err = svc.Places(t.Context(), &params)
+	require.Error(t, err)
+
+	// without coordinates of its own, a job has no center
+	_, err = svc.Places(t.Context(), &web.PlaceParams{JobID: job.ID, Sort: web.SortDistance})
+	require.Error(t, err)
+
+	_, err = svc.Places(t.Context(), &web.PlaceParams{JobID: job.ID, Sort: "name"})
+	require.Error(t, err)
+}
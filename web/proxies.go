@@ -0,0 +1,56 @@
+package web
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gosom/google-maps-scraper/proxypool"
+)
+
+// ProxyPool is the health of the proxies of the server, which the jobs
+// share, or of the proxies of a running job.
+type ProxyPool struct {
+	// JobID is empty for the proxies of the server.
+	JobID   string            `json:"job_id,omitempty"`
+	Policy  string            `json:"policy"`
+	Proxies []proxypool.Stats `json:"proxies"`
+}
+
+// ProxyMonitor reports the proxy pools in use. It is implemented by the
+// runner of the jobs.
+type ProxyMonitor interface {
+	ProxyPools() []ProxyPool
+}
+
+func WithProxyMonitor(m ProxyMonitor) ServiceOption {
+	return func(s *Service) {
+		s.proxies = m
+	}
+}
+
+// ProxyPools returns the proxy pools in use, the one of the server and
+// those of the running jobs the caller can see.
+func (s *Service) ProxyPools(ctx context.Context) ([]ProxyPool, error) {
+	ans := []ProxyPool{}
+
+	if s.proxies == nil {
+		return ans, nil
+	}
+
+	for _, pool := range s.proxies.ProxyPools() {
+		if pool.JobID != "" {
+			err := s.checkOwner(ctx, pool.JobID)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ans = append(ans, pool)
+	}
+
+	return ans, nil
+}
@@ -0,0 +1,281 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RunStarted = "started"
+	RunSkipped = "skipped"
+)
+
+type ScheduleParams struct {
+	// DueBefore selects the enabled schedules whose next run is not after
+	// the time.
+	DueBefore time.Time
+	Owner     string
+}
+
+type ScheduleRunParams struct {
+	ScheduleID string
+	Status     string
+	Limit      int
+}
+
+// ScheduleRepository stores the schedules and the history of their runs.
+// It is optional, the service checks if the job repository implements it.
+type ScheduleRepository interface {
+	GetSchedule(context.Context, string) (Schedule, error)
+	CreateSchedule(context.Context, *Schedule) error
+	UpdateSchedule(context.Context, *Schedule) error
+	DeleteSchedule(context.Context, string) error
+	SelectSchedules(context.Context, ScheduleParams) ([]Schedule, error)
+	CreateScheduleRun(context.Context, *ScheduleRun) error
+	SelectScheduleRuns(context.Context, ScheduleRunParams) ([]ScheduleRun, error)
+}
+
+// Schedule creates a job from its template each time the cron expression
+// matches.
+type Schedule struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Owner also owns the jobs of the schedule.
+	Owner string  `json:"owner,omitempty"`
+	Cron  string  `json:"cron"`
+	Data  JobData `json:"data"`
+	// SkipIfRunning skips a run while the job of the previous run is still
+	// pending or working.
+	SkipIfRunning bool      `json:"skip_if_running"`
+	Enabled       bool      `json:"enabled"`
+	NextRun       time.Time `json:"next_run,omitzero"`
+	LastRun       time.Time `json:"last_run,omitzero"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (s *Schedule) Validate() error {
+	if s.ID == "" {
+		return errors.New("missing id")
+	}
+
+	if s.Name == "" {
+		return errors.New("missing name")
+	}
+
+	if s.Cron == "" {
+		return errors.New("missing cron")
+	}
+
+	if _, err := parseCron(s.Cron); err != nil {
+		return err
+	}
+
+	return s.Data.Validate()
+}
+
+// UpdateNextRun sets the next run to the first time after t that the cron
+// expression matches.
+func (s *Schedule) UpdateNextRun(t time.Time) error {
+	c, err := parseCron(s.Cron)
+	if err != nil {
+		return err
+	}
+
+	s.NextRun = c.next(t)
+
+	if s.NextRun.IsZero() {
+		return errors.New("cron expression never matches")
+	}
+
+	return nil
+}
+
+// ScheduleRun is a run of a schedule, with the job it created or skipped.
+type ScheduleRun struct {
+	ScheduleID string    `json:"schedule_id"`
+	Date       time.Time `json:"date"`
+	Status     string    `json:"status"`
+	JobID      string    `json:"job_id,omitempty"`
+	// JobStatus is the current status of the job, empty when the job was
+	// deleted.
+	JobStatus string `json:"job_status,omitempty"`
+}
+
+func (s *Service) CreateSchedule(ctx context.Context, schedule *Schedule) error {
+	if s.schedules == nil {
+		return ErrNoSchedules
+	}
+
+	if schedule.Owner == "" {
+		schedule.Owner = OwnerFromContext(ctx)
+	}
+
+	if err := s.checkWebhookURL(schedule.Data.WebhookURL); err != nil {
+		return err
+	}
+
+	if err := schedule.UpdateNextRun(time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return s.schedules.CreateSchedule(ctx, schedule)
+}
+
+func (s *Service) AllSchedules(ctx context.Context) ([]Schedule, error) {
+	if s.schedules == nil {
+		return nil, ErrNoSchedules
+	}
+
+	return s.schedules.SelectSchedules(ctx, ScheduleParams{Owner: OwnerFromContext(ctx)})
+}
+
+func (s *Service) GetSchedule(ctx context.Context, id string) (Schedule, error) {
+	if s.schedules == nil {
+		return Schedule{}, ErrNoSchedules
+	}
+
+	schedule, err := s.schedules.GetSchedule(ctx, id)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if !visible(ctx, schedule.Owner) {
+		return Schedule{}, ErrNotFound
+	}
+
+	return schedule, nil
+}
+
+// UpdateSchedule saves the schedule and computes its next run again, as the
+// cron expression may have changed.
+func (s *Service) UpdateSchedule(ctx context.Context, schedule *Schedule) error {
+	if s.schedules == nil {
+		return ErrNoSchedules
+	}
+
+	if err := s.checkWebhookURL(schedule.Data.WebhookURL); err != nil {
+		return err
+	}
+
+	if err := schedule.UpdateNextRun(time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return s.schedules.UpdateSchedule(ctx, schedule)
+}
+
+// DeleteSchedule deletes the schedule and its history. The jobs it created
+// are kept.
+func (s *Service) DeleteSchedule(ctx context.Context, id string) error {
+	if s.schedules == nil {
+		return ErrNoSchedules
+	}
+
+	if _, err := s.GetSchedule(ctx, id); err != nil {
+		return err
+	}
+
+	return s.schedules.DeleteSchedule(ctx, id)
+}
+
+func (s *Service) ScheduleRuns(ctx context.Context, id string, limit int) ([]ScheduleRun, error) {
+	if s.schedules == nil {
+		return nil, ErrNoSchedules
+	}
+
+	return s.schedules.SelectScheduleRuns(ctx, ScheduleRunParams{ScheduleID: id, Limit: limit})
+}
+
+// RunDueSchedules creates a pending job for each enabled schedule whose next
+// run is due at now. Runs missed while the server was down are not caught
+// up, a schedule runs once and moves on to its next time after now. A
+// schedule that fails does not hold back the others, the errors are
+// returned together and the failed schedules are tried again on the next
+// call.
+func (s *Service) RunDueSchedules(ctx context.Context, now time.Time) error {
+	if s.schedules == nil {
+		return nil
+	}
+
+	due, err := s.schedules.SelectSchedules(ctx, ScheduleParams{DueBefore: now})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for i := range due {
+		if err := s.runSchedule(ctx, &due[i], now); err != nil {
+			errs = append(errs, fmt.Errorf("schedule %s: %w", due[i].ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Service) runSchedule(ctx context.Context, schedule *Schedule, now time.Time) error {
+	run := ScheduleRun{
+		ScheduleID: schedule.ID,
+		Date:       now,
+		Status:     RunStarted,
+	}
+
+	if schedule.SkipIfRunning {
+		running, err := s.scheduleRunning(ctx, schedule.ID)
+		if err != nil {
+			return err
+		}
+
+		if running {
+			run.Status = RunSkipped
+		}
+	}
+
+	if run.Status == RunStarted {
+		job := Job{
+			ID:     uuid.New().String(),
+			Name:   schedule.Name,
+			Owner:  schedule.Owner,
+			Date:   now,
+			Status: StatusPending,
+			Data:   schedule.Data,
+		}
+
+		if err := s.repo.Create(ctx, &job); err != nil {
+			return err
+		}
+
+		run.JobID = job.ID
+	}
+
+	if err := s.schedules.CreateScheduleRun(ctx, &run); err != nil {
+		return err
+	}
+
+	schedule.LastRun = now
+
+	if err := schedule.UpdateNextRun(now); err != nil {
+		schedule.Enabled = false
+	}
+
+	return s.schedules.UpdateSchedule(ctx, schedule)
+}
+
+// scheduleRunning reports whether the job of the last started run of the
+// schedule is still pending or working.
+func (s *Service) scheduleRunning(ctx context.Context, id string) (bool, error) {
+	runs, err := s.schedules.SelectScheduleRuns(ctx, ScheduleRunParams{
+		ScheduleID: id,
+		Status:     RunStarted,
+		Limit:      1,
+	})
+	if err != nil || len(runs) == 0 {
+		return false, err
+	}
+
+	return runs[0].JobStatus == StatusPending || runs[0].JobStatus == StatusWorking, nil
+}
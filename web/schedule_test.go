@@ -0,0 +1,114 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/google-maps-scraper/web/sqlite"
+)
+
+func Test_ScheduleUpdateNextRun(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2024, 5, 15, 10, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		cron string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 5, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 6 * * 1-5", time.Date(2024, 5, 16, 6, 0, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2024, 5, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2024, 5, 19, 9, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// both days restricted, either matches
+		{"0 0 1 * 5", time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.cron, func(t *testing.T) {
+			s := web.Schedule{Cron: tc.cron}
+
+			require.NoError(t, s.UpdateNextRun(now))
+			require.Equal(t, tc.want, s.NextRun)
+		})
+	}
+
+	for _, cron := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "0 0 30 2 *"} {
+		s := web.Schedule{Cron: cron}
+
+		require.Error(t, s.UpdateNextRun(now), cron)
+	}
+}
+
+// failingRepo fails to create the jobs with the name.
+type failingRepo struct {
+	web.JobRepository
+	web.ScheduleRepository
+
+	name string
+}
+
+func (r *failingRepo) Create(ctx context.Context, job *web.Job) error {
+	if job.Name == r.name {
+		return errors.New("disk full")
+	}
+
+	return r.JobRepository.Create(ctx, job)
+}
+
+func Test_RunDueSchedulesContinuesAfterError(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := sqlite.New(filepath.Join(dir, "jobs.db"))
+	require.NoError(t, err)
+
+	svc := web.NewService(&failingRepo{
+		JobRepository:      repo,
+		ScheduleRepository: repo.(web.ScheduleRepository),
+		name:               "broken",
+	}, dir)
+
+	var ids []string
+
+	for _, name := range []string{"broken", "daily"} {
+		schedule := web.Schedule{
+			ID:      name + "-id",
+			Name:    name,
+			Cron:    "0 6 * * *",
+			Enabled: true,
+			Data:    web.JobData{Keywords: []string{"coffee"}, Lang: "en", Depth: 1},
+		}
+
+		require.NoError(t, svc.CreateSchedule(t.Context(), &schedule))
+
+		ids = append(ids, schedule.ID)
+	}
+
+	now := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+
+	err = svc.RunDueSchedules(t.Context(), now)
+	require.ErrorContains(t, err, "schedule broken-id: disk full")
+
+	// the schedule after the failed one still ran
+	runs, err := svc.ScheduleRuns(t.Context(), ids[1], 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, web.RunStarted, runs[0].Status)
+
+	daily, err := svc.GetSchedule(t.Context(), ids[1])
+	require.NoError(t, err)
+	require.Equal(t, now, daily.LastRun.UTC())
+
+	// the failed one is due again
+	broken, err := svc.GetSchedule(t.Context(), ids[0])
+	require.NoError(t, err)
+	require.True(t, broken.LastRun.IsZero())
+	require.False(t, broken.NextRun.After(now))
+}
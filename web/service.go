@@ -1,39 +1,142 @@
 package web
 
 import (
+	"bufio"
 	"context"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 
-	"github.com/xuri/excelize/v2"
+	"github.com/gosom/google-maps-scraper/diff"
+	"github.com/gosom/google-maps-scraper/geo"
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/parquetwriter"
 )
 
 type Service struct {
 	repo       JobRepository
+	schedules  ScheduleRepository
+	tokens     TokenRepository
+	quotas     QuotaRepository
+	deliveries DeliveryRepository
+	places     PlaceRepository
+	proxies    ProxyMonitor
+	webhooks   WebhookConfig
 	dataFolder string
+
+	webhookClient *http.Client
+
+	// mu serializes the status changes of the jobs that can race with the
+	// runner, and guards running.
+	mu      sync.Mutex
+	running map[string]context.CancelCauseFunc
+
+	events eventHub
 }
 
-func NewService(repo JobRepository, dataFolder string) *Service {
-	return &Service{
+func NewService(repo JobRepository, dataFolder string, opts ...ServiceOption) *Service {
+	schedules, _ := repo.(ScheduleRepository)
+	tokens, _ := repo.(TokenRepository)
+	quotas, _ := repo.(QuotaRepository)
+	deliveries, _ := repo.(DeliveryRepository)
+	places, _ := repo.(PlaceRepository)
+
+	ans := Service{
 		repo:       repo,
+		schedules:  schedules,
+		tokens:     tokens,
+		quotas:     quotas,
+		deliveries: deliveries,
+		places:     places,
 		dataFolder: dataFolder,
+		running:    map[string]context.CancelCauseFunc{},
 	}
+
+	for _, opt := range opts {
+		opt(&ans)
+	}
+
+	return &ans
 }
 
+// Create creates the job for the owner of the context, unless it has an
+// owner already.
 func (s *Service) Create(ctx context.Context, job *Job) error {
+	if job.Owner == "" {
+		job.Owner = OwnerFromContext(ctx)
+	}
+
+	if err := s.checkWebhookURL(job.Data.WebhookURL); err != nil {
+		return err
+	}
+
 	return s.repo.Create(ctx, job)
 }
 
 func (s *Service) All(ctx context.Context) ([]Job, error) {
-	return s.repo.Select(ctx, SelectParams{})
+	jobs, err := s.repo.Select(ctx, SelectParams{Owner: OwnerFromContext(ctx)})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setQueuePositions(ctx, jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
 }
 
 func (s *Service) Get(ctx context.Context, id string) (Job, error) {
-	return s.repo.Get(ctx, id)
+	job, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Job{}, err
+	}
+
+	if !visible(ctx, job.Owner) {
+		return Job{}, ErrNotFound
+	}
+
+	jobs := []Job{job}
+
+	if err := s.setQueuePositions(ctx, jobs); err != nil {
+		return Job{}, err
+	}
+
+	return jobs[0], nil
+}
+
+// setQueuePositions sets the queue position of the pending jobs.
+func (s *Service) setQueuePositions(ctx context.Context, jobs []Job) error {
+	if !slices.ContainsFunc(jobs, func(j Job) bool { return j.Status == StatusPending }) {
+		return nil
+	}
+
+	queue, err := s.repo.Select(ctx, SelectParams{Status: StatusPending, Queue: true})
+	if err != nil {
+		return err
+	}
+
+	positions := make(map[string]int, len(queue))
+	for i := range queue {
+		positions[queue[i].ID] = i + 1
+	}
+
+	for i := range jobs {
+		if jobs[i].Status == StatusPending {
+			jobs[i].QueuePosition = positions[jobs[i].ID]
+		}
+	}
+
+	return nil
 }
 
 func (s *Service) Delete(ctx context.Context, id string) error {
@@ -41,10 +144,20 @@ func (s *Service) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("invalid file name")
 	}
 
-	// Delete merged CSV
+	if err := s.checkOwner(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if cancel, ok := s.running[id]; ok {
+		cancel(ErrJobDeleted)
+	}
+	s.mu.Unlock()
+
+	// Delete the CSV files of jobs that ran before the results were stored
+	// in the database, merged and rotated
 	os.Remove(filepath.Join(s.dataFolder, id+".csv"))
 
-	// Delete rotated CSV files ({id}_1.csv, {id}_2.csv, ...)
 	csvPattern := filepath.Join(s.dataFolder, id+"_*.csv")
 	if matches, err := filepath.Glob(csvPattern); err == nil {
 		for _, m := range matches {
@@ -52,7 +165,7 @@ func (s *Service) Delete(ctx context.Context, id string) error {
 		}
 	}
 
-	// Delete Excel files
+	// Delete the Excel files that earlier versions saved for downloads
 	xlsxPattern := filepath.Join(s.dataFolder, id+"*.xlsx")
 	if matches, err := filepath.Glob(xlsxPattern); err == nil {
 		for _, m := range matches {
@@ -60,141 +173,526 @@ func (s *Service) Delete(ctx context.Context, id string) error {
 		}
 	}
 
+	if s.places != nil {
+		if err := s.places.DeletePlaces(ctx, id); err != nil {
+			return err
+		}
+	}
+
 	return s.repo.Delete(ctx, id)
 }
 
 func (s *Service) Update(ctx context.Context, job *Job) error {
-	return s.repo.Update(ctx, job)
+	if err := s.repo.Update(ctx, job); err != nil {
+		return err
+	}
+
+	s.publishStatus(job)
+
+	return nil
 }
 
-func (s *Service) SelectPending(ctx context.Context) ([]Job, error) {
-	return s.repo.Select(ctx, SelectParams{Status: StatusPending, Limit: 1})
+// StartJob marks the pending job as working and registers the cancel func
+// of its context, so it can be stopped from the API. It returns
+// ErrInvalidStatus when the job was cancelled or paused after it was
+// selected. The returned func must be called when the job ends.
+func (s *Service) StartJob(ctx context.Context, job *Job, cancel context.CancelCauseFunc) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.repo.Get(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Status != StatusPending {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStatus, current.Status)
+	}
+
+	job.Status = StatusWorking
+
+	if err := s.Update(ctx, job); err != nil {
+		return nil, err
+	}
+
+	s.running[job.ID] = cancel
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.running, job.ID)
+	}, nil
 }
 
-func (s *Service) GetCSV(_ context.Context, id string) (string, error) {
-	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
-		return "", fmt.Errorf("invalid file name")
+// Cancel stops a pending, working or paused job for good. The results
+// scraped so far are kept.
+func (s *Service) Cancel(ctx context.Context, id string) (Job, error) {
+	return s.stop(ctx, id, StatusCancelled, ErrJobCancelled, StatusPending, StatusWorking, StatusPaused)
+}
+
+// Pause stops a pending or working job until it is resumed. The results
+// scraped so far are kept.
+func (s *Service) Pause(ctx context.Context, id string) (Job, error) {
+	return s.stop(ctx, id, StatusPaused, ErrJobPaused, StatusPending, StatusWorking)
+}
+
+// Resume queues a paused job again. Its seed queries run again from the
+// start and the new results are added to the ones of the earlier runs. A
+// job that was paused while working can only be resumed once its runner
+// saved the partial results and stopped, until then ErrInvalidStatus is
+// returned.
+func (s *Service) Resume(ctx context.Context, id string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Job{}, err
 	}
 
-	// First check for merged CSV file
-	datapath := filepath.Join(s.dataFolder, id+".csv")
-	if _, err := os.Stat(datapath); err == nil {
-		return datapath, nil
+	if !visible(ctx, job.Owner) {
+		return Job{}, ErrNotFound
 	}
 
-	// Then check for rotated CSV files ({id}_1.csv, {id}_2.csv, ...)
-	pattern := filepath.Join(s.dataFolder, id+"_*.csv")
-	matches, err := filepath.Glob(pattern)
+	if job.Status != StatusPaused {
+		return job, fmt.Errorf("%w: %s", ErrInvalidStatus, job.Status)
+	}
+
+	if _, running := s.running[id]; running {
+		return job, fmt.Errorf("%w: %s, still stopping", ErrInvalidStatus, job.Status)
+	}
+
+	job.Status = StatusPending
+
+	return job, s.Update(ctx, &job)
+}
+
+// stop sets the status of the job, if it has one of the from statuses, and
+// cancels its context with the cause when it is running. The runner then
+// keeps the partial results and sets the status again once they are saved.
+func (s *Service) stop(ctx context.Context, id, status string, cause error, from ...string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.repo.Get(ctx, id)
 	if err != nil {
-		return "", fmt.Errorf("error searching for csv files: %w", err)
+		return Job{}, err
 	}
 
-	if len(matches) == 0 {
-		return "", fmt.Errorf("csv file not found for job %s", id)
+	if !visible(ctx, job.Owner) {
+		return Job{}, ErrNotFound
 	}
 
-	sort.Strings(matches)
-	return matches[0], nil
+	if !slices.Contains(from, job.Status) {
+		return job, fmt.Errorf("%w: %s", ErrInvalidStatus, job.Status)
+	}
+
+	cancel, running := s.running[id]
+	if running {
+		cancel(cause)
+	}
+
+	job.Status = status
+
+	if err := s.Update(ctx, &job); err != nil {
+		return job, err
+	}
+
+	// the runner notifies once the results of a running job are saved
+	if !running {
+		if err := s.NotifyJob(ctx, job.ID, 0); err != nil {
+			return job, err
+		}
+	}
+
+	return job, nil
 }
 
-func (s *Service) GetExcel(ctx context.Context, id string, fields []string) (string, error) {
-	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
-		return "", fmt.Errorf("invalid file name")
+// SelectPending returns the next job to run. Jobs of owners that are at
+// their quota are skipped and keep their place in the queue.
+func (s *Service) SelectPending(ctx context.Context) ([]Job, error) {
+	if s.quotas == nil {
+		return s.repo.Select(ctx, SelectParams{Status: StatusPending, Queue: true, Limit: 1})
 	}
 
-	csvPath, err := s.GetCSV(ctx, id)
+	queue, err := s.repo.Select(ctx, SelectParams{Status: StatusPending, Queue: true})
 	if err != nil {
-		return "", fmt.Errorf("failed to find csv: %w", err)
+		return nil, err
 	}
 
-	// Read CSV
-	csvFile, err := os.Open(csvPath)
+	working, err := s.repo.Select(ctx, SelectParams{Status: StatusWorking})
 	if err != nil {
-		return "", fmt.Errorf("failed to open csv: %w", err)
+		return nil, err
 	}
-	defer csvFile.Close()
 
-	reader := csv.NewReader(csvFile)
-	records, err := reader.ReadAll()
+	running := make(map[string]int, len(working))
+	for i := range working {
+		running[working[i].Owner]++
+	}
+
+	allowed := map[string]bool{}
+
+	for i := range queue {
+		owner := queue[i].Owner
+
+		ok, checked := allowed[owner]
+		if !checked {
+			if ok, err = s.canStart(ctx, owner, running); err != nil {
+				return nil, err
+			}
+
+			allowed[owner] = ok
+		}
+
+		if ok {
+			return queue[i : i+1], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// checkOwner returns ErrNotFound when the job belongs to another owner than
+// the one of the context.
+func (s *Service) checkOwner(ctx context.Context, id string) error {
+	if OwnerFromContext(ctx) == "" {
+		return nil
+	}
+
+	job, err := s.repo.Get(ctx, id)
 	if err != nil {
-		return "", fmt.Errorf("failed to read csv: %w", err)
+		return err
+	}
+
+	if !visible(ctx, job.Owner) {
+		return ErrNotFound
 	}
 
-	// Filter records if fields specified
-	if len(fields) > 0 && len(records) > 0 {
-		records = filterRecords(records, fields)
+	return nil
+}
+
+// WriteCSV writes the results of the job as CSV, only with the fields when
+// there are any. The results are read from the database, or from the CSV
+// file of jobs that ran before results were stored there.
+func (s *Service) WriteCSV(ctx context.Context, w io.Writer, id string, fields []string) error {
+	// buffered with the records, so an error reading the results comes
+	// before anything is written
+	bw := bufio.NewWriter(w)
+
+	// BOM for Excel compatibility
+	if _, err := bw.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		return err
 	}
 
-	// Create Excel
-	f := excelize.NewFile()
-	defer func() {
-		if err := f.Close(); err != nil {
-			fmt.Println(err)
+	writer := csv.NewWriter(bw)
+
+	var columns []int
+
+	err := s.eachRecord(ctx, id, func(record []string) error {
+		if columns == nil {
+			columns = selectColumns(record, fields)
 		}
-	}()
 
-	// Write data
-	for i, row := range records {
-		cell, err := excelize.CoordinatesToCellName(1, i+1)
-		if err != nil {
-			return "", err
+		return writer.Write(pickColumns(record, columns))
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// WriteGeo writes the results of the job as a GeoJSON or KML document, with
+// the fields as the properties of the places, all of them when there are
+// none.
+func (s *Service) WriteGeo(ctx context.Context, w io.Writer, id, format string, fields []string) error {
+	var enc geo.Encoder
+
+	err := s.eachRecord(ctx, id, func(record []string) error {
+		if enc == nil {
+			var err error
+
+			enc, err = geo.NewEncoder(format, w, record, fields)
+
+			return err
 		}
-		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
-			return "", err
+
+		return enc.Encode(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	// an empty legacy CSV file has no header either
+	if enc == nil {
+		enc, err = geo.NewEncoder(format, w, (&gmaps.Entry{}).CsvHeaders(), fields)
+		if err != nil {
+			return err
 		}
 	}
 
-	// Save Excel (always generate fresh to respect current field selection)
-	suffix := ""
-	if len(fields) > 0 {
-		suffix = "_filtered"
+	return enc.Close()
+}
+
+// WriteParquet writes the places of the job to w as a Parquet file with
+// all the fields, the nested ones as lists of structs. It returns
+// ErrLegacyResults, before writing anything, for jobs whose results are in
+// a CSV file.
+func (s *Service) WriteParquet(ctx context.Context, w io.Writer, id string) error {
+	legacy, err := s.legacyCSV(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if legacy != "" {
+		return ErrLegacyResults
 	}
-	xlsxPath := filepath.Join(s.dataFolder, id+suffix+".xlsx")
 
-	if err := f.SaveAs(xlsxPath); err != nil {
-		return "", fmt.Errorf("failed to save xlsx: %w", err)
+	enc := parquetwriter.NewEncoder(w)
+
+	if err := s.eachPlace(ctx, id, enc.Encode); err != nil {
+		return err
 	}
 
-	return xlsxPath, nil
+	return enc.Close()
 }
 
-func (s *Service) FilterCSV(csvPath string, fields []string) ([]byte, error) {
-	csvFile, err := os.Open(csvPath)
+// WriteReviewsCSV writes the reviews of the job's places as CSV with one
+// row per review, keyed by the place's data_id and cid.
+func (s *Service) WriteReviewsCSV(ctx context.Context, w io.Writer, id string) error {
+	var columns map[string]int
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write((&gmaps.ReviewRow{}).CsvHeaders()); err != nil {
+		return err
+	}
+
+	column := func(record []string, name string) string {
+		if idx := columns[name]; idx < len(record) {
+			return record[idx]
+		}
+
+		return ""
+	}
+
+	err := s.eachRecord(ctx, id, func(record []string) error {
+		if columns == nil {
+			columns = make(map[string]int, len(record))
+			for i, h := range record {
+				columns[h] = i
+			}
+
+			for _, name := range []string{"data_id", "cid", "user_reviews", "user_reviews_extended"} {
+				if _, ok := columns[name]; !ok {
+					return fmt.Errorf("csv file has no %s column", name)
+				}
+			}
+
+			return nil
+		}
+
+		var inline, extended []gmaps.Review
+
+		// the columns are empty or "null" for places without reviews
+		_ = json.Unmarshal([]byte(column(record, "user_reviews")), &inline)
+		_ = json.Unmarshal([]byte(column(record, "user_reviews_extended")), &extended)
+
+		rows := gmaps.NewReviewRows(column(record, "data_id"), column(record, "cid"), inline, extended)
+		for i := range rows {
+			if err := writer.Write(rows[i].CsvRow()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open csv: %w", err)
+		return err
 	}
-	defer csvFile.Close()
 
-	reader := csv.NewReader(csvFile)
-	records, err := reader.ReadAll()
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// Diff compares the results of the job with the results of the base job,
+// an earlier crawl of the same queries.
+func (s *Service) Diff(ctx context.Context, baseID, id string) (diff.Report, error) {
+	oldPlaces, err := s.readPlaces(ctx, baseID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read csv: %w", err)
+		return diff.Report{}, err
 	}
 
-	if len(records) == 0 {
-		return nil, fmt.Errorf("empty csv file")
+	newPlaces, err := s.readPlaces(ctx, id)
+	if err != nil {
+		return diff.Report{}, err
 	}
 
-	filtered := filterRecords(records, fields)
+	return diff.Compare(oldPlaces, newPlaces), nil
+}
 
-	var buf strings.Builder
-	writer := csv.NewWriter(&buf)
-	if err := writer.WriteAll(filtered); err != nil {
+func (s *Service) readPlaces(ctx context.Context, id string) ([]diff.Place, error) {
+	legacy, err := s.legacyCSV(ctx, id)
+	if err != nil {
 		return nil, err
 	}
-	writer.Flush()
 
-	return []byte(buf.String()), nil
+	if legacy != "" {
+		csvFile, err := os.Open(legacy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open csv: %w", err)
+		}
+		defer csvFile.Close()
+
+		return diff.ReadPlaces(csvFile)
+	}
+
+	var ans []diff.Place
+
+	err = s.eachPlace(ctx, id, func(e *gmaps.Entry) error {
+		ans = append(ans, diff.NewPlace(e))
+
+		return nil
+	})
+
+	return ans, err
+}
+
+// eachRecord calls fn with the CSV headers of the results of the job, and
+// then with each row.
+func (s *Service) eachRecord(ctx context.Context, id string, fn func([]string) error) error {
+	legacy, err := s.legacyCSV(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if legacy != "" {
+		return readCSVFile(legacy, fn)
+	}
+
+	if err := fn((&gmaps.Entry{}).CsvHeaders()); err != nil {
+		return err
+	}
+
+	return s.eachPlace(ctx, id, func(e *gmaps.Entry) error {
+		return fn(e.CsvRow())
+	})
 }
 
-func filterRecords(records [][]string, fields []string) [][]string {
-	if len(records) == 0 {
-		return records
+// eachPlace calls fn with the places of the job in the order they were
+// written, reading them a page at a time.
+func (s *Service) eachPlace(ctx context.Context, id string, fn func(*gmaps.Entry) error) error {
+	if s.places == nil {
+		return ErrNoPlaces
 	}
 
-	headers := records[0]
+	params := PlaceParams{JobID: id, Limit: maxPlacesLimit}
 
-	// Find column indices for requested fields
+	for {
+		places, err := s.places.SelectPlaces(ctx, &params)
+		if err != nil {
+			return err
+		}
+
+		for i := range places {
+			if err := fn(&places[i].Entry); err != nil {
+				return err
+			}
+		}
+
+		if len(places) < params.Limit {
+			return nil
+		}
+
+		params.After = &places[len(places)-1].cursor
+	}
+}
+
+// legacyCSV returns the CSV file of a job that ran before the results were
+// stored in the database, or "" when the results are in the database.
+func (s *Service) legacyCSV(ctx context.Context, id string) (string, error) {
+	if strings.Contains(id, "/") || strings.Contains(id, "\\") || strings.Contains(id, "..") {
+		return "", fmt.Errorf("invalid file name")
+	}
+
+	if err := s.checkOwner(ctx, id); err != nil {
+		return "", err
+	}
+
+	if s.places != nil {
+		count, err := s.places.CountPlaces(ctx, id)
+		if err != nil || count > 0 {
+			return "", err
+		}
+	}
+
+	// First check for merged CSV file
+	datapath := filepath.Join(s.dataFolder, id+".csv")
+	if _, err := os.Stat(datapath); err == nil {
+		return datapath, nil
+	}
+
+	// Then check for rotated CSV files ({id}_1.csv, {id}_2.csv, ...)
+	pattern := filepath.Join(s.dataFolder, id+"_*.csv")
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("error searching for csv files: %w", err)
+	}
+
+	if len(matches) == 0 {
+		if s.places == nil {
+			return "", fmt.Errorf("csv file not found for job %s", id)
+		}
+
+		return "", nil
+	}
+
+	sort.Strings(matches)
+	return matches[0], nil
+}
+
+// readCSVFile calls fn with each record of the CSV file, stripping the
+// UTF-8 BOM if present.
+func readCSVFile(path string, fn func([]string) error) error {
+	csvFile, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open csv: %w", err)
+	}
+	defer csvFile.Close()
+
+	reader := csv.NewReader(csvFile)
+	reader.FieldsPerRecord = -1
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		if first && len(record) > 0 {
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// selectColumns returns the indices of the fields in the headers, or all of
+// them when no field matches.
+func selectColumns(headers, fields []string) []int {
 	fieldSet := make(map[string]bool)
 	for _, f := range fields {
 		fieldSet[strings.TrimSpace(f)] = true
@@ -208,22 +706,27 @@ func filterRecords(records [][]string, fields []string) [][]string {
 	}
 
 	if len(indices) == 0 {
-		return records // no matching fields, return all
+		// no matching fields, keep all
+		for i := range headers {
+			indices = append(indices, i)
+		}
 	}
 
-	var result [][]string
-	for _, row := range records {
-		var filteredRow []string
-		for _, idx := range indices {
-			if idx < len(row) {
-				filteredRow = append(filteredRow, row[idx])
-			}
+	return indices
+}
+
+func pickColumns(row []string, indices []int) []string {
+	ans := make([]string, 0, len(indices))
+
+	for _, idx := range indices {
+		if idx < len(row) {
+			ans = append(ans, row[idx])
 		}
-		result = append(result, filteredRow)
 	}
 
-	return result
+	return ans
 }
+
 func (s *Service) GetJobCount(ctx context.Context, id string) (int, error) {
 	job, err := s.repo.Get(ctx, id)
 	if err != nil {
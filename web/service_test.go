@@ -0,0 +1,180 @@
+package web_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+func Test_ServicePauseResumeCancel(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusPending)
+
+	paused, err := svc.Pause(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, web.StatusPaused, paused.Status)
+
+	_, err = svc.Pause(t.Context(), job.ID)
+	require.ErrorIs(t, err, web.ErrInvalidStatus)
+
+	resumed, err := svc.Resume(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, web.StatusPending, resumed.Status)
+
+	_, err = svc.Resume(t.Context(), job.ID)
+	require.ErrorIs(t, err, web.ErrInvalidStatus)
+
+	cancelled, err := svc.Cancel(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, web.StatusCancelled, cancelled.Status)
+
+	// cancelled is for good
+	_, err = svc.Resume(t.Context(), job.ID)
+	require.ErrorIs(t, err, web.ErrInvalidStatus)
+
+	_, err = svc.Cancel(t.Context(), job.ID)
+	require.ErrorIs(t, err, web.ErrInvalidStatus)
+
+	stored, err := svc.Get(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, web.StatusCancelled, stored.Status)
+}
+
+func Test_ServicePauseWorkingJob(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusPending)
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	defer cancel(nil)
+
+	done, err := svc.StartJob(t.Context(), &job, cancel)
+	require.NoError(t, err)
+
+	// a working job can't be started twice
+	_, err = svc.StartJob(t.Context(), &job, cancel)
+	require.ErrorIs(t, err, web.ErrInvalidStatus)
+
+	_, err = svc.Pause(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.ErrorIs(t, context.Cause(ctx), web.ErrJobPaused)
+
+	// the runner is still saving the partial results
+	_, err = svc.Resume(t.Context(), job.ID)
+	require.ErrorIs(t, err, web.ErrInvalidStatus)
+
+	stored, err := svc.Get(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, web.StatusPaused, stored.Status)
+
+	done()
+
+	resumed, err := svc.Resume(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, web.StatusPending, resumed.Status)
+}
+
+func Test_ServiceCancelWorkingJob(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusPending)
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	defer cancel(nil)
+
+	done, err := svc.StartJob(t.Context(), &job, cancel)
+	require.NoError(t, err)
+
+	defer done()
+
+	cancelled, err := svc.Cancel(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, web.StatusCancelled, cancelled.Status)
+	require.ErrorIs(t, context.Cause(ctx), web.ErrJobCancelled)
+
+	// a job cancelled before it was started is not started
+	pending := createTestJob(t, repo, "", web.StatusPending)
+
+	_, err = svc.Cancel(t.Context(), pending.ID)
+	require.NoError(t, err)
+
+	_, err = svc.StartJob(t.Context(), &pending, cancel)
+	require.ErrorIs(t, err, web.ErrInvalidStatus)
+}
+
+func Test_ServiceQueueOrder(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	start := time.Now().UTC().Add(-time.Hour)
+
+	queue := func(name string, priority int, age time.Duration) web.Job {
+		job := web.Job{
+			ID:     uuid.New().String(),
+			Name:   name,
+			Date:   start.Add(age),
+			Status: web.StatusPending,
+			Data: web.JobData{
+				Keywords: []string{name},
+				Lang:     "en",
+				Depth:    1,
+				Priority: priority,
+			},
+		}
+
+		require.NoError(t, repo.Create(t.Context(), &job))
+
+		return job
+	}
+
+	oldest := queue("oldest", 0, 0)
+	urgent := queue("urgent", 5, 3*time.Minute)
+	first := queue("first", 1, time.Minute)
+	second := queue("second", 1, 2*time.Minute)
+	working := createTestJob(t, repo, "", web.StatusWorking)
+
+	want := []string{urgent.ID, first.ID, second.ID, oldest.ID}
+
+	for i, id := range want {
+		job, err := svc.Get(t.Context(), id)
+		require.NoError(t, err)
+		require.Equal(t, i+1, job.QueuePosition, job.Name)
+	}
+
+	jobs, err := svc.All(t.Context())
+	require.NoError(t, err)
+
+	for _, job := range jobs {
+		if job.ID == working.ID {
+			require.Zero(t, job.QueuePosition)
+		} else {
+			require.Equal(t, slices.Index(want, job.ID)+1, job.QueuePosition, job.Name)
+		}
+	}
+
+	// the runner takes them in the same order
+	for _, id := range want {
+		pending, err := svc.SelectPending(t.Context())
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		require.Equal(t, id, pending[0].ID)
+
+		ctx, cancel := context.WithCancelCause(t.Context())
+		defer cancel(nil)
+
+		done, err := svc.StartJob(ctx, &pending[0], cancel)
+		require.NoError(t, err)
+
+		done()
+	}
+
+	pending, err := svc.SelectPending(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+var _ web.DeliveryRepository = (*repo)(nil)
+
+const deliveryColumns = `id, job_id, event, url, payload, status, attempts, next_attempt, last_error, response_code, created_at, delivered_at`
+
+func (repo *repo) CreateDelivery(ctx context.Context, d *web.Delivery) error {
+	const q = `INSERT INTO webhook_deliveries (` + deliveryColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := repo.db.ExecContext(ctx, q,
+		d.ID, d.JobID, d.Event, d.URL, d.Payload, d.Status, d.Attempts, unixOrZero(d.NextAttempt),
+		d.LastError, d.ResponseCode, d.CreatedAt.Unix(), unixOrZero(d.DeliveredAt),
+	)
+
+	return err
+}
+
+func (repo *repo) UpdateDelivery(ctx context.Context, d *web.Delivery) error {
+	const q = `UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt = ?, last_error = ?, response_code = ?, delivered_at = ? WHERE id = ?`
+
+	_, err := repo.db.ExecContext(ctx, q,
+		d.Status, d.Attempts, unixOrZero(d.NextAttempt), d.LastError, d.ResponseCode, unixOrZero(d.DeliveredAt), d.ID,
+	)
+
+	return err
+}
+
+func (repo *repo) SelectDeliveries(ctx context.Context, params web.DeliveryParams) ([]web.Delivery, error) {
+	q := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries`
+
+	var args []any
+
+	switch {
+	case !params.DueBefore.IsZero():
+		q += ` WHERE status = ? AND next_attempt <= ? ORDER BY next_attempt ASC`
+
+		args = append(args, web.DeliveryPending, params.DueBefore.Unix())
+	default:
+		q += ` WHERE job_id = ? ORDER BY created_at DESC, rowid DESC`
+
+		args = append(args, params.JobID)
+	}
+
+	if params.Limit > 0 {
+		q += ` LIMIT ?`
+
+		args = append(args, params.Limit)
+	}
+
+	rows, err := repo.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []web.Delivery
+
+	for rows.Next() {
+		var (
+			d                                   web.Delivery
+			nextAttempt, createdAt, deliveredAt int64
+		)
+
+		err := rows.Scan(&d.ID, &d.JobID, &d.Event, &d.URL, &d.Payload, &d.Status, &d.Attempts, &nextAttempt,
+			&d.LastError, &d.ResponseCode, &createdAt, &deliveredAt)
+		if err != nil {
+			return nil, err
+		}
+
+		d.NextAttempt = timeOrZero(nextAttempt)
+		d.CreatedAt = time.Unix(createdAt, 0).UTC()
+		d.DeliveredAt = timeOrZero(deliveredAt)
+
+		ans = append(ans, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+func createDeliverySchema(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			job_id TEXT NOT NULL,
+			event TEXT NOT NULL,
+			url TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt INT NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			response_code INTEGER NOT NULL DEFAULT 0,
+			created_at INT NOT NULL,
+			delivered_at INT NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_job_id ON webhook_deliveries (job_id)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt)`)
+
+	return err
+}
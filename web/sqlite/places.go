@@ -0,0 +1,258 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+var _ web.PlaceRepository = (*repo)(nil)
+
+func (repo *repo) AddPlaces(ctx context.Context, jobID string, entries []*gmaps.Entry) ([]*gmaps.Entry, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// places scraped again after a job was resumed are ignored
+	const q = `INSERT OR IGNORE INTO places
+		(job_id, data_id, title, category, categories, address, rating, review_count, website, phone, has_email, latitude, longitude, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	stmt, err := tx.PrepareContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	var added []*gmaps.Entry
+
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+
+		categories, err := json.Marshal(e.Categories)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := stmt.ExecContext(ctx,
+			jobID, e.DataID, e.Title, e.Category, string(categories), e.Address, e.ReviewRating, e.ReviewCount,
+			e.WebSite, e.Phone, len(e.Emails) > 0, e.Latitude, e.Longtitude, string(data),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+
+		if n > 0 {
+			added = append(added, e)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return added, nil
+}
+
+// SelectPlaces pages with the sort key and the insertion order of the
+// places, so pages do not shift while a job writes more places.
+func (repo *repo) SelectPlaces(ctx context.Context, params *web.PlaceParams) ([]web.Place, error) {
+	var (
+		key     string
+		keyArgs []any
+		desc    bool
+		where   = []string{`job_id = ?`}
+		args    = []any{params.JobID}
+	)
+
+	switch params.Sort {
+	case web.SortRating:
+		key, desc = `rating`, true
+	case web.SortReviews:
+		key, desc = `review_count`, true
+	case web.SortDistance:
+		// the squared distance on an equirectangular projection, which
+		// keeps the order of the distances at the scale of a search
+		scale := math.Cos(params.Lat * math.Pi / 180)
+
+		key = `((latitude - ?) * (latitude - ?) + (longitude - ?) * (longitude - ?) * ?)`
+
+		keyArgs = []any{params.Lat, params.Lat, params.Lon, params.Lon, scale * scale}
+	default:
+		key = `0`
+	}
+
+	if params.Category != "" {
+		where = append(where, `(category = ? COLLATE NOCASE OR EXISTS (SELECT 1 FROM json_each(categories) WHERE value = ? COLLATE NOCASE))`)
+		args = append(args, params.Category, params.Category)
+	}
+
+	if params.MinRating > 0 {
+		where = append(where, `rating >= ?`)
+		args = append(args, params.MinRating)
+	}
+
+	if params.MinReviews > 0 {
+		where = append(where, `review_count >= ?`)
+		args = append(args, params.MinReviews)
+	}
+
+	if params.HasWebsite {
+		where = append(where, `website != ''`)
+	}
+
+	if params.HasPhone {
+		where = append(where, `phone != ''`)
+	}
+
+	if params.HasEmail {
+		where = append(where, `has_email = 1`)
+	}
+
+	if params.BBox != nil {
+		where = append(where, `latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?`)
+		args = append(args, params.BBox[0], params.BBox[2], params.BBox[1], params.BBox[3])
+	}
+
+	if params.Query != "" {
+		like := "%" + escapeLike(params.Query) + "%"
+
+		where = append(where, `(title LIKE ? ESCAPE '\' OR category LIKE ? ESCAPE '\' OR address LIKE ? ESCAPE '\')`)
+		args = append(args, like, like, like)
+	}
+
+	q := `SELECT seq, ` + key + ` AS sort_key, data FROM places WHERE ` + strings.Join(where, ` AND `)
+
+	order := `sort_key ASC, seq ASC`
+	if desc {
+		order = `sort_key DESC, seq ASC`
+	}
+
+	if params.After != nil {
+		if desc {
+			q += ` AND (` + key + ` < ? OR (` + key + ` = ? AND seq > ?))`
+		} else {
+			q += ` AND (` + key + ` > ? OR (` + key + ` = ? AND seq > ?))`
+		}
+
+		args = append(args, keyArgs...)
+		args = append(args, params.After.Key)
+		args = append(args, keyArgs...)
+		args = append(args, params.After.Key, params.After.Seq)
+	}
+
+	q += ` ORDER BY ` + order + ` LIMIT ?`
+
+	args = append(args, params.Limit)
+	// the key is selected first, its arguments come before the ones of
+	// the filters
+	args = append(append([]any{}, keyArgs...), args...)
+
+	rows, err := repo.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []web.Place
+
+	for rows.Next() {
+		var (
+			cursor web.PlaceCursor
+			data   string
+			entry  gmaps.Entry
+		)
+
+		if err := rows.Scan(&cursor.Seq, &cursor.Key, &data); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, err
+		}
+
+		ans = append(ans, web.NewPlace(&entry, cursor))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+func (repo *repo) CountPlaces(ctx context.Context, jobID string) (int, error) {
+	var n int
+
+	err := repo.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM places WHERE job_id = ?`, jobID).Scan(&n)
+
+	return n, err
+}
+
+func (repo *repo) DeletePlaces(ctx context.Context, jobID string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM places WHERE job_id = ?`, jobID)
+
+	return err
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func createPlaceSchema(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS places (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_id TEXT NOT NULL,
+			data_id TEXT NOT NULL DEFAULT '',
+			title TEXT NOT NULL DEFAULT '',
+			category TEXT NOT NULL DEFAULT '',
+			categories TEXT NOT NULL DEFAULT '[]',
+			address TEXT NOT NULL DEFAULT '',
+			rating REAL NOT NULL DEFAULT 0,
+			review_count INTEGER NOT NULL DEFAULT 0,
+			website TEXT NOT NULL DEFAULT '',
+			phone TEXT NOT NULL DEFAULT '',
+			has_email INTEGER NOT NULL DEFAULT 0,
+			latitude REAL NOT NULL DEFAULT 0,
+			longitude REAL NOT NULL DEFAULT 0,
+			data TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_places_job_id ON places (job_id, seq)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_places_job_id_data_id ON places (job_id, data_id) WHERE data_id != ''`)
+
+	return err
+}
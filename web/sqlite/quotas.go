@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+var _ web.QuotaRepository = (*repo)(nil)
+
+func (repo *repo) GetQuota(ctx context.Context, owner string) (web.Quota, error) {
+	const q = `SELECT owner, max_jobs, max_places_per_day FROM quotas WHERE owner = ?`
+
+	var ans web.Quota
+
+	err := repo.db.QueryRowContext(ctx, q, owner).Scan(&ans.Owner, &ans.MaxJobs, &ans.MaxPlacesPerDay)
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Quota{}, web.ErrNotFound
+	}
+
+	return ans, err
+}
+
+func (repo *repo) SetQuota(ctx context.Context, quota *web.Quota) error {
+	const q = `INSERT INTO quotas (owner, max_jobs, max_places_per_day) VALUES (?, ?, ?)
+		ON CONFLICT (owner) DO UPDATE SET max_jobs = excluded.max_jobs, max_places_per_day = excluded.max_places_per_day`
+
+	_, err := repo.db.ExecContext(ctx, q, quota.Owner, quota.MaxJobs, quota.MaxPlacesPerDay)
+
+	return err
+}
+
+func (repo *repo) SelectQuotas(ctx context.Context) ([]web.Quota, error) {
+	const q = `SELECT owner, max_jobs, max_places_per_day FROM quotas ORDER BY owner`
+
+	rows, err := repo.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []web.Quota
+
+	for rows.Next() {
+		var quota web.Quota
+
+		if err := rows.Scan(&quota.Owner, &quota.MaxJobs, &quota.MaxPlacesPerDay); err != nil {
+			return nil, err
+		}
+
+		ans = append(ans, quota)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+func (repo *repo) AddUsage(ctx context.Context, owner string, day time.Time, places int) error {
+	const q = `INSERT INTO usage (owner, day, places) VALUES (?, ?, ?)
+		ON CONFLICT (owner, day) DO UPDATE SET places = places + excluded.places`
+
+	_, err := repo.db.ExecContext(ctx, q, owner, day.Format(time.DateOnly), places)
+
+	return err
+}
+
+func (repo *repo) Usage(ctx context.Context, owner string, day time.Time) (int, error) {
+	const q = `SELECT COALESCE(SUM(places), 0) FROM usage WHERE owner = ? AND day = ?`
+
+	var ans int
+
+	err := repo.db.QueryRowContext(ctx, q, owner, day.Format(time.DateOnly)).Scan(&ans)
+
+	return ans, err
+}
+
+func createQuotaSchema(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS quotas (
+			owner TEXT PRIMARY KEY,
+			max_jobs INTEGER NOT NULL DEFAULT 0,
+			max_places_per_day INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS usage (
+			owner TEXT NOT NULL,
+			day TEXT NOT NULL,
+			places INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (owner, day)
+		)
+	`)
+
+	return err
+}
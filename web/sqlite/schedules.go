@@ -0,0 +1,272 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+var _ web.ScheduleRepository = (*repo)(nil)
+
+const scheduleColumns = `id, name, owner, cron, data, skip_if_running, enabled, next_run, last_run, created_at`
+
+func (repo *repo) GetSchedule(ctx context.Context, id string) (web.Schedule, error) {
+	q := `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = ?`
+
+	row := repo.db.QueryRowContext(ctx, q, id)
+
+	schedule, err := rowToSchedule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Schedule{}, web.ErrNotFound
+	}
+
+	return schedule, err
+}
+
+func (repo *repo) CreateSchedule(ctx context.Context, schedule *web.Schedule) error {
+	data, err := json.Marshal(schedule.Data)
+	if err != nil {
+		return err
+	}
+
+	const q = `INSERT INTO schedules (id, name, owner, cron, data, skip_if_running, enabled, next_run, last_run, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err = repo.db.ExecContext(ctx, q,
+		schedule.ID, schedule.Name, schedule.Owner, schedule.Cron, string(data), schedule.SkipIfRunning, schedule.Enabled,
+		unixOrZero(schedule.NextRun), unixOrZero(schedule.LastRun), schedule.CreatedAt.Unix(), time.Now().UTC().Unix(),
+	)
+
+	return err
+}
+
+func (repo *repo) UpdateSchedule(ctx context.Context, schedule *web.Schedule) error {
+	data, err := json.Marshal(schedule.Data)
+	if err != nil {
+		return err
+	}
+
+	const q = `UPDATE schedules SET name = ?, cron = ?, data = ?, skip_if_running = ?, enabled = ?, next_run = ?, last_run = ?, updated_at = ? WHERE id = ?`
+
+	_, err = repo.db.ExecContext(ctx, q,
+		schedule.Name, schedule.Cron, string(data), schedule.SkipIfRunning, schedule.Enabled,
+		unixOrZero(schedule.NextRun), unixOrZero(schedule.LastRun), time.Now().UTC().Unix(), schedule.ID,
+	)
+
+	return err
+}
+
+func (repo *repo) DeleteSchedule(ctx context.Context, id string) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schedule_runs WHERE schedule_id = ?`, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schedules WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *repo) SelectSchedules(ctx context.Context, params web.ScheduleParams) ([]web.Schedule, error) {
+	q := `SELECT ` + scheduleColumns + ` FROM schedules`
+
+	var (
+		where []string
+		args  []any
+	)
+
+	if !params.DueBefore.IsZero() {
+		where = append(where, `enabled = 1 AND next_run <= ?`)
+		args = append(args, params.DueBefore.Unix())
+	}
+
+	if params.Owner != "" {
+		where = append(where, `owner = ?`)
+		args = append(args, params.Owner)
+	}
+
+	if len(where) > 0 {
+		q += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+
+	q += ` ORDER BY created_at DESC`
+
+	rows, err := repo.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []web.Schedule
+
+	for rows.Next() {
+		schedule, err := rowToSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		ans = append(ans, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+func (repo *repo) CreateScheduleRun(ctx context.Context, run *web.ScheduleRun) error {
+	const q = `INSERT INTO schedule_runs (schedule_id, job_id, status, created_at) VALUES (?, ?, ?, ?)`
+
+	_, err := repo.db.ExecContext(ctx, q, run.ScheduleID, run.JobID, run.Status, run.Date.Unix())
+
+	return err
+}
+
+// SelectScheduleRuns returns the runs of a schedule, latest first, with the
+// current status of their jobs.
+func (repo *repo) SelectScheduleRuns(ctx context.Context, params web.ScheduleRunParams) ([]web.ScheduleRun, error) {
+	q := `SELECT r.schedule_id, r.job_id, r.status, r.created_at, COALESCE(j.status, '')
+		FROM schedule_runs r LEFT JOIN jobs j ON j.id = r.job_id
+		WHERE r.schedule_id = ?`
+
+	args := []any{params.ScheduleID}
+
+	if params.Status != "" {
+		q += ` AND r.status = ?`
+
+		args = append(args, params.Status)
+	}
+
+	q += ` ORDER BY r.id DESC`
+
+	if params.Limit > 0 {
+		q += ` LIMIT ?`
+
+		args = append(args, params.Limit)
+	}
+
+	rows, err := repo.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []web.ScheduleRun
+
+	for rows.Next() {
+		var (
+			run       web.ScheduleRun
+			createdAt int64
+		)
+
+		if err := rows.Scan(&run.ScheduleID, &run.JobID, &run.Status, &createdAt, &run.JobStatus); err != nil {
+			return nil, err
+		}
+
+		run.Date = time.Unix(createdAt, 0).UTC()
+
+		ans = append(ans, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+func rowToSchedule(row scannable) (web.Schedule, error) {
+	var (
+		ans                         web.Schedule
+		data                        string
+		nextRun, lastRun, createdAt int64
+	)
+
+	err := row.Scan(&ans.ID, &ans.Name, &ans.Owner, &ans.Cron, &data, &ans.SkipIfRunning, &ans.Enabled, &nextRun, &lastRun, &createdAt)
+	if err != nil {
+		return web.Schedule{}, err
+	}
+
+	if err := json.Unmarshal([]byte(data), &ans.Data); err != nil {
+		return web.Schedule{}, err
+	}
+
+	ans.NextRun = timeOrZero(nextRun)
+	ans.LastRun = timeOrZero(lastRun)
+	ans.CreatedAt = time.Unix(createdAt, 0).UTC()
+
+	return ans, nil
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
+
+func timeOrZero(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(sec, 0).UTC()
+}
+
+func createScheduleSchema(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schedules (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			owner TEXT NOT NULL DEFAULT '',
+			cron TEXT NOT NULL,
+			data TEXT NOT NULL,
+			skip_if_running INTEGER NOT NULL DEFAULT 0,
+			enabled INTEGER NOT NULL DEFAULT 1,
+			next_run INT NOT NULL DEFAULT 0,
+			last_run INT NOT NULL DEFAULT 0,
+			created_at INT NOT NULL,
+			updated_at INT NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, _ = db.Exec(`ALTER TABLE schedules ADD COLUMN owner TEXT NOT NULL DEFAULT ''`)
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS schedule_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			schedule_id TEXT NOT NULL,
+			job_id TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			created_at INT NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_schedule_runs_schedule_id ON schedule_runs (schedule_id, id)`)
+
+	return err
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite" // sqlite driver
@@ -25,11 +27,16 @@ func New(path string) (web.JobRepository, error) {
 }
 
 func (repo *repo) Get(ctx context.Context, id string) (web.Job, error) {
-	const q = `SELECT id, name, status, data, count, created_at, updated_at FROM jobs WHERE id = ?`
+	const q = `SELECT id, name, owner, status, data, count, created_at, updated_at FROM jobs WHERE id = ?`
 
 	row := repo.db.QueryRowContext(ctx, q, id)
 
-	return rowToJob(row)
+	job, err := rowToJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Job{}, web.ErrNotFound
+	}
+
+	return job, err
 }
 
 func (repo *repo) Create(ctx context.Context, job *web.Job) error {
@@ -38,9 +45,9 @@ func (repo *repo) Create(ctx context.Context, job *web.Job) error {
 		return err
 	}
 
-	const q = `INSERT INTO jobs (id, name, status, data, count, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	const q = `INSERT INTO jobs (id, name, owner, status, data, count, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err = repo.db.ExecContext(ctx, q, item.ID, item.Name, item.Status, item.Data, item.Count, item.CreatedAt, item.UpdatedAt)
+	_, err = repo.db.ExecContext(ctx, q, item.ID, item.Name, item.Owner, item.Status, item.Data, item.Count, item.CreatedAt, item.UpdatedAt)
 	if err != nil {
 		return err
 	}
@@ -57,17 +64,32 @@ func (repo *repo) Delete(ctx context.Context, id string) error {
 }
 
 func (repo *repo) Select(ctx context.Context, params web.SelectParams) ([]web.Job, error) {
-	q := `SELECT id, name, status, data, count, created_at, updated_at FROM jobs`
+	q := `SELECT id, name, owner, status, data, count, created_at, updated_at FROM jobs`
 
-	var args []any
+	var (
+		where []string
+		args  []any
+	)
 
 	if params.Status != "" {
-		q += ` WHERE status = ?`
-
+		where = append(where, `status = ?`)
 		args = append(args, params.Status)
 	}
 
-	q += " ORDER BY created_at DESC"
+	if params.Owner != "" {
+		where = append(where, `owner = ?`)
+		args = append(args, params.Owner)
+	}
+
+	if len(where) > 0 {
+		q += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+
+	if params.Queue {
+		q += " ORDER BY COALESCE(json_extract(data, '$.priority'), 0) DESC, created_at ASC, rowid ASC"
+	} else {
+		q += " ORDER BY created_at DESC"
+	}
 
 	if params.Limit > 0 {
 		q += " LIMIT ?"
@@ -128,7 +150,7 @@ type scannable interface {
 func rowToJob(row scannable) (web.Job, error) {
 	var j job
 
-	err := row.Scan(&j.ID, &j.Name, &j.Status, &j.Data, &j.Count, &j.CreatedAt, &j.UpdatedAt)
+	err := row.Scan(&j.ID, &j.Name, &j.Owner, &j.Status, &j.Data, &j.Count, &j.CreatedAt, &j.UpdatedAt)
 	if err != nil {
 		return web.Job{}, err
 	}
@@ -136,6 +158,7 @@ func rowToJob(row scannable) (web.Job, error) {
 	ans := web.Job{
 		ID:     j.ID,
 		Name:   j.Name,
+		Owner:  j.Owner,
 		Status: j.Status,
 		Date:   time.Unix(j.CreatedAt, 0).UTC(),
 		Count:  j.Count,
@@ -158,6 +181,7 @@ func jobToRow(item *web.Job) (job, error) {
 	return job{
 		ID:        item.ID,
 		Name:      item.Name,
+		Owner:     item.Owner,
 		Status:    item.Status,
 		Data:      string(data),
 		Count:     item.Count,
@@ -169,6 +193,7 @@ func jobToRow(item *web.Job) (job, error) {
 type job struct {
 	ID        string
 	Name      string
+	Owner     string
 	Status    string
 	Data      string
 	Count     int
@@ -233,6 +258,23 @@ func createSchema(db *sql.DB) error {
 	// Migration: add count column if it doesn't exist
 	// Ignore error if it already exists
 	_, _ = db.Exec(`ALTER TABLE jobs ADD COLUMN count INTEGER NOT NULL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE jobs ADD COLUMN owner TEXT NOT NULL DEFAULT ''`)
 
-	return nil
+	if err := createScheduleSchema(db); err != nil {
+		return err
+	}
+
+	if err := createTokenSchema(db); err != nil {
+		return err
+	}
+
+	if err := createQuotaSchema(db); err != nil {
+		return err
+	}
+
+	if err := createDeliverySchema(db); err != nil {
+		return err
+	}
+
+	return createPlaceSchema(db)
 }
@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+var _ web.TokenRepository = (*repo)(nil)
+
+const tokenColumns = `t.id, t.name, t.owner, t.admin, t.hint, t.created_at, t.revoked_at`
+
+func (repo *repo) CreateToken(ctx context.Context, token *web.Token, hash string) error {
+	const q = `INSERT INTO api_tokens (id, name, owner, admin, hint, hash, created_at, revoked_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := repo.db.ExecContext(ctx, q,
+		token.ID, token.Name, token.Owner, token.Admin, token.Hint, hash, token.CreatedAt.Unix(), unixOrZero(token.RevokedAt),
+	)
+
+	return err
+}
+
+func (repo *repo) SelectTokens(ctx context.Context) ([]web.Token, error) {
+	q := `SELECT ` + tokenColumns + ` FROM api_tokens t ORDER BY t.created_at DESC`
+
+	rows, err := repo.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var ans []web.Token
+
+	for rows.Next() {
+		token, err := rowToToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		ans = append(ans, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+func (repo *repo) RevokeToken(ctx context.Context, id string, at time.Time) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at = 0`, at.Unix(), id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return web.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE token_id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *repo) TokenByHash(ctx context.Context, hash string) (web.Token, error) {
+	q := `SELECT ` + tokenColumns + ` FROM api_tokens t WHERE t.hash = ?`
+
+	token, err := rowToToken(repo.db.QueryRowContext(ctx, q, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Token{}, web.ErrNotFound
+	}
+
+	return token, err
+}
+
+func (repo *repo) CreateSession(ctx context.Context, hash, tokenID string, expiresAt time.Time) error {
+	// expired sessions are only removed here, there are few of them
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= ?`, time.Now().UTC().Unix()); err != nil {
+		return err
+	}
+
+	const q = `INSERT INTO sessions (hash, token_id, expires_at) VALUES (?, ?, ?)`
+
+	_, err := repo.db.ExecContext(ctx, q, hash, tokenID, expiresAt.Unix())
+
+	return err
+}
+
+func (repo *repo) SessionToken(ctx context.Context, hash string, now time.Time) (web.Token, error) {
+	q := `SELECT ` + tokenColumns + ` FROM sessions s JOIN api_tokens t ON t.id = s.token_id
+		WHERE s.hash = ? AND s.expires_at > ?`
+
+	token, err := rowToToken(repo.db.QueryRowContext(ctx, q, hash, now.Unix()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return web.Token{}, web.ErrNotFound
+	}
+
+	return token, err
+}
+
+func (repo *repo) DeleteSession(ctx context.Context, hash string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM sessions WHERE hash = ?`, hash)
+
+	return err
+}
+
+func rowToToken(row scannable) (web.Token, error) {
+	var (
+		ans                  web.Token
+		createdAt, revokedAt int64
+	)
+
+	if err := row.Scan(&ans.ID, &ans.Name, &ans.Owner, &ans.Admin, &ans.Hint, &createdAt, &revokedAt); err != nil {
+		return web.Token{}, err
+	}
+
+	ans.CreatedAt = time.Unix(createdAt, 0).UTC()
+	ans.RevokedAt = timeOrZero(revokedAt)
+
+	return ans, nil
+}
+
+func createTokenSchema(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_tokens (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			owner TEXT NOT NULL DEFAULT '',
+			admin INTEGER NOT NULL DEFAULT 0,
+			hint TEXT NOT NULL,
+			hash TEXT NOT NULL UNIQUE,
+			created_at INT NOT NULL,
+			revoked_at INT NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, _ = db.Exec(`ALTER TABLE api_tokens ADD COLUMN owner TEXT NOT NULL DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE api_tokens ADD COLUMN admin INTEGER NOT NULL DEFAULT 0`)
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS sessions (
+			hash TEXT PRIMARY KEY,
+			token_id TEXT NOT NULL,
+			expires_at INT NOT NULL
+		)
+	`)
+
+	return err
+}
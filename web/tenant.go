@@ -0,0 +1,205 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// QuotaRepository stores the quotas of the owners and the places their jobs
+// wrote each day. It is optional, the service checks if the job repository
+// implements it.
+type QuotaRepository interface {
+	// GetQuota returns ErrNotFound for owners without a quota.
+	GetQuota(ctx context.Context, owner string) (Quota, error)
+	SetQuota(ctx context.Context, quota *Quota) error
+	SelectQuotas(ctx context.Context) ([]Quota, error)
+	AddUsage(ctx context.Context, owner string, day time.Time, places int) error
+	Usage(ctx context.Context, owner string, day time.Time) (int, error)
+}
+
+// Quota limits the jobs of an owner, a user or team that shares the server
+// with others. Zero means no limit.
+type Quota struct {
+	Owner string `json:"owner"`
+	// MaxJobs is how many jobs of the owner can run at once. Further jobs
+	// wait in the queue.
+	MaxJobs int `json:"max_jobs"`
+	// MaxPlacesPerDay is how many places the jobs of the owner can write in
+	// a UTC day. Jobs that reach it are paused, and pending jobs wait for
+	// the next day.
+	MaxPlacesPerDay int `json:"max_places_per_day"`
+}
+
+// Usage is the quota of an owner with what the owner uses of it.
+type Usage struct {
+	Quota
+	RunningJobs int `json:"running_jobs"`
+	PlacesToday int `json:"places_today"`
+}
+
+type ownerCtxKey struct{}
+
+// WithOwner returns a context for the requests of the owner. The service
+// only shows and changes the jobs and schedules of the owner of the
+// context, and creates them for that owner. Without an owner, as when the
+// server runs without authentication, everything is visible.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerCtxKey{}, owner)
+}
+
+func OwnerFromContext(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerCtxKey{}).(string)
+
+	return owner
+}
+
+// visible reports whether the caller can see a job or schedule of the owner.
+// The jobs and schedules without an owner, created before authentication
+// was turned on, are only visible to callers without an owner: admin tokens
+// and servers without authentication.
+func visible(ctx context.Context, owner string) bool {
+	caller := OwnerFromContext(ctx)
+
+	return caller == "" || caller == owner
+}
+
+func (s *Service) SetQuota(ctx context.Context, quota *Quota) error {
+	if s.quotas == nil {
+		return ErrNoQuotas
+	}
+
+	if quota.Owner == "" {
+		return errors.New("missing owner")
+	}
+
+	if quota.MaxJobs < 0 || quota.MaxPlacesPerDay < 0 {
+		return errors.New("quota must not be negative")
+	}
+
+	return s.quotas.SetQuota(ctx, quota)
+}
+
+func (s *Service) Quotas(ctx context.Context) ([]Quota, error) {
+	if s.quotas == nil {
+		return nil, ErrNoQuotas
+	}
+
+	return s.quotas.SelectQuotas(ctx)
+}
+
+// Usage returns the quota and the usage of the owner of the context.
+func (s *Service) Usage(ctx context.Context) (Usage, error) {
+	owner := OwnerFromContext(ctx)
+
+	ans := Usage{Quota: Quota{Owner: owner}}
+
+	if s.quotas == nil || owner == "" {
+		return ans, nil
+	}
+
+	quota, err := s.quota(ctx, owner)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	ans.Quota = quota
+
+	if ans.PlacesToday, err = s.quotas.Usage(ctx, owner, today()); err != nil {
+		return Usage{}, err
+	}
+
+	running, err := s.repo.Select(ctx, SelectParams{Status: StatusWorking, Owner: owner})
+	if err != nil {
+		return Usage{}, err
+	}
+
+	ans.RunningJobs = len(running)
+
+	return ans, nil
+}
+
+// RecordPlaces adds the places written by the job to its count and to the
+// daily usage of its owner. When the owner reaches the daily quota, the job
+// is paused with ErrQuotaExceeded as the cause, like from the API, and
+// ErrQuotaExceeded is returned.
+func (s *Service) RecordPlaces(ctx context.Context, job *Job, amount int) error {
+	if err := s.repo.IncrementCount(ctx, job.ID, amount); err != nil {
+		return err
+	}
+
+	if s.quotas == nil || job.Owner == "" {
+		return nil
+	}
+
+	if err := s.quotas.AddUsage(ctx, job.Owner, today(), amount); err != nil {
+		return err
+	}
+
+	quota, err := s.quota(ctx, job.Owner)
+	if err != nil || quota.MaxPlacesPerDay == 0 {
+		return err
+	}
+
+	used, err := s.quotas.Usage(ctx, job.Owner, today())
+	if err != nil || used < quota.MaxPlacesPerDay {
+		return err
+	}
+
+	_, err = s.stop(context.Background(), job.ID, StatusPaused, ErrQuotaExceeded, StatusWorking)
+	if errors.Is(err, ErrInvalidStatus) {
+		// paused already
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: %d places today", ErrQuotaExceeded, used)
+}
+
+// canStart reports whether a job of the owner can start without going over
+// its quota. running is the number of working jobs per owner.
+func (s *Service) canStart(ctx context.Context, owner string, running map[string]int) (bool, error) {
+	if s.quotas == nil || owner == "" {
+		return true, nil
+	}
+
+	quota, err := s.quota(ctx, owner)
+	if err != nil {
+		return false, err
+	}
+
+	if quota.MaxJobs > 0 && running[owner] >= quota.MaxJobs {
+		return false, nil
+	}
+
+	if quota.MaxPlacesPerDay > 0 {
+		used, err := s.quotas.Usage(ctx, owner, today())
+		if err != nil {
+			return false, err
+		}
+
+		if used >= quota.MaxPlacesPerDay {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// quota returns the quota of the owner, without limits when it has none.
+func (s *Service) quota(ctx context.Context, owner string) (Quota, error) {
+	quota, err := s.quotas.GetQuota(ctx, owner)
+	if errors.Is(err, ErrNotFound) {
+		return Quota{Owner: owner}, nil
+	}
+
+	return quota, err
+}
+
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
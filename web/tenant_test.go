@@ -0,0 +1,155 @@
+package web_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+func Test_OwnerScope(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	growth := web.WithOwner(t.Context(), "growth")
+	sales := web.WithOwner(t.Context(), "sales")
+
+	job := createTestJob(t, repo, "growth", web.StatusOK)
+
+	_, err := svc.Get(growth, job.ID)
+	require.NoError(t, err)
+
+	// other owners can't tell the job exists
+	_, err = svc.Get(sales, job.ID)
+	require.ErrorIs(t, err, web.ErrNotFound)
+
+	require.ErrorIs(t, svc.Delete(sales, job.ID), web.ErrNotFound)
+
+	_, err = svc.Cancel(sales, job.ID)
+	require.ErrorIs(t, err, web.ErrNotFound)
+
+	jobs, err := svc.All(sales)
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+
+	require.NoError(t, svc.Delete(growth, job.ID))
+
+	_, err = svc.Get(growth, job.ID)
+	require.ErrorIs(t, err, web.ErrNotFound)
+}
+
+func Test_OwnerlessJobs(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	srv, err := web.New(svc, ":0", web.WithAuth())
+	require.NoError(t, err)
+
+	legacy := createTestJob(t, repo, "", web.StatusOK)
+	owned := createTestJob(t, repo, "growth", web.StatusOK)
+
+	_, secret, err := svc.CreateToken(t.Context(), "alice", "growth", false)
+	require.NoError(t, err)
+
+	_, adminSecret, err := svc.CreateToken(t.Context(), "ops", "", true)
+	require.NoError(t, err)
+
+	list := func(secret string) []string {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+secret)
+
+		rec := serve(srv.Handler(), req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var jobs []web.Job
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jobs))
+
+		var ids []string
+		for i := range jobs {
+			ids = append(ids, jobs[i].ID)
+		}
+
+		return ids
+	}
+
+	// the jobs from before authentication have no owner to see them
+	require.Equal(t, []string{owned.ID}, list(secret))
+
+	// but admin tokens see every job
+	require.ElementsMatch(t, []string{legacy.ID, owned.ID}, list(adminSecret))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/jobs/"+legacy.ID, http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+adminSecret)
+
+	require.Equal(t, http.StatusOK, serve(srv.Handler(), req).Code)
+	require.Equal(t, []string{owned.ID}, list(adminSecret))
+}
+
+func Test_QuotaPausesJob(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	require.NoError(t, svc.SetQuota(t.Context(), &web.Quota{Owner: "growth", MaxPlacesPerDay: 5}))
+
+	job := createTestJob(t, repo, "growth", web.StatusPending)
+	createTestJob(t, repo, "growth", web.StatusPending)
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	defer cancel(nil)
+
+	done, err := svc.StartJob(t.Context(), &job, cancel)
+	require.NoError(t, err)
+
+	defer done()
+
+	require.NoError(t, svc.RecordPlaces(t.Context(), &job, 3))
+	require.NoError(t, ctx.Err())
+
+	err = svc.RecordPlaces(t.Context(), &job, 3)
+	require.ErrorIs(t, err, web.ErrQuotaExceeded)
+	require.ErrorIs(t, context.Cause(ctx), web.ErrQuotaExceeded)
+
+	stored, err := svc.Get(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, web.StatusPaused, stored.Status)
+	require.Equal(t, 6, stored.Count)
+
+	usage, err := svc.Usage(web.WithOwner(t.Context(), "growth"))
+	require.NoError(t, err)
+	require.Equal(t, 6, usage.PlacesToday)
+
+	// the pending jobs of the owner wait for the next day, the others start
+	pending, err := svc.SelectPending(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	other := createTestJob(t, repo, "sales", web.StatusPending)
+
+	pending, err = svc.SelectPending(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, other.ID, pending[0].ID)
+}
+
+func Test_QuotaMaxJobs(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	require.NoError(t, svc.SetQuota(t.Context(), &web.Quota{Owner: "growth", MaxJobs: 1}))
+
+	createTestJob(t, repo, "growth", web.StatusWorking)
+	createTestJob(t, repo, "growth", web.StatusPending)
+
+	pending, err := svc.SelectPending(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	other := createTestJob(t, repo, "sales", web.StatusPending)
+
+	pending, err = svc.SelectPending(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, other.ID, pending[0].ID)
+}
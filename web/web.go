@@ -4,19 +4,23 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/gosom/google-maps-scraper/diff"
+	"github.com/gosom/google-maps-scraper/geo"
 )
 
 //go:embed static
@@ -26,9 +30,21 @@ type Server struct {
 	tmpl map[string]*template.Template
 	srv  *http.Server
 	svc  *Service
+	auth bool
+}
+
+// ServerOption configures the Server.
+type ServerOption func(*Server)
+
+// WithAuth requires an API token for the REST API and a login for the
+// dashboard. The job repository must implement TokenRepository.
+func WithAuth() ServerOption {
+	return func(s *Server) {
+		s.auth = true
+	}
 }
 
-func New(svc *Service, addr string) (*Server, error) {
+func New(svc *Service, addr string, opts ...ServerOption) (*Server, error) {
 	ans := Server{
 		svc:  svc,
 		tmpl: make(map[string]*template.Template),
@@ -42,6 +58,14 @@ func New(svc *Service, addr string) (*Server, error) {
 		},
 	}
 
+	for _, opt := range opts {
+		opt(&ans)
+	}
+
+	if ans.auth && svc.tokens == nil {
+		return nil, ErrNoAuth
+	}
+
 	staticFS, err := fs.Sub(static, "static")
 	if err != nil {
 		return nil, err
@@ -63,6 +87,9 @@ func New(svc *Service, addr string) (*Server, error) {
 		ans.delete(w, r)
 	})
 	mux.HandleFunc("/jobs", ans.getJobs)
+	mux.HandleFunc("/proxies", ans.getProxies)
+	mux.HandleFunc("/login", ans.login)
+	mux.HandleFunc("/logout", ans.logout)
 	mux.HandleFunc("/", ans.index)
 
 	// api routes
@@ -118,14 +145,196 @@ func New(svc *Service, addr string) (*Server, error) {
 		ans.download(w, r)
 	})
 
-	handler := securityHeaders(mux)
+	for action, handler := range map[string]http.HandlerFunc{
+		"cancel": ans.apiCancelJob,
+		"pause":  ans.apiPauseJob,
+		"resume": ans.apiResumeJob,
+	} {
+		mux.HandleFunc("/api/v1/jobs/{id}/"+action, func(w http.ResponseWriter, r *http.Request) {
+			r = requestWithID(r)
+
+			if r.Method != http.MethodPost {
+				ans := apiError{
+					Code:    http.StatusMethodNotAllowed,
+					Message: "Method not allowed",
+				}
+
+				renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+				return
+			}
+
+			handler(w, r)
+		})
+	}
+
+	mux.HandleFunc("/api/v1/jobs/{id}/events", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiJobEvents(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiGetDeliveries(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/places", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiGetPlaces(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/jobs/{id}/diff", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiDiff(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/schedules", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			ans.apiCreateSchedule(w, r)
+		case http.MethodGet:
+			ans.apiGetSchedules(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/schedules/{id}", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		switch r.Method {
+		case http.MethodGet:
+			ans.apiGetSchedule(w, r)
+		case http.MethodPatch:
+			ans.apiUpdateSchedule(w, r)
+		case http.MethodDelete:
+			ans.apiDeleteSchedule(w, r)
+		default:
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+		}
+	})
+
+	mux.HandleFunc("/api/v1/schedules/{id}/runs", func(w http.ResponseWriter, r *http.Request) {
+		r = requestWithID(r)
+
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiGetScheduleRuns(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/usage", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiGetUsage(w, r)
+	})
+
+	mux.HandleFunc("/api/v1/proxies", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			ans := apiError{
+				Code:    http.StatusMethodNotAllowed,
+				Message: "Method not allowed",
+			}
+
+			renderJSON(w, http.StatusMethodNotAllowed, ans)
+
+			return
+		}
+
+		ans.apiGetProxies(w, r)
+	})
+
+	var handler http.Handler = mux
+
+	if ans.auth {
+		handler = ans.authenticate(handler)
+	}
+
+	handler = securityHeaders(handler)
 	ans.srv.Handler = handler
 
 	tmplsKeys := []string{
 		"static/templates/index.html",
 		"static/templates/job_rows.html",
 		"static/templates/job_row.html",
+		"static/templates/proxy_rows.html",
 		"static/templates/redoc.html",
+		"static/templates/login.html",
 	}
 
 	for _, key := range tmplsKeys {
@@ -140,6 +349,11 @@ func New(svc *Service, addr string) (*Server, error) {
 	return &ans, nil
 }
 
+// Handler returns the routes of the server behind its middleware.
+func (s *Server) Handler() http.Handler {
+	return s.srv.Handler
+}
+
 func (s *Server) Start(ctx context.Context) error {
 	go func() {
 		<-ctx.Done()
@@ -178,6 +392,8 @@ type formData struct {
 	Email       bool
 	Proxies     []string
 	SearchDelay int
+	// Auth shows the logout button.
+	Auth bool
 }
 
 type ctxKey string
@@ -241,6 +457,7 @@ func (s *Server) index(w http.ResponseWriter, r *http.Request) {
 		Depth:       6,
 		Email:       false,
 		SearchDelay: 5,
+		Auth:        s.auth,
 	}
 
 	_ = tmpl.Execute(w, data)
@@ -334,6 +551,30 @@ func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
 
 	newJob.Data.Email = r.Form.Get("email") == "on"
 
+	newJob.Data.ExtraReviews = r.Form.Get("extra_reviews") == "on"
+	newJob.Data.ReviewSort = r.Form.Get("review_sort")
+	newJob.Data.ReviewsSince = r.Form.Get("reviews_since")
+
+	if v := r.Form.Get("max_reviews"); v != "" {
+		newJob.Data.MaxReviews, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid max reviews", http.StatusUnprocessableEntity)
+
+			return
+		}
+	}
+
+	if v := r.Form.Get("priority"); v != "" {
+		newJob.Data.Priority, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid priority", http.StatusUnprocessableEntity)
+
+			return
+		}
+	}
+
+	newJob.Data.WebhookURL = strings.TrimSpace(r.Form.Get("webhook_url"))
+
 	searchDelay, sdErr := strconv.Atoi(r.Form.Get("search_delay"))
 	if sdErr == nil && searchDelay > 0 {
 		newJob.Data.SearchDelay = searchDelay
@@ -360,7 +601,7 @@ func (s *Server) scrape(w http.ResponseWriter, r *http.Request) {
 
 	err = s.svc.Create(r.Context(), &newJob)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), saveStatus(err))
 
 		return
 	}
@@ -388,7 +629,7 @@ func (s *Server) getJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobs, err := s.svc.All(context.Background())
+	jobs, err := s.svc.All(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 
@@ -405,6 +646,29 @@ func (s *Server) getJobs(w http.ResponseWriter, r *http.Request) {
 	_ = tmpl.Execute(w, jobs)
 }
 
+func (s *Server) getProxies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	tmpl, ok := s.tmpl["static/templates/proxy_rows.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
+		return
+	}
+
+	pools, err := s.svc.ProxyPools(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	_ = tmpl.Execute(w, pools)
+}
+
 func (s *Server) download(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -429,62 +693,115 @@ func (s *Server) download(w http.ResponseWriter, r *http.Request) {
 		fields = strings.Split(fieldsParam, ",")
 	}
 
-	if format == "excel" {
-		filePath, err := s.svc.GetExcel(ctx, id.String(), fields)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
+	if _, err := s.svc.Get(ctx, id.String()); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 
-		file, err := os.Open(filePath)
-		if err != nil {
-			http.Error(w, "Failed to open file", http.StatusInternalServerError)
-			return
+	// large downloads take longer than the write timeout of the server
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("failed to clear the write deadline of the download of job %s: %v", id.String(), err)
+	}
+
+	cw := &countingWriter{w: w}
+
+	if format == "reviews" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s_reviews.csv", id.String()))
+		w.Header().Set("Content-Type", "text/csv")
+
+		if err := s.svc.WriteReviewsCSV(ctx, cw, id.String()); err != nil {
+			downloadFailed(w, cw, "reviews", id.String(), err)
 		}
-		defer file.Close()
 
-		fileName := filepath.Base(filePath)
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+		return
+	}
+
+	name := id.String()
+	if len(fields) > 0 {
+		name += "_filtered"
+	}
+
+	if format == "excel" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.xlsx", name))
 		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
 
-		_, _ = io.Copy(w, file)
+		if err := s.svc.WriteExcel(ctx, cw, id.String(), fields); err != nil {
+			downloadFailed(w, cw, "excel", id.String(), err)
+		}
+
 		return
 	}
 
-	// CSV download with optional field filtering
-	csvPath, err := s.svc.GetCSV(ctx, id.String())
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if geo.IsFormat(format) {
+		contentType := "application/geo+json"
+		if format == geo.FormatKML {
+			contentType = "application/vnd.google-earth.kml+xml"
+		}
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", name, format))
+		w.Header().Set("Content-Type", contentType)
+
+		if err := s.svc.WriteGeo(ctx, cw, id.String(), format, fields); err != nil {
+			downloadFailed(w, cw, format, id.String(), err)
+		}
+
 		return
 	}
 
-	if len(fields) == 0 {
-		// No filtering, serve raw file
-		file, err := os.Open(csvPath)
-		if err != nil {
-			http.Error(w, "Failed to open file", http.StatusInternalServerError)
+	if format == "parquet" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.parquet", id.String()))
+		w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+
+		err := s.svc.WriteParquet(ctx, cw, id.String())
+		if errors.Is(err, ErrLegacyResults) {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+
 			return
 		}
-		defer file.Close()
 
-		fileName := filepath.Base(csvPath)
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
-		w.Header().Set("Content-Type", "text/csv")
+		if err != nil {
+			downloadFailed(w, cw, "parquet", id.String(), err)
+		}
 
-		_, _ = io.Copy(w, file)
 		return
 	}
 
-	// Filtered CSV
-	filteredData, err := s.svc.FilterCSV(csvPath, fields)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// CSV download with optional field filtering
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", name))
+	w.Header().Set("Content-Type", "text/csv")
+
+	if err := s.svc.WriteCSV(ctx, cw, id.String(), fields); err != nil {
+		downloadFailed(w, cw, "csv", id.String(), err)
+	}
+}
+
+// countingWriter counts the bytes written to the response, to tell whether
+// a download has started.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// downloadFailed reports an error of a download. Before anything was sent
+// it answers with an error, rather than an empty file; afterwards all it
+// can do is cut the download short.
+func downloadFailed(w http.ResponseWriter, cw *countingWriter, format, id string, err error) {
+	log.Printf("failed to write %s of job %s: %v", format, id, err)
+
+	if cw.n > 0 {
 		return
 	}
 
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s_filtered.csv", id.String()))
-	w.Header().Set("Content-Type", "text/csv")
-	w.Write(filteredData)
+	w.Header().Del("Content-Disposition")
+	http.Error(w, err.Error(), http.StatusInternalServerError)
 }
 
 func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
@@ -502,6 +819,12 @@ func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := s.svc.Delete(r.Context(), deleteID.String())
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 
@@ -579,11 +902,11 @@ func (s *Server) apiScrape(w http.ResponseWriter, r *http.Request) {
 	err = s.svc.Create(r.Context(), &newJob)
 	if err != nil {
 		ans := apiError{
-			Code:    http.StatusInternalServerError,
+			Code:    saveStatus(err),
 			Message: err.Error(),
 		}
 
-		renderJSON(w, http.StatusInternalServerError, ans)
+		renderJSON(w, ans.Code, ans)
 
 		return
 	}
@@ -639,32 +962,705 @@ func (s *Server) apiGetJob(w http.ResponseWriter, r *http.Request) {
 	renderJSON(w, http.StatusOK, job)
 }
 
-func (s *Server) apiDeleteJob(w http.ResponseWriter, r *http.Request) {
+// apiDiff compares the job with the base job given in the query, an
+// earlier crawl of the same queries.
+func (s *Server) apiDiff(w http.ResponseWriter, r *http.Request) {
 	id, ok := getIDFromRequest(r)
 	if !ok {
-		apiError := apiError{
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
 			Code:    http.StatusUnprocessableEntity,
 			Message: "Invalid ID",
-		}
-
-		renderJSON(w, http.StatusUnprocessableEntity, apiError)
+		})
 
 		return
 	}
 
-	err := s.svc.Delete(r.Context(), id.String())
+	baseID, err := uuid.Parse(r.URL.Query().Get("base"))
 	if err != nil {
-		apiError := apiError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		}
-
-		renderJSON(w, http.StatusInternalServerError, apiError)
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid base ID",
+		})
 
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	report, err := s.svc.Diff(r.Context(), baseID.String(), id.String())
+	if err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		renderJSON(w, http.StatusOK, report)
+	case "csv":
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s_diff.csv", id.String()))
+		w.Header().Set("Content-Type", "text/csv")
+
+		_ = diff.WriteCSV(w, &report)
+	default:
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "format must be json or csv",
+		})
+	}
+}
+
+func (s *Server) apiDeleteJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		apiError := apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		}
+
+		renderJSON(w, http.StatusUnprocessableEntity, apiError)
+
+		return
+	}
+
+	err := s.svc.Delete(r.Context(), id.String())
+	if errors.Is(err, ErrNotFound) {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: http.StatusText(http.StatusNotFound),
+		})
+
+		return
+	}
+
+	if err != nil {
+		apiError := apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+
+		renderJSON(w, http.StatusInternalServerError, apiError)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// apiJobEvents streams the events of a job as Server-Sent Events, starting
+// with its current status. The stream ends once the job is done.
+func (s *Server) apiJobEvents(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	// subscribe first, so no event is missed between the two
+	events, unsubscribe := s.svc.Subscribe(id.String())
+	defer unsubscribe()
+
+	job, err := s.svc.Get(r.Context(), id.String())
+	if err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: http.StatusText(http.StatusNotFound),
+		})
+
+		return
+	}
+
+	// the stream outlives the write timeout of the server
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(evt Event) bool {
+		data, err := json.Marshal(evt.Data)
+		if err != nil {
+			return false
+		}
+
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Type, data); err != nil {
+			return false
+		}
+
+		return rc.Flush() == nil
+	}
+
+	status := Event{Type: EventStatus, Data: StatusEvent{Status: job.Status, Count: job.Count}}
+	if !send(status) || IsFinal(job.Status) {
+		return
+	}
+
+	// the status is checked again now and then, in case the final status
+	// event was dropped
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case evt := <-events:
+			if !send(evt) {
+				return
+			}
+
+			if se, ok := evt.Data.(StatusEvent); ok && IsFinal(se.Status) {
+				return
+			}
+		case <-ticker.C:
+			job, err := s.svc.Get(r.Context(), id.String())
+			if err != nil {
+				return
+			}
+
+			if IsFinal(job.Status) {
+				send(Event{Type: EventStatus, Data: StatusEvent{Status: job.Status, Count: job.Count}})
+
+				return
+			}
+
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) apiCancelJob(w http.ResponseWriter, r *http.Request) {
+	s.apiChangeJobStatus(w, r, s.svc.Cancel)
+}
+
+func (s *Server) apiPauseJob(w http.ResponseWriter, r *http.Request) {
+	s.apiChangeJobStatus(w, r, s.svc.Pause)
+}
+
+func (s *Server) apiResumeJob(w http.ResponseWriter, r *http.Request) {
+	s.apiChangeJobStatus(w, r, s.svc.Resume)
+}
+
+// apiChangeJobStatus applies a status change of the service to the job of
+// the request and returns the updated job.
+func (s *Server) apiChangeJobStatus(w http.ResponseWriter, r *http.Request, change func(context.Context, string) (Job, error)) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	job, err := change(r.Context(), id.String())
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: http.StatusText(http.StatusNotFound),
+		})
+	case errors.Is(err, ErrInvalidStatus):
+		renderJSON(w, http.StatusConflict, apiError{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+		})
+	case err != nil:
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+	default:
+		renderJSON(w, http.StatusOK, job)
+	}
+}
+
+// saveStatus is the status code of an error saving a job or a schedule.
+// The webhook URLs are only refused by the service, which knows whether
+// private addresses are allowed.
+func saveStatus(err error) int {
+	if errors.Is(err, ErrPrivateWebhook) {
+		return http.StatusUnprocessableEntity
+	}
+
+	return http.StatusInternalServerError
+}
+
+type apiScheduleRequest struct {
+	Name          string  `json:"name"`
+	Cron          string  `json:"cron"`
+	SkipIfRunning bool    `json:"skip_if_running"`
+	Enabled       *bool   `json:"enabled"`
+	Data          JobData `json:"data"`
+}
+
+// apiScheduleUpdate holds the fields of a schedule to change, the others
+// are nil.
+type apiScheduleUpdate struct {
+	Name          *string  `json:"name"`
+	Cron          *string  `json:"cron"`
+	SkipIfRunning *bool    `json:"skip_if_running"`
+	Enabled       *bool    `json:"enabled"`
+	Data          *JobData `json:"data"`
+}
+
+func (s *Server) apiCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req apiScheduleRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	schedule := Schedule{
+		ID:            uuid.New().String(),
+		Name:          req.Name,
+		Cron:          req.Cron,
+		Data:          req.Data,
+		SkipIfRunning: req.SkipIfRunning,
+		Enabled:       req.Enabled == nil || *req.Enabled,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	// convert to seconds
+	schedule.Data.MaxTime *= time.Second
+
+	if err := schedule.Validate(); err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := s.svc.CreateSchedule(r.Context(), &schedule); err != nil {
+		renderJSON(w, saveStatus(err), apiError{
+			Code:    saveStatus(err),
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusCreated, schedule)
+}
+
+func (s *Server) apiGetSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := s.svc.AllSchedules(r.Context())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, schedules)
+}
+
+func (s *Server) apiGetSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := s.scheduleFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	renderJSON(w, http.StatusOK, schedule)
+}
+
+func (s *Server) apiUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := s.scheduleFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req apiScheduleUpdate
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if req.Name != nil {
+		schedule.Name = *req.Name
+	}
+
+	if req.Cron != nil {
+		schedule.Cron = *req.Cron
+	}
+
+	if req.SkipIfRunning != nil {
+		schedule.SkipIfRunning = *req.SkipIfRunning
+	}
+
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+
+	if req.Data != nil {
+		schedule.Data = *req.Data
+		// convert to seconds
+		schedule.Data.MaxTime *= time.Second
+	}
+
+	if err := schedule.Validate(); err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if err := s.svc.UpdateSchedule(r.Context(), &schedule); err != nil {
+		renderJSON(w, saveStatus(err), apiError{
+			Code:    saveStatus(err),
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, schedule)
+}
+
+func (s *Server) apiDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	err := s.svc.DeleteSchedule(r.Context(), id.String())
+	if errors.Is(err, ErrNotFound) {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: http.StatusText(http.StatusNotFound),
+		})
+
+		return
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// apiGetScheduleRuns returns the run history of a schedule, latest first.
+func (s *Server) apiGetScheduleRuns(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := s.scheduleFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 100
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			renderJSON(w, http.StatusUnprocessableEntity, apiError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "Invalid limit",
+			})
+
+			return
+		}
+
+		limit = n
+	}
+
+	runs, err := s.svc.ScheduleRuns(r.Context(), schedule.ID, limit)
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if runs == nil {
+		runs = []ScheduleRun{}
+	}
+
+	renderJSON(w, http.StatusOK, runs)
+}
+
+// apiGetDeliveries returns the webhook deliveries of a job, latest first.
+func (s *Server) apiGetDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	deliveries, err := s.svc.Deliveries(r.Context(), id.String())
+	if errors.Is(err, ErrNotFound) {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: http.StatusText(http.StatusNotFound),
+		})
+
+		return
+	}
+
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []Delivery{}
+	}
+
+	renderJSON(w, http.StatusOK, deliveries)
+}
+
+// apiGetPlaces returns a page of the places of a job, filtered and sorted
+// by the query.
+func (s *Server) apiGetPlaces(w http.ResponseWriter, r *http.Request) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return
+	}
+
+	params, err := parsePlaceParams(r.URL.Query())
+	if err != nil {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	params.JobID = id.String()
+
+	page, err := s.svc.Places(r.Context(), &params)
+
+	switch {
+	case err == nil:
+		renderJSON(w, http.StatusOK, page)
+	case errors.Is(err, ErrNotFound):
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: http.StatusText(http.StatusNotFound),
+		})
+	case errors.Is(err, ErrNoPlaces):
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+	default:
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		})
+	}
+}
+
+func parsePlaceParams(q url.Values) (PlaceParams, error) {
+	params := PlaceParams{
+		Category: q.Get("category"),
+		Query:    q.Get("q"),
+		Sort:     q.Get("sort"),
+	}
+
+	floats := []struct {
+		name string
+		dst  *float64
+	}{
+		{"min_rating", &params.MinRating},
+		{"lat", &params.Lat},
+		{"lon", &params.Lon},
+	}
+
+	for _, f := range floats {
+		if v := q.Get(f.name); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return PlaceParams{}, fmt.Errorf("invalid %s", f.name)
+			}
+
+			*f.dst = n
+		}
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"min_reviews", &params.MinReviews},
+		{"limit", &params.Limit},
+	}
+
+	for _, f := range ints {
+		if v := q.Get(f.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return PlaceParams{}, fmt.Errorf("invalid %s", f.name)
+			}
+
+			*f.dst = n
+		}
+	}
+
+	bools := []struct {
+		name string
+		dst  *bool
+	}{
+		{"has_website", &params.HasWebsite},
+		{"has_phone", &params.HasPhone},
+		{"has_email", &params.HasEmail},
+	}
+
+	for _, f := range bools {
+		if v := q.Get(f.name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return PlaceParams{}, fmt.Errorf("invalid %s", f.name)
+			}
+
+			*f.dst = b
+		}
+	}
+
+	if v := q.Get("bbox"); v != "" {
+		parts := strings.Split(v, ",")
+		if len(parts) != 4 {
+			return PlaceParams{}, errors.New("bbox must be min_lat,min_lon,max_lat,max_lon")
+		}
+
+		var bbox [4]float64
+
+		for i, p := range parts {
+			n, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return PlaceParams{}, errors.New("bbox must be min_lat,min_lon,max_lat,max_lon")
+			}
+
+			bbox[i] = n
+		}
+
+		if bbox[0] > bbox[2] || bbox[1] > bbox[3] {
+			return PlaceParams{}, errors.New("bbox minimums must not be above its maximums")
+		}
+
+		params.BBox = &bbox
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := ParsePlaceCursor(v)
+		if err != nil {
+			return PlaceParams{}, err
+		}
+
+		params.After = cursor
+	}
+
+	return params, nil
+}
+
+// apiGetUsage returns the quota of the caller's owner and what it uses of it.
+func (s *Server) apiGetUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := s.svc.Usage(r.Context())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, usage)
+}
+
+// apiGetProxies returns the health of the proxies of the server and of the
+// running jobs of the caller.
+func (s *Server) apiGetProxies(w http.ResponseWriter, r *http.Request) {
+	pools, err := s.svc.ProxyPools(r.Context())
+	if err != nil {
+		renderJSON(w, http.StatusInternalServerError, apiError{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return
+	}
+
+	renderJSON(w, http.StatusOK, pools)
+}
+
+// scheduleFromRequest loads the schedule with the id of the request. It
+// writes the error response and returns false when there is none.
+func (s *Server) scheduleFromRequest(w http.ResponseWriter, r *http.Request) (Schedule, bool) {
+	id, ok := getIDFromRequest(r)
+	if !ok {
+		renderJSON(w, http.StatusUnprocessableEntity, apiError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Invalid ID",
+		})
+
+		return Schedule{}, false
+	}
+
+	schedule, err := s.svc.GetSchedule(r.Context(), id.String())
+	if err != nil {
+		renderJSON(w, http.StatusNotFound, apiError{
+			Code:    http.StatusNotFound,
+			Message: http.StatusText(http.StatusNotFound),
+		})
+
+		return Schedule{}, false
+	}
+
+	return schedule, true
 }
 
 func renderJSON(w http.ResponseWriter, code int, data any) {
@@ -678,6 +1674,166 @@ func formatDate(t time.Time) string {
 	return t.Format("Jan 02, 2006 15:04:05")
 }
 
+// sessionCookie holds the dashboard session.
+const sessionCookie = "gms_session"
+
+type loginData struct {
+	Error string
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	if !s.auth {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+		return
+	}
+
+	tmpl, ok := s.tmpl["static/templates/login.html"]
+	if !ok {
+		http.Error(w, "missing tpl", http.StatusInternalServerError)
+
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		_ = tmpl.Execute(w, loginData{})
+
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	session, err := s.svc.Login(r.Context(), strings.TrimSpace(r.Form.Get("token")))
+	if errors.Is(err, ErrUnauthorized) {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		_ = tmpl.Execute(w, loginData{Error: "Invalid or revoked token"})
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session,
+		Path:     "/",
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if c, err := r.Cookie(sessionCookie); err == nil && s.auth {
+		if err := s.svc.Logout(r.Context(), c.Value); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// authenticate lets through requests with an API token, in the Authorization
+// header as a bearer token or in the X-API-Key header, and requests with a
+// dashboard session cookie. The dashboard itself uses the API with the
+// cookie, for the live progress. The owner of the token is set on the
+// context of the request, except for admin tokens.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" || strings.HasPrefix(r.URL.Path, "/static/") {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		var (
+			token Token
+			err   error
+		)
+
+		switch secret := requestToken(r); {
+		case secret != "":
+			token, err = s.svc.Authenticate(r.Context(), secret)
+		default:
+			c, cerr := r.Cookie(sessionCookie)
+			if cerr != nil {
+				err = ErrUnauthorized
+
+				break
+			}
+
+			token, err = s.svc.AuthenticateSession(r.Context(), c.Value)
+		}
+
+		switch {
+		case err == nil:
+			next.ServeHTTP(w, r.WithContext(token.Scope(r.Context())))
+		case !errors.Is(err, ErrUnauthorized):
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		case strings.HasPrefix(r.URL.Path, "/api/"):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+
+			ans := apiError{
+				Code:    http.StatusUnauthorized,
+				Message: "missing or invalid API token",
+			}
+
+			renderJSON(w, http.StatusUnauthorized, ans)
+		case r.Header.Get("HX-Request") != "":
+			// htmx does not follow redirects for the whole page
+			w.Header().Set("HX-Redirect", "/login")
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodGet:
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+		default:
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		}
+	})
+}
+
+func requestToken(r *http.Request) string {
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(v)
+	}
+
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
 func securityHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-Content-Type-Options", "nosniff")
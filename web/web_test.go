@@ -0,0 +1,240 @@
+package web_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/gmaps"
+	"github.com/gosom/google-maps-scraper/web"
+	"github.com/gosom/google-maps-scraper/web/sqlite"
+)
+
+func newTestService(t *testing.T, opts ...web.ServiceOption) (*web.Service, web.JobRepository) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	repo, err := sqlite.New(filepath.Join(dir, "jobs.db"))
+	require.NoError(t, err)
+
+	return web.NewService(repo, dir, opts...), repo
+}
+
+func createTestJob(t *testing.T, repo web.JobRepository, owner, status string) web.Job {
+	t.Helper()
+
+	job := web.Job{
+		ID:     uuid.New().String(),
+		Name:   "coffee",
+		Owner:  owner,
+		Date:   time.Now().UTC(),
+		Status: status,
+		Data: web.JobData{
+			Keywords: []string{"coffee"},
+			Lang:     "en",
+			Depth:    1,
+			MaxTime:  time.Minute,
+		},
+	}
+
+	require.NoError(t, repo.Create(t.Context(), &job))
+
+	return job
+}
+
+func Test_DownloadReviews(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusOK)
+
+	_, err := repo.(web.PlaceRepository).AddPlaces(t.Context(), job.ID, []*gmaps.Entry{
+		{
+			DataID:      "0x1",
+			Cid:         "1",
+			Title:       "Cafe A",
+			UserReviews: []gmaps.Review{{ID: "a1", Name: "Ana", Rating: 5, Description: "Great"}},
+			UserReviewsExtended: []gmaps.Review{
+				{ID: "a1", Name: "Ana", Rating: 5, Description: "Great"},
+				{ID: "a2", Name: "Ben", Rating: 2, Description: "Cold"},
+			},
+		},
+		{DataID: "0x2", Cid: "2", Title: "Cafe B"},
+	})
+	require.NoError(t, err)
+
+	srv, err := web.New(svc, ":0")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?format=reviews&id="+job.ID, http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, (&gmaps.ReviewRow{}).CsvHeaders(), records[0])
+	require.Equal(t, []string{"0x1", "a1"}, []string{records[1][0], records[1][2]})
+	require.Equal(t, []string{"0x1", "a2"}, []string{records[2][0], records[2][2]})
+
+	// like every other format, an unknown job is not found
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?format=reviews&id="+uuid.New().String(), http.NoBody))
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Empty(t, rec.Header().Get("Content-Disposition"))
+}
+
+func Test_DownloadCSV(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusOK)
+
+	// more places than a page of the table
+	entries := make([]*gmaps.Entry, 0, 501)
+	for i := range 501 {
+		entries = append(entries, &gmaps.Entry{DataID: fmt.Sprintf("0x%d", i), Title: fmt.Sprintf("Cafe %d", i), Phone: "+30 210"})
+	}
+
+	_, err := svc.AddPlaces(t.Context(), job.ID, entries...)
+	require.NoError(t, err)
+
+	srv, err := web.New(svc, ":0")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?id="+job.ID, http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	require.Equal(t, "attachment; filename="+job.ID+".csv", rec.Header().Get("Content-Disposition"))
+
+	body := strings.TrimPrefix(rec.Body.String(), "\ufeff")
+
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 502)
+	require.Equal(t, (&gmaps.Entry{}).CsvHeaders(), records[0])
+
+	// in the order the places were written
+	title := slices.Index(records[0], "title")
+	require.Equal(t, "Cafe 0", records[1][title])
+	require.Equal(t, "Cafe 500", records[501][title])
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?fields=title,phone&id="+job.ID, http.NoBody))
+
+	require.Equal(t, "attachment; filename="+job.ID+"_filtered.csv", rec.Header().Get("Content-Disposition"))
+
+	records, err = csv.NewReader(strings.NewReader(strings.TrimPrefix(rec.Body.String(), "\ufeff"))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 502)
+	require.Equal(t, []string{"title", "phone"}, records[0])
+	require.Equal(t, []string{"Cafe 7", "+30 210"}, records[8])
+}
+
+func Test_DownloadFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := sqlite.New(filepath.Join(dir, "jobs.db"))
+	require.NoError(t, err)
+
+	svc := web.NewService(repo, dir)
+
+	// the results file of a job from before the results were stored in the
+	// database, which can't be read
+	job := createTestJob(t, repo, "", web.StatusOK)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, job.ID+".csv"), []byte("title,phone\n\"Cafe,1\n"), 0o600))
+
+	srv, err := web.New(svc, ":0")
+	require.NoError(t, err)
+
+	// nothing was sent, so the error is not a truncated file
+	for _, format := range []string{"", "excel", "geojson", "reviews"} {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?format="+format+"&id="+job.ID, http.NoBody))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code, format)
+		require.Empty(t, rec.Header().Get("Content-Disposition"), format)
+	}
+}
+
+func Test_DownloadGeoEmpty(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := sqlite.New(filepath.Join(dir, "jobs.db"))
+	require.NoError(t, err)
+
+	svc := web.NewService(repo, dir)
+
+	// the empty results file of a job from before the results were stored
+	// in the database, without a header either
+	job := createTestJob(t, repo, "", web.StatusOK)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, job.ID+".csv"), nil, 0o600))
+
+	srv, err := web.New(svc, ":0")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?format=geojson&id="+job.ID, http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc struct {
+		Type     string `json:"type"`
+		Features []any  `json:"features"`
+	}
+
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Equal(t, "FeatureCollection", doc.Type)
+	require.Empty(t, doc.Features)
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?format=kml&id="+job.ID, http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "<kml")
+}
+
+func Test_DownloadWriteTimeout(t *testing.T) {
+	svc, repo := newTestService(t)
+
+	job := createTestJob(t, repo, "", web.StatusOK)
+
+	_, err := svc.AddPlaces(t.Context(), job.ID, &gmaps.Entry{DataID: "0x1", Title: "Cafe"})
+	require.NoError(t, err)
+
+	srv, err := web.New(svc, ":0")
+	require.NoError(t, err)
+
+	// a download is not cut off by the write timeout of the server
+	ts := httptest.NewUnstartedServer(srv.Handler())
+	ts.Config.WriteTimeout = time.Nanosecond
+	ts.Start()
+
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/download?id=" + job.ID)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), "Cafe")
+}
@@ -0,0 +1,390 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventJobCompleted = "job.completed"
+	EventJobFailed    = "job.failed"
+	EventJobCancelled = "job.cancelled"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+const (
+	// webhookAttempts is how many times a delivery is tried before it
+	// fails for good.
+	webhookAttempts = 8
+	// webhookBackoff is the wait after the first failed attempt, doubled
+	// after each further one.
+	webhookBackoff = 30 * time.Second
+	webhookTimeout = 10 * time.Second
+	// webhookBatch is how many due deliveries are sent at a time.
+	webhookBatch = 20
+)
+
+type DeliveryParams struct {
+	JobID string
+	// DueBefore selects the pending deliveries whose next attempt is not
+	// after the time, oldest first.
+	DueBefore time.Time
+	Limit     int
+}
+
+// DeliveryRepository stores the webhook deliveries and their attempts. It is
+// optional, the service checks if the job repository implements it.
+type DeliveryRepository interface {
+	CreateDelivery(context.Context, *Delivery) error
+	UpdateDelivery(context.Context, *Delivery) error
+	SelectDeliveries(context.Context, DeliveryParams) ([]Delivery, error)
+}
+
+// Delivery is a webhook call for a job event, with the state of its
+// attempts.
+type Delivery struct {
+	ID           string    `json:"id"`
+	JobID        string    `json:"job_id"`
+	Event        string    `json:"event"`
+	URL          string    `json:"url"`
+	Payload      string    `json:"payload"`
+	Status       string    `json:"status"`
+	Attempts     int       `json:"attempts"`
+	NextAttempt  time.Time `json:"next_attempt,omitzero"`
+	LastError    string    `json:"last_error,omitempty"`
+	ResponseCode int       `json:"response_code,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	DeliveredAt  time.Time `json:"delivered_at,omitzero"`
+}
+
+// WebhookPayload is the JSON body of a webhook call.
+type WebhookPayload struct {
+	Event           string    `json:"event"`
+	JobID           string    `json:"job_id"`
+	Name            string    `json:"name"`
+	Owner           string    `json:"owner,omitempty"`
+	Status          string    `json:"status"`
+	Count           int       `json:"count"`
+	Keywords        int       `json:"keywords"`
+	CreatedAt       time.Time `json:"created_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	// Downloads has the links of the results, by format. It is empty for
+	// jobs without results.
+	Downloads map[string]string `json:"downloads,omitempty"`
+}
+
+// WebhookConfig configures the webhooks of the service.
+type WebhookConfig struct {
+	// DefaultURL is called for the jobs without a webhook URL of their own.
+	DefaultURL string
+	// Secret signs the payloads with HMAC-SHA256. Without it no webhook is
+	// queued or sent.
+	Secret string
+	// BaseURL is the address of the server for the download links.
+	BaseURL string
+	// AllowPrivate lets the webhooks call loopback, link-local and private
+	// addresses, such as a receiver on the same host. They are refused by
+	// default, so a job can't make the server call the services of its
+	// network or the metadata endpoint of its cloud.
+	AllowPrivate bool
+}
+
+// ServiceOption configures the Service.
+type ServiceOption func(*Service)
+
+func WithWebhooks(cfg WebhookConfig) ServiceOption {
+	return func(s *Service) {
+		s.webhooks = cfg
+		s.webhookClient = newWebhookClient(cfg.AllowPrivate)
+	}
+}
+
+// NotifyJob queues a webhook call for the job when it completed, failed or
+// was cancelled, and it has a webhook URL or there is a default one. The
+// job is read again, so deleted jobs are not reported. duration is how long
+// the job ran. Without a secret it returns ErrNoWebhookSecret rather than
+// queue a call that can't be signed.
+func (s *Service) NotifyJob(ctx context.Context, id string, duration time.Duration) error {
+	if s.deliveries == nil {
+		return nil
+	}
+
+	job, err := s.repo.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	target := job.Data.WebhookURL
+	if target == "" {
+		target = s.webhooks.DefaultURL
+	}
+
+	var event string
+
+	switch job.Status {
+	case StatusOK:
+		event = EventJobCompleted
+	case StatusFailed:
+		event = EventJobFailed
+	case StatusCancelled:
+		event = EventJobCancelled
+	}
+
+	if target == "" || event == "" {
+		return nil
+	}
+
+	if s.webhooks.Secret == "" {
+		return ErrNoWebhookSecret
+	}
+
+	now := time.Now().UTC()
+
+	payload := WebhookPayload{
+		Event:           event,
+		JobID:           job.ID,
+		Name:            job.Name,
+		Owner:           job.Owner,
+		Status:          job.Status,
+		Count:           job.Count,
+		Keywords:        len(job.Data.Keywords),
+		CreatedAt:       job.Date,
+		FinishedAt:      now,
+		DurationSeconds: duration.Seconds(),
+	}
+
+	if job.Count > 0 && s.webhooks.BaseURL != "" {
+		download := s.webhooks.BaseURL + "/api/v1/jobs/" + job.ID + "/download"
+
+		payload.Downloads = map[string]string{
+			"csv":   download,
+			"excel": download + "?format=excel",
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	delivery := Delivery{
+		ID:          uuid.New().String(),
+		JobID:       job.ID,
+		Event:       event,
+		URL:         target,
+		Payload:     string(body),
+		Status:      DeliveryPending,
+		NextAttempt: now,
+		CreatedAt:   now,
+	}
+
+	return s.deliveries.CreateDelivery(ctx, &delivery)
+}
+
+// Deliveries returns the webhook deliveries of the job, latest first.
+func (s *Service) Deliveries(ctx context.Context, id string) ([]Delivery, error) {
+	if s.deliveries == nil {
+		return nil, ErrNoWebhooks
+	}
+
+	if err := s.checkOwner(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.deliveries.SelectDeliveries(ctx, DeliveryParams{JobID: id})
+}
+
+// DeliverWebhooks sends the deliveries that are due at now. A failed
+// attempt is tried again later, waiting twice as long after each one, and
+// the delivery fails for good after webhookAttempts attempts. Without a
+// secret the deliveries wait, they are never sent unsigned.
+func (s *Service) DeliverWebhooks(ctx context.Context, now time.Time) error {
+	if s.deliveries == nil || s.webhooks.Secret == "" {
+		return nil
+	}
+
+	due, err := s.deliveries.SelectDeliveries(ctx, DeliveryParams{DueBefore: now, Limit: webhookBatch})
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		d := &due[i]
+
+		d.Attempts++
+
+		code, err := s.sendWebhook(ctx, d)
+
+		d.ResponseCode = code
+
+		switch {
+		case err == nil:
+			d.Status = DeliveryDelivered
+			d.DeliveredAt = time.Now().UTC()
+			d.NextAttempt = time.Time{}
+			d.LastError = ""
+		case d.Attempts >= webhookAttempts:
+			d.Status = DeliveryFailed
+			d.NextAttempt = time.Time{}
+			d.LastError = err.Error()
+		default:
+			d.NextAttempt = now.Add(webhookBackoff << (d.Attempts - 1))
+			d.LastError = err.Error()
+		}
+
+		if err := s.deliveries.UpdateDelivery(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) sendWebhook(ctx context.Context, d *Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "google-maps-scraper-webhook")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", d.ID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(s.webhooks.Secret, timestamp, []byte(d.Payload)))
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of the timestamp and the body,
+// joined with a dot, as sent in the X-Webhook-Signature header. Receivers
+// compute it the same way to check a call.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid webhook url")
+	}
+
+	return nil
+}
+
+// checkWebhookURL refuses the webhook URLs of private addresses, unless
+// they are allowed. The host names that resolve to one are refused when
+// the delivery connects.
+func (s *Service) checkWebhookURL(raw string) error {
+	if raw == "" || s.webhooks.AllowPrivate {
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateWebhook
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil && privateAddr(addr) {
+		return ErrPrivateWebhook
+	}
+
+	return nil
+}
+
+// privateAddr reports whether the address is a loopback, link-local,
+// private or unspecified one.
+func privateAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsPrivate() || addr.IsUnspecified()
+}
+
+// newWebhookClient returns the client of the deliveries. Unless private
+// addresses are allowed, it checks the address of each connection, after
+// the host name was resolved and on redirects too, and it does not use the
+// proxy of the environment, which would connect in its place.
+func newWebhookClient(allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return http.DefaultClient
+	}
+
+	dialer := net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+
+			if privateAddr(addr.Addr()) {
+				return ErrPrivateWebhook
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Transport: transport}
+}
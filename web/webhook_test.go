@@ -0,0 +1,188 @@
+package web_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gosom/google-maps-scraper/web"
+)
+
+func Test_SignWebhook(t *testing.T) {
+	sig := web.SignWebhook("secret", "1700000000", []byte(`{"event":"job.completed"}`))
+	require.Equal(t, "e33f34cc0b46f4e752fe75a10d7177366fd795c052ed09dfa63608265c13be69", sig)
+
+	require.NotEqual(t, sig, web.SignWebhook("other", "1700000000", []byte(`{"event":"job.completed"}`)))
+	require.NotEqual(t, sig, web.SignWebhook("secret", "1700000001", []byte(`{"event":"job.completed"}`)))
+}
+
+func Test_NotifyJobWithoutSecret(t *testing.T) {
+	svc, repo := newTestService(t, web.WithWebhooks(web.WebhookConfig{DefaultURL: "http://127.0.0.1:1/hook"}))
+
+	job := createTestJob(t, repo, "", web.StatusOK)
+
+	require.ErrorIs(t, svc.NotifyJob(t.Context(), job.ID, time.Minute), web.ErrNoWebhookSecret)
+
+	deliveries, err := svc.Deliveries(t.Context(), job.ID)
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
+
+func Test_DeliverWebhooks(t *testing.T) {
+	var (
+		status    atomic.Int32
+		calls     atomic.Int32
+		signature atomic.Value
+	)
+
+	status.Store(http.StatusInternalServerError)
+
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		body, _ := io.ReadAll(r.Body)
+
+		want := "sha256=" + web.SignWebhook("secret", r.Header.Get("X-Webhook-Timestamp"), body)
+		signature.Store(r.Header.Get("X-Webhook-Signature") == want)
+
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer hook.Close()
+
+	// the test server listens on a loopback address
+	svc, repo := newTestService(t, web.WithWebhooks(web.WebhookConfig{DefaultURL: hook.URL, Secret: "secret", AllowPrivate: true}))
+
+	job := createTestJob(t, repo, "", web.StatusOK)
+
+	require.NoError(t, svc.NotifyJob(t.Context(), job.ID, time.Minute))
+
+	delivery := func() web.Delivery {
+		deliveries, err := svc.Deliveries(t.Context(), job.ID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+
+		return deliveries[0]
+	}
+
+	require.Equal(t, web.EventJobCompleted, delivery().Event)
+
+	now := time.Now().UTC().Truncate(time.Second).Add(time.Second)
+
+	require.NoError(t, svc.DeliverWebhooks(t.Context(), now))
+	require.EqualValues(t, 1, calls.Load())
+	require.True(t, signature.Load().(bool))
+
+	d := delivery()
+	require.Equal(t, web.DeliveryPending, d.Status)
+	require.Equal(t, 1, d.Attempts)
+	require.Equal(t, http.StatusInternalServerError, d.ResponseCode)
+	require.Contains(t, d.LastError, "500")
+	require.Equal(t, now.Add(30*time.Second), d.NextAttempt)
+
+	// the next attempt waits for the backoff
+	require.NoError(t, svc.DeliverWebhooks(t.Context(), now.Add(29*time.Second)))
+	require.EqualValues(t, 1, calls.Load())
+
+	// which doubles after each attempt, until the delivery fails for good
+	wait := 30 * time.Second
+
+	for attempt := 2; attempt <= 8; attempt++ {
+		now = now.Add(wait)
+		wait *= 2
+
+		require.NoError(t, svc.DeliverWebhooks(t.Context(), now))
+
+		d = delivery()
+		require.Equal(t, attempt, d.Attempts)
+
+		if attempt < 8 {
+			require.Equal(t, web.DeliveryPending, d.Status)
+			require.Equal(t, now.Add(wait), d.NextAttempt)
+		}
+	}
+
+	require.Equal(t, web.DeliveryFailed, d.Status)
+	require.True(t, d.NextAttempt.IsZero())
+
+	require.NoError(t, svc.DeliverWebhooks(t.Context(), now.Add(24*time.Hour)))
+	require.EqualValues(t, 8, calls.Load())
+
+	// a delivery that goes through is not sent again
+	status.Store(http.StatusNoContent)
+
+	require.NoError(t, svc.NotifyJob(t.Context(), job.ID, time.Minute))
+	require.NoError(t, svc.DeliverWebhooks(t.Context(), now))
+	require.NoError(t, svc.DeliverWebhooks(t.Context(), now.Add(time.Hour)))
+	require.EqualValues(t, 9, calls.Load())
+	require.True(t, signature.Load().(bool))
+}
+
+func Test_WebhookPrivate(t *testing.T) {
+	var calls atomic.Int32
+
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hook.Close()
+
+	local := strings.Replace(hook.URL, "127.0.0.1", "localhost", 1)
+
+	svc, repo := newTestService(t, web.WithWebhooks(web.WebhookConfig{DefaultURL: local, Secret: "secret"}))
+
+	// the jobs can't target the host or its networks
+	for _, target := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hook",
+		"http://192.168.1.10/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	} {
+		job := web.Job{ID: uuid.New().String(), Data: web.JobData{WebhookURL: target}}
+
+		require.ErrorIs(t, svc.Create(t.Context(), &job), web.ErrPrivateWebhook, target)
+	}
+
+	job := web.Job{ID: uuid.New().String(), Data: web.JobData{WebhookURL: "https://example.com/hook"}}
+	require.NoError(t, svc.Create(t.Context(), &job))
+
+	srv, err := web.New(svc, ":0")
+	require.NoError(t, err)
+
+	body := `{"name":"coffee","keywords":["coffee"],"lang":"en","depth":1,"max_time":600,"webhook_url":"http://169.254.169.254/"}`
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.Contains(t, rec.Body.String(), web.ErrPrivateWebhook.Error())
+
+	// nor a host name that resolves to one, the default URL included
+	done := createTestJob(t, repo, "", web.StatusOK)
+
+	require.NoError(t, svc.NotifyJob(t.Context(), done.ID, time.Minute))
+	require.NoError(t, svc.DeliverWebhooks(t.Context(), time.Now().UTC().Add(time.Second)))
+	require.Zero(t, calls.Load())
+
+	deliveries, err := svc.Deliveries(t.Context(), done.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, web.DeliveryPending, deliveries[0].Status)
+	require.Contains(t, deliveries[0].LastError, web.ErrPrivateWebhook.Error())
+
+	// unless private addresses are allowed
+	svc, _ = newTestService(t, web.WithWebhooks(web.WebhookConfig{Secret: "secret", AllowPrivate: true}))
+
+	job = web.Job{ID: uuid.New().String(), Data: web.JobData{WebhookURL: hook.URL}}
+	require.NoError(t, svc.Create(t.Context(), &job))
+}